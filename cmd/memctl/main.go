@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/database"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/services"
+	"memology-backend/pkg/auth"
+
+	"gorm.io/gorm"
+)
+
+// command - подкоманда вида "<группа> <действие>"
+type command struct {
+	name string
+	args string
+	help string
+	run  func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = []command{
+	{name: "users list", args: "[-limit N] [-offset N]", help: "list users", run: usersList},
+	{name: "users deactivate", args: "<user>", help: "block a user and revoke all sessions", run: usersDeactivate},
+	{name: "users activate", args: "<user>", help: "unblock a user", run: usersActivate},
+	{name: "users reset-password", args: "[-password P] <user>", help: "set a new password (random if omitted) and revoke all sessions", run: usersResetPassword},
+	{name: "memes stuck", args: "[-older-than D]", help: "list pending, processing and failed memes whose status has not changed for D", run: memesStuck},
+	{name: "memes requeue", args: "[-older-than D] [-timeout D] [id...]", help: "poll AI tasks of the given (or all stuck) memes again until they finish", run: memesRequeue},
+	{name: "memes fail", args: "[-older-than D] [id...]", help: "mark the given (or all stuck) memes as failed", run: memesFail},
+	{name: "memes regenerate", args: "[-timeout D] <id>", help: "send the prompt of an AI meme to the AI service again and wait for the new image", run: memesRegenerate},
+	{name: "memes purge", args: "[-older-than D]", help: "permanently delete memes that stayed in trash longer than D (TRASH_RETENTION)", run: memesPurge},
+	{name: "storage gc", args: "[-dry-run] [-grace D]", help: "delete storage objects not referenced by any meme", run: storageGC},
+	{name: "queue stats", args: "[-older-than D] [-metrics-url URL]", help: "meme counts by status; with -metrics-url also the in-process queue of a running server", run: queueStats},
+}
+
+// memctl - административные операции без ручного SQL: пользователи, зависшие мемы,
+// корзина, сборка мусора в хранилище и состояние очереди генерации
+func main() {
+	jsonOutput := flag.Bool("json", false, "print results as JSON")
+	verbose := flag.Bool("v", false, "log progress to stderr")
+	configPath := flag.String("config", "", "path to a YAML or TOML config file (default: CONFIG_FILE)")
+	flag.Usage = usage
+	flag.Parse()
+
+	// Логи идут в stderr, чтобы не смешиваться с выводом для скриптов
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0]+" "+args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "memctl: unknown command %q\n\n", args[0]+" "+args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.LoadFile(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "memctl: invalid configuration:", err)
+		os.Exit(1)
+	}
+
+	a := newApp(cfg, *jsonOutput)
+	if err := cmd.run(ctx, a, args[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: memctl %s %s\n", cmd.name, cmd.args)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "memctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: memctl [-json] [-v] [-config FILE] <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.help)
+	}
+	w.Flush()
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "<user> is an id, username or email. Durations use Go syntax: 30m, 24h.")
+}
+
+var errUsage = errors.New("invalid arguments")
+
+// parseFlags разбирает флаги подкоманды; ошибка разбора печатает usage подкоманды
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "memctl:", err)
+		return errUsage
+	}
+	return nil
+}
+
+// app - зависимости подкоманд. Хранилище и AI-сервис создаются только для команд,
+// которым они нужны
+type app struct {
+	cfg  *config.Config
+	json bool
+	out  io.Writer
+
+	db       *gorm.DB
+	userRepo repository.UserRepository
+	memeRepo repository.MemeRepository
+
+	storageSvc services.MinIOService
+	aiSvc      services.AIService
+}
+
+func newApp(cfg *config.Config, jsonOutput bool) *app {
+	db := database.Connect(&cfg.Database)
+	return &app{
+		cfg:      cfg,
+		json:     jsonOutput,
+		out:      os.Stdout,
+		db:       db,
+		userRepo: repository.NewUserRepository(db),
+		memeRepo: repository.NewMemeRepository(db),
+	}
+}
+
+func (a *app) userService() services.UserService {
+	return services.NewUserService(a.userRepo)
+}
+
+func (a *app) authService() services.AuthService {
+	jwtManager := auth.NewJWTManager(a.cfg.JWT.SecretKey, a.cfg.JWT.AccessTokenTTL, a.cfg.JWT.RefreshTokenTTL)
+	return services.NewAuthService(a.userRepo, repository.NewSessionRepository(a.db), jwtManager)
+}
+
+func (a *app) storage() (services.MinIOService, error) {
+	if a.storageSvc == nil {
+		storageSvc, err := services.NewStorageService(a.cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		}
+		a.storageSvc = storageSvc
+	}
+	return a.storageSvc, nil
+}
+
+func (a *app) ai() services.AIService {
+	if a.aiSvc == nil {
+		a.aiSvc = services.NewAIService(&a.cfg.AI)
+	}
+	return a.aiSvc
+}
+
+// print выводит результат как JSON или таблицей, которую заполняет text
+func (a *app) print(result any, text func(w io.Writer)) error {
+	if a.json {
+		encoder := json.NewEncoder(a.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	text(w)
+	return w.Flush()
+}
+
+const timeFormat = "2006-01-02 15:04:05"
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// stuckThreshold - как и сканер TaskProcessor, мем считается зависшим через 30 минут без изменений статуса генерации
+const stuckThreshold = 30 * time.Minute
+
+type memeResult struct {
+	MemeID   uuid.UUID `json:"meme_id"`
+	Status   string    `json:"status"`
+	ImageURL string    `json:"image_url,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func memesStuck(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("memes stuck", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", stuckThreshold, "minimum time since the last generation status update")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	memes, err := a.memeRepo.FindStuckMemes(ctx, *olderThan)
+	if err != nil {
+		return err
+	}
+	if memes == nil {
+		memes = []*models.Meme{}
+	}
+
+	return a.print(memes, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tSTATUS\tBACKEND\tTASK\tSTATUS UPDATED\tPROMPT")
+		for _, meme := range memes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				meme.ID, meme.Status, meme.AIBackend, meme.TaskID, meme.StatusUpdatedAt.Format(timeFormat), truncate(meme.Prompt, 40))
+		}
+	})
+}
+
+func memesRequeue(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("memes requeue", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", stuckThreshold, "requeue stuck memes whose status has not changed for this long (when no ids are given)")
+	timeout := fs.Duration("timeout", 15*time.Minute, "how long to wait for the AI tasks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	memes, err := selectMemes(ctx, a, fs.Args(), *olderThan)
+	if err != nil {
+		return err
+	}
+	tp, err := startLocalProcessor(a, len(memes))
+	if err != nil {
+		return err
+	}
+
+	results := make([]memeResult, 0, len(memes))
+	var queued []uuid.UUID
+	for _, meme := range memes {
+		result := memeResult{MemeID: meme.ID, Status: meme.Status}
+		switch {
+		case meme.Status == "completed":
+			result.Error = "meme is already completed"
+		case meme.TaskID == "":
+			result.Error = "meme has no AI task, use memes regenerate"
+		default:
+			meme.Status = "pending"
+			if err := a.memeRepo.UpdateGeneration(ctx, meme); err != nil {
+				result.Error = err.Error()
+			} else if err := tp.AddTask(meme.ID); err != nil {
+				result.Error = err.Error()
+			} else {
+				queued = append(queued, meme.ID)
+			}
+		}
+		results = append(results, result)
+	}
+
+	waitIdle(ctx, tp, *timeout)
+	tp.Stop()
+
+	for i := range results {
+		if results[i].Error == "" {
+			results[i] = reloadResult(ctx, a, results[i].MemeID)
+		}
+	}
+
+	return a.print(results, func(w io.Writer) {
+		printResults(w, results)
+		fmt.Fprintf(w, "\nrequeued %d of %d memes\n", len(queued), len(memes))
+	})
+}
+
+func memesFail(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("memes fail", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", stuckThreshold, "fail stuck memes whose status has not changed for this long (when no ids are given)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	memes, err := selectMemes(ctx, a, fs.Args(), *olderThan)
+	if err != nil {
+		return err
+	}
+
+	results := make([]memeResult, 0, len(memes))
+	for _, meme := range memes {
+		result := memeResult{MemeID: meme.ID, Status: meme.Status}
+		if meme.Status == "completed" {
+			result.Error = "meme is already completed"
+		} else {
+			meme.Status = "failed"
+			if err := a.memeRepo.UpdateGeneration(ctx, meme); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Status = meme.Status
+			}
+		}
+		results = append(results, result)
+	}
+
+	return a.print(results, func(w io.Writer) {
+		printResults(w, results)
+	})
+}
+
+func memesRegenerate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("memes regenerate", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 15*time.Minute, "how long to wait for the new image")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	memeID, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid meme id %q", fs.Arg(0))
+	}
+
+	storage, err := a.storage()
+	if err != nil {
+		return err
+	}
+	tp, err := startLocalProcessor(a, 1)
+	if err != nil {
+		return err
+	}
+	styleCatalog := services.NewStyleCatalog(&a.cfg.Styles, a.ai(), repository.NewStyleOverrideRepository(a.db))
+	memeService := services.NewMemeServiceWithProcessor(a.cfg, a.memeRepo, repository.NewTagRepository(a.db), storage, a.ai(), styleCatalog, tp)
+
+	if _, err := memeService.RegenerateMeme(ctx, memeID); err != nil {
+		tp.Stop()
+		return err
+	}
+
+	waitIdle(ctx, tp, *timeout)
+	tp.Stop()
+
+	result := reloadResult(ctx, a, memeID)
+	return a.print(result, func(w io.Writer) {
+		printResults(w, []memeResult{result})
+	})
+}
+
+type purgeResult struct {
+	Before time.Time `json:"before"`
+	Purged int       `json:"purged"`
+}
+
+func memesPurge(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("memes purge", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", a.cfg.Trash.Retention, "purge memes deleted earlier than this")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	storage, err := a.storage()
+	if err != nil {
+		return err
+	}
+
+	result := purgeResult{Before: time.Now().Add(-*olderThan)}
+	result.Purged, err = services.NewTrashPurger(&a.cfg.Trash, a.memeRepo, storage).Purge(ctx, result.Before)
+	if err != nil {
+		return err
+	}
+
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "purged %d memes deleted before %s\n", result.Purged, result.Before.Format(timeFormat))
+	})
+}
+
+// selectMemes возвращает мемы по id или, если id не заданы, все зависшие
+func selectMemes(ctx context.Context, a *app, ids []string, olderThan time.Duration) ([]*models.Meme, error) {
+	if len(ids) == 0 {
+		return a.memeRepo.FindStuckMemes(ctx, olderThan)
+	}
+
+	memes := make([]*models.Meme, 0, len(ids))
+	for _, raw := range ids {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meme id %q", raw)
+		}
+		meme, err := a.memeRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", services.ErrMemeNotFound, raw)
+		}
+		memes = append(memes, meme)
+	}
+	return memes, nil
+}
+
+// startLocalProcessor запускает воркеры TaskProcessor в этом процессе. Очередь вмещает
+// все выбранные мемы, сканер зависших мемов не запускается
+func startLocalProcessor(a *app, size int) (*services.TaskProcessor, error) {
+	storage, err := a.storage()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := *a.cfg
+	cfg.TaskProcessor.QueueSize = max(cfg.TaskProcessor.QueueSize, size)
+	tp := services.NewTaskProcessor(&cfg, a.memeRepo, a.ai(), storage)
+	tp.StartWorkers()
+	return tp, nil
+}
+
+// waitIdle ждёт, пока TaskProcessor не обработает все задачи. Незавершённые к timeout
+// задачи позже подхватит сканер зависших мемов на сервере
+func waitIdle(ctx context.Context, tp *services.TaskProcessor, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for tp.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func reloadResult(ctx context.Context, a *app, memeID uuid.UUID) memeResult {
+	result := memeResult{MemeID: memeID}
+	meme, err := a.memeRepo.GetByID(ctx, memeID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = meme.Status
+	result.ImageURL = meme.ImageURL
+	if meme.Status == "pending" || meme.Status == "processing" {
+		result.Error = "not finished in time, the server will pick it up as stuck"
+	}
+	return result
+}
+
+func printResults(w io.Writer, results []memeResult) {
+	fmt.Fprintln(w, "ID\tSTATUS\tIMAGE\tERROR")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.MemeID, result.Status, result.ImageURL, result.Error)
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
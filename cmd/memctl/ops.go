@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"memology-backend/internal/repository"
+	"memology-backend/internal/services"
+)
+
+func storageGC(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("storage gc", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report orphaned objects, do not delete them")
+	grace := fs.Duration("grace", a.cfg.StorageGC.GracePeriod, "skip objects modified more recently than this")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	storage, err := a.storage()
+	if err != nil {
+		return err
+	}
+
+	gcCfg := a.cfg.StorageGC
+	gcCfg.GracePeriod = *grace
+	report, err := services.NewStorageGC(&gcCfg, a.memeRepo, storage).Run(ctx, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	err = a.print(report, func(w io.Writer) {
+		fmt.Fprintln(w, "KEY\tSIZE\tMODIFIED\tSTATE")
+		for _, orphan := range report.Orphans {
+			state := "would delete"
+			if orphan.Deleted {
+				state = "deleted"
+			} else if orphan.Error != "" {
+				state = "failed: " + orphan.Error
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", orphan.Key, orphan.Size, orphan.LastModified.Format(timeFormat), state)
+		}
+		fmt.Fprintf(w, "\nscanned=%d referenced=%d too_recent=%d orphans=%d deleted=%d failed=%d dry_run=%v\n",
+			report.Scanned, report.Referenced, report.TooRecent, len(report.Orphans), report.Deleted, report.Failed, report.DryRun)
+	})
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("failed to delete %d objects", report.Failed)
+	}
+	return nil
+}
+
+// queueReport - очередь генерации глазами базы и, если указан -metrics-url,
+// in-memory очередь TaskProcessor работающего сервера
+type queueReport struct {
+	Statuses map[string]int64 `json:"statuses"`
+	Stuck    int              `json:"stuck"`
+	Server   *serverQueue     `json:"server,omitempty"`
+}
+
+type serverQueue struct {
+	QueueDepth  int `json:"queue_depth"`
+	InFlight    int `json:"in_flight"`
+	BusyWorkers int `json:"busy_workers"`
+}
+
+var memeStatuses = []string{"pending", "processing", "completed", "failed"}
+
+func queueStats(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("queue stats", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", stuckThreshold, "minimum time since the last generation status update for a meme to count as stuck")
+	metricsURL := fs.String("metrics-url", "", "Prometheus endpoint of a running server, e.g. http://localhost:9464/metrics")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	report := queueReport{Statuses: make(map[string]int64, len(memeStatuses))}
+	for _, status := range memeStatuses {
+		count, err := a.memeRepo.Count(ctx, repository.MemeFilter{Status: status})
+		if err != nil {
+			return err
+		}
+		report.Statuses[status] = count
+	}
+
+	stuck, err := a.memeRepo.FindStuckMemes(ctx, *olderThan)
+	if err != nil {
+		return err
+	}
+	report.Stuck = len(stuck)
+
+	if *metricsURL != "" {
+		report.Server, err = fetchServerQueue(ctx, *metricsURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	return a.print(report, func(w io.Writer) {
+		for _, status := range memeStatuses {
+			fmt.Fprintf(w, "%s\t%d\n", status, report.Statuses[status])
+		}
+		fmt.Fprintf(w, "stuck (>%s)\t%d\n", *olderThan, report.Stuck)
+		if report.Server != nil {
+			fmt.Fprintf(w, "server queue depth\t%d\n", report.Server.QueueDepth)
+			fmt.Fprintf(w, "server in flight\t%d\n", report.Server.InFlight)
+			fmt.Fprintf(w, "server busy workers\t%d\n", report.Server.BusyWorkers)
+		}
+	})
+}
+
+// fetchServerQueue читает gauge-метрики TaskProcessor из текстового формата Prometheus
+func fetchServerQueue(ctx context.Context, url string) (*serverQueue, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch server metrics: status %d", resp.StatusCode)
+	}
+
+	gauges := map[string]*int{}
+	queue := &serverQueue{}
+	gauges["memology_task_queue_depth"] = &queue.QueueDepth
+	gauges["memology_task_in_flight"] = &queue.InFlight
+	gauges["memology_task_workers_busy"] = &queue.BusyWorkers
+
+	found := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		target, known := gauges[name]
+		if !ok || !known {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %q", name, value)
+		}
+		*target = int(parsed)
+		found++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read server metrics: %w", err)
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("no task processor metrics at %s", url)
+	}
+	return queue, nil
+}
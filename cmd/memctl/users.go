@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func usersList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users list", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "max users to print")
+	offset := fs.Int("offset", 0, "users to skip")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	users, err := a.userService().GetUsers(ctx, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	if users == nil {
+		users = []*models.User{}
+	}
+
+	return a.print(users, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tACTIVE\tADMIN\tCREATED")
+		for _, user := range users {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%v\t%s\n",
+				user.ID, user.Username, user.Email, user.IsActive, user.IsAdmin, user.CreatedAt.Format(timeFormat))
+		}
+	})
+}
+
+func usersDeactivate(ctx context.Context, a *app, args []string) error {
+	return setUserActive(ctx, a, args, false)
+}
+
+func usersActivate(ctx context.Context, a *app, args []string) error {
+	return setUserActive(ctx, a, args, true)
+}
+
+func setUserActive(ctx context.Context, a *app, args []string, active bool) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	user, err := resolveUser(ctx, a, args[0])
+	if err != nil {
+		return err
+	}
+
+	user, err = a.userService().SetActive(ctx, user.ID, active)
+	if err != nil {
+		return err
+	}
+	// Без сессий заблокированный пользователь не обновит access-токен
+	if !active {
+		if err := a.authService().LogoutAll(ctx, user.ID); err != nil {
+			return fmt.Errorf("user deactivated, but failed to revoke sessions: %w", err)
+		}
+	}
+
+	return a.print(user, func(w io.Writer) {
+		state := "activated"
+		if !active {
+			state = "deactivated, sessions revoked"
+		}
+		fmt.Fprintf(w, "%s (%s) %s\n", user.Username, user.ID, state)
+	})
+}
+
+type passwordReset struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Password string    `json:"password,omitempty"`
+}
+
+func usersResetPassword(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users reset-password", flag.ContinueOnError)
+	password := fs.String("password", "", "new password; a random one is generated and printed if empty")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	user, err := resolveUser(ctx, a, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	result := passwordReset{UserID: user.ID, Username: user.Username}
+	newPassword := *password
+	if newPassword == "" {
+		newPassword = randomPassword()
+		result.Password = newPassword
+	} else if len(newPassword) < 6 {
+		return fmt.Errorf("password must be at least 6 characters")
+	}
+
+	if err := a.userService().ResetPassword(ctx, user.ID, newPassword); err != nil {
+		return err
+	}
+	if err := a.authService().LogoutAll(ctx, user.ID); err != nil {
+		return fmt.Errorf("password reset, but failed to revoke sessions: %w", err)
+	}
+
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "password of %s (%s) reset, sessions revoked\n", user.Username, user.ID)
+		if result.Password != "" {
+			fmt.Fprintf(w, "new password: %s\n", result.Password)
+		}
+	})
+}
+
+// resolveUser ищет пользователя по id, email (если есть @) или username
+func resolveUser(ctx context.Context, a *app, ref string) (*models.User, error) {
+	var user *models.User
+	var err error
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		user, err = a.userRepo.GetByID(ctx, id)
+	} else if strings.Contains(ref, "@") {
+		user, err = a.userRepo.GetByEmail(ctx, ref)
+	} else {
+		user, err = a.userRepo.GetByUsername(ctx, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: %s", services.ErrUserNotFound, ref)
+	}
+	return user, nil
+}
+
+func randomPassword() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"memology-backend/internal/mockai"
+)
+
+// mockai - заглушка AI-сервиса для работы без GPU: AI_BASE_URL=http://localhost:7080
+func main() {
+	addr := flag.String("addr", ":7080", "listen address")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	jitter := flag.Duration("jitter", 0, "random extra delay up to this value")
+	failureRate := flag.Float64("failure-rate", 0, "share of requests answered with 503 (0..1)")
+	taskFailureRate := flag.Float64("task-failure-rate", 0, "share of tasks that end with status failed (0..1)")
+	statuses := flag.String("statuses", strings.Join(mockai.DefaultStatuses, ","), "comma-separated task statuses, one step per status poll")
+	model := flag.String("model", "", "model name reported for completed tasks")
+	seed := flag.Uint64("seed", 0, "random seed for injected failures (0 - random)")
+	flag.Parse()
+
+	srv := mockai.New(mockai.Config{
+		Latency:         *latency,
+		Jitter:          *jitter,
+		FailureRate:     *failureRate,
+		TaskFailureRate: *taskFailureRate,
+		Statuses:        strings.Split(*statuses, ","),
+		Model:           *model,
+		Seed:            *seed,
+	})
+
+	log.Printf("Mock AI service listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		log.Fatal("Mock AI service failed:", err)
+	}
+}
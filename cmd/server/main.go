@@ -2,7 +2,8 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,9 +13,12 @@ import (
 	_ "memology-backend/docs"
 	"memology-backend/internal/config"
 	"memology-backend/internal/database"
+	"memology-backend/internal/logger"
+	"memology-backend/internal/metrics"
 	"memology-backend/internal/repository"
 	"memology-backend/internal/router"
 	"memology-backend/internal/services"
+	"memology-backend/internal/tracing"
 	"memology-backend/pkg/auth"
 )
 
@@ -27,22 +31,67 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token
 func main() {
-	cfg := config.Load()
+	configPath := flag.String("config", "", "path to a YAML or TOML config file (default: CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.LoadFile(*configPath)
+	if *printConfig && cfg != nil {
+		if printErr := cfg.Print(os.Stdout); printErr != nil {
+			fatal("failed to print config", printErr)
+		}
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	if *printConfig {
+		return
+	}
+	logger.Setup(&cfg.Log)
+
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(&cfg.Database, args[1:]); err != nil {
+			fatal("migration failed", err)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
 
 	db := database.Connect(&cfg.Database)
-	if err := database.Migrate(db); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	if cfg.Database.AutoMigrate {
+		if err := database.Migrate(db); err != nil {
+			fatal("failed to migrate database", err)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("failed to get database handle", err)
 	}
+	metrics.RegisterDB(sqlDB, cfg.Database.DBName)
 
 	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
 
 	userRepo := repository.NewUserRepository(db)
 	sessionRepo := repository.NewSessionRepository(db)
 	memeRepo := repository.NewMemeRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	styleOverrideRepo := repository.NewStyleOverrideRepository(db)
 
-	minioService, err := services.NewMinIOService(&cfg.MinIO)
+	minioService, err := services.NewStorageService(cfg)
 	if err != nil {
-		log.Fatal("Failed to initialize MinIO:", err)
+		fatal("failed to initialize storage", err)
 	}
 
 	aiService := services.NewAIService(&cfg.AI)
@@ -52,11 +101,31 @@ func main() {
 
 	taskProcessor := services.NewTaskProcessor(cfg, memeRepo, aiService, minioService)
 	taskProcessor.Start()
+	metrics.RegisterTaskProcessor(taskProcessor)
 	defer taskProcessor.Stop()
 
-	memeService := services.NewMemeServiceWithProcessor(memeRepo, minioService, aiService, taskProcessor)
+	styleCatalog := services.NewStyleCatalog(&cfg.Styles, aiService, styleOverrideRepo)
+	styleCatalog.Start()
+	defer styleCatalog.Stop()
+
+	memeService := services.NewMemeServiceWithProcessor(cfg, memeRepo, tagRepo, minioService, aiService, styleCatalog, taskProcessor)
+
+	if cfg.StorageGC.Enabled {
+		storageGC := services.NewStorageGC(&cfg.StorageGC, memeRepo, minioService)
+		storageGC.Start()
+		defer storageGC.Stop()
+	}
+
+	trashPurger := services.NewTrashPurger(&cfg.Trash, memeRepo, minioService)
+	trashPurger.Start()
+	defer trashPurger.Stop()
+
+	healthChecker := services.NewHealthChecker(&cfg.Health, sqlDB, minioService, aiService, taskProcessor)
 
-	r := router.SetupRouter(authService, userService, memeService)
+	r := router.SetupRouter(authService, userService, memeService, styleCatalog, healthChecker, &cfg.CORS, &cfg.Cookie)
+	if cfg.Storage.Backend == "local" {
+		r.Static(services.LocalStoragePath, cfg.Storage.LocalDir)
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
@@ -64,24 +133,56 @@ func main() {
 	}
 
 	go func() {
-		log.Printf("Server starting on %s:%s", cfg.Server.Host, cfg.Server.Port)
+		slog.Info("server starting", "host", cfg.Server.Host, "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Failed to start server:", err)
+			fatal("failed to start server", err)
 		}
 	}()
 
+	// Метрики отдаются на отдельном адресе, который не публикуется наружу
+	var metricsSrv *http.Server
+	if cfg.Metrics.Addr != "" {
+		metricsSrv = &http.Server{
+			Addr:              cfg.Metrics.Addr,
+			Handler:           metrics.Handler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			slog.Info("metrics server starting", "addr", cfg.Metrics.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("failed to start metrics server", err)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
+
+	// /readyz отвечает 503, пока балансировщик не перестанет направлять сюда запросы
+	healthChecker.SetShuttingDown()
+	if cfg.Server.ShutdownDelay > 0 {
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("server forced to shutdown", err)
 	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			slog.Error("failed to stop metrics server", "error", err)
+		}
+	}
+
+	slog.Info("server exited")
+}
 
-	log.Println("Server exited")
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -15,6 +15,312 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/memes": {
+            "get": {
+                "description": "Get paginated list of all memes, including private ones, with the same filters as public listing plus status and author",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List all memes (admin)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (ignored when cursor is set)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count total memes",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search by title, tags, prompt and description (word forms and typos are matched)",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag slug (repeat or comma-separate for several)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "any - meme has at least one tag, all - meme has every tag",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by generation style",
+                        "name": "style",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "processing",
+                            "completed",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC3339, or YYYY-MM-DD inclusive)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by aspect ratio, e.g. 1:1",
+                        "name": "aspect_ratio",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ai",
+                            "template",
+                            "upload"
+                        ],
+                        "type": "string",
+                        "description": "ai - generated by neural network, template - memegen template, upload - uploaded image",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by author username",
+                        "name": "author",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MemeHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/admin/styles": {
+            "get": {
+                "description": "Get all styles including hidden ones, with administrator overrides",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List style catalog (admin)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.StyleCatalogEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "AI service is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/admin/styles/{name}": {
+            "put": {
+                "description": "Hide a style or change its display name, description and preview. Empty fields keep values from the AI service",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Override style (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Style name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Style override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.StyleOverrideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StyleOverride"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Restore the style as reported by the AI service",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Remove style override (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Style name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
         "/auth/login": {
             "post": {
                 "description": "Login with username or email and password",
@@ -95,11 +401,6 @@ const docTemplate = `{
         },
         "/auth/logout-all": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
                 "description": "Logout user from all devices",
                 "produces": [
                     "application/json"
@@ -121,12 +422,17 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
         "/auth/refresh": {
             "post": {
-                "description": "Get new access token using refresh token from cookies",
+                "description": "Get new access token using refresh token from cookies. Requires X-CSRF-Token header matching the csrf_token cookie",
                 "produces": [
                     "application/json"
                 ],
@@ -134,6 +440,14 @@ const docTemplate = `{
                     "auth"
                 ],
                 "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "CSRF token from the csrf_token cookie",
+                        "name": "X-CSRF-Token",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -152,6 +466,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -204,19 +524,19 @@ const docTemplate = `{
         },
         "/memes": {
             "get": {
-                "description": "Get paginated list of all memes (admin only)",
+                "description": "Get paginated list of all memes, including private ones, with the same filters as public listing plus status and author",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "memes"
+                    "admin"
                 ],
-                "summary": "Get all memes",
+                "summary": "List all memes (admin)",
                 "parameters": [
                     {
                         "type": "integer",
                         "default": 1,
-                        "description": "Page number",
+                        "description": "Page number (ignored when cursor is set)",
                         "name": "page",
                         "in": "query"
                     },
@@ -226,16 +546,132 @@ const docTemplate = `{
                         "description": "Items per page",
                         "name": "limit",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count total memes",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search by title, tags, prompt and description (word forms and typos are matched)",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag slug (repeat or comma-separate for several)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "any - meme has at least one tag, all - meme has every tag",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by generation style",
+                        "name": "style",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "processing",
+                            "completed",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC3339, or YYYY-MM-DD inclusive)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by aspect ratio, e.g. 1:1",
+                        "name": "aspect_ratio",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ai",
+                            "template",
+                            "upload"
+                        ],
+                        "type": "string",
+                        "description": "ai - generated by neural network, template - memegen template, upload - uploaded image",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by author username",
+                        "name": "author",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/handlers.MemeHistoryResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
         "/memes/generate": {
@@ -280,6 +716,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
+                    },
+                    "429": {
+                        "description": "Meme quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "AI service is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -327,18 +775,117 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "429": {
+                        "description": "Meme quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Template image was rejected by the fetcher",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "AI service is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
             }
         },
+        "/memes/import": {
+            "post": {
+                "description": "Download a PNG, JPEG or GIF image from a public URL and create a completed meme from it, like /memes/upload. Private network addresses are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memes"
+                ],
+                "summary": "Import meme from URL",
+                "parameters": [
+                    {
+                        "description": "Image URL and meme metadata",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.ImportMemeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The same image was already uploaded",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meme"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meme"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Meme quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Image could not be downloaded",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
         "/memes/my": {
             "get": {
-                "description": "Get list of memes created by current user with pagination and optional search",
+                "description": "Get list of memes created by current user with pagination and optional search. Pass next_cursor from the previous response as cursor to get the next page without duplicates; page is kept for backward compatibility.",
                 "produces": [
                     "application/json"
                 ],
@@ -350,7 +897,7 @@ const docTemplate = `{
                     {
                         "type": "integer",
                         "default": 1,
-                        "description": "Page number",
+                        "description": "Page number (ignored when cursor is set)",
                         "name": "page",
                         "in": "query"
                     },
@@ -363,9 +910,90 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Search by prompt",
+                        "description": "next_cursor from previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count total matching memes",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search by title, tags, prompt and description (word forms and typos are matched)",
                         "name": "search",
                         "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag slug (repeat or comma-separate for several)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "any - meme has at least one tag, all - meme has every tag",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by generation style",
+                        "name": "style",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "processing",
+                            "completed",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC3339, or YYYY-MM-DD inclusive)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by aspect ratio, e.g. 1:1",
+                        "name": "aspect_ratio",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ai",
+                            "template",
+                            "upload"
+                        ],
+                        "type": "string",
+                        "description": "ai - generated by neural network, template - memegen template, upload - uploaded image",
+                        "name": "kind",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -375,6 +1003,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.MemeHistoryResponse"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -386,7 +1020,7 @@ const docTemplate = `{
         },
         "/memes/public": {
             "get": {
-                "description": "Get paginated list of public memes with optional search",
+                "description": "Get paginated list of public memes with optional search and tag filter. Pass next_cursor from the previous response as cursor to get the next page without duplicates; page is kept for backward compatibility.",
                 "produces": [
                     "application/json"
                 ],
@@ -398,7 +1032,7 @@ const docTemplate = `{
                     {
                         "type": "integer",
                         "default": 1,
-                        "description": "Page number",
+                        "description": "Page number (ignored when cursor is set)",
                         "name": "page",
                         "in": "query"
                     },
@@ -411,9 +1045,96 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Search by prompt",
+                        "description": "next_cursor from previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Count total matching memes",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search by title, tags, prompt and description (word forms and typos are matched)",
                         "name": "search",
                         "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag slug (repeat or comma-separate for several)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "any - meme has at least one tag, all - meme has every tag",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by generation style",
+                        "name": "style",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "processing",
+                            "completed",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC3339, or YYYY-MM-DD inclusive)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by aspect ratio, e.g. 1:1",
+                        "name": "aspect_ratio",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ai",
+                            "template",
+                            "upload"
+                        ],
+                        "type": "string",
+                        "description": "ai - generated by neural network, template - memegen template, upload - uploaded image",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by author username",
+                        "name": "author",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -422,13 +1143,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.MemeHistoryResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/memes/styles": {
             "get": {
-                "description": "Get list of available meme generation styles from neural network",
+                "description": "Get the style catalog: name to pass in the generation request, display name, description, preview and availability. The catalog is cached and refreshed in the background; styles hidden by administrators are not listed",
                 "produces": [
                     "application/json"
                 ],
@@ -440,14 +1167,318 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.Style"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "AI service is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/memes/trash": {
+            "get": {
+                "description": "Get memes deleted by current user. They can be restored until purge_at, after which they are removed permanently.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memes"
+                ],
+                "summary": "Get deleted memes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrashResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/memes/upload": {
+            "post": {
+                "description": "Create a completed meme from an uploaded PNG, JPEG or GIF image. Caption is stored as prompt and used for search and auto tags. Tags can be repeated or comma-separated. If the user already has a meme with the same image, it is returned with status 200 instead of creating a duplicate.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memes"
+                ],
+                "summary": "Upload meme",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meme caption",
+                        "name": "caption",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meme title",
+                        "name": "title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Visibility, public by default",
+                        "name": "is_public",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags",
+                        "name": "tags",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The same image was already uploaded",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meme"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meme"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Meme quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/memes/{id}": {
+            "get": {
+                "description": "Get meme details by ID. Private memes can only be viewed by their owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memes"
+                ],
+                "summary": "Get meme by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meme ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meme"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move meme to trash (only owner can delete). It can be restored via /memes/{id}/restore until the trash retention period expires.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memes"
+                ],
+                "summary": "Delete meme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meme ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update title, description, displayed prompt or visibility of own meme. Only passed fields are changed. Send the ETag from the last read in If-Match (or its updated_at in the body) to get 412 instead of overwriting a concurrent edit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memes"
+                ],
+                "summary": "Update meme",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meme ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from previous GET",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateMemeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meme"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -455,16 +1486,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/memes/{id}": {
-            "get": {
-                "description": "Get meme details by ID. Private memes can only be viewed by their owner.",
+        "/memes/{id}/restore": {
+            "post": {
+                "description": "Restore meme from trash (only owner can restore)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "memes"
                 ],
-                "summary": "Get meme by ID",
+                "summary": "Restore deleted meme",
                 "parameters": [
                     {
                         "type": "string",
@@ -481,6 +1512,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Meme"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
@@ -494,16 +1537,18 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete meme by ID (only owner can delete)",
+            }
+        },
+        "/memes/{id}/status": {
+            "get": {
+                "description": "Check if meme generation is completed and fetch result if ready",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "memes"
                 ],
-                "summary": "Delete meme",
+                "summary": "Check meme generation status",
                 "parameters": [
                     {
                         "type": "string",
@@ -517,11 +1562,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.MessageResponse"
+                            "$ref": "#/definitions/models.Meme"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -531,34 +1576,45 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "AI service is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/memes/{id}/status": {
+        "/stats/generation": {
             "get": {
-                "description": "Check if meme generation is completed and fetch result if ready",
+                "description": "Get generation latency percentiles (p50/p95), success and failure rates and volumes per kind and style for memes created in [from, to). Defaults to the last 7 days",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "memes"
+                    "stats"
                 ],
-                "summary": "Check meme generation status",
+                "summary": "Get generation statistics (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Meme ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Period start (RFC3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period end, exclusive (RFC3339, or YYYY-MM-DD inclusive)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Meme"
+                            "$ref": "#/definitions/services.GenerationStatsReport"
                         }
                     },
                     "400": {
@@ -567,22 +1623,72 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/users/account": {
-            "delete": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
+                ]
+            }
+        },
+        "/tags/popular": {
+            "get": {
+                "description": "Get tags used by the largest number of public memes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get popular tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of tags",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.TagUsage"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/account": {
+            "delete": {
                 "description": "Delete current user account and all associated data",
                 "produces": [
                     "application/json"
@@ -610,16 +1716,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/users/change-password": {
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/users/change-password": {
+            "post": {
                 "description": "Change user password",
                 "consumes": [
                     "application/json"
@@ -661,16 +1767,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/users/list": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/users/list": {
+            "get": {
                 "description": "Get paginated users list",
                 "produces": [
                     "application/json"
@@ -717,16 +1823,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/users/profile": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/users/profile": {
+            "get": {
                 "description": "Get current user profile",
                 "produces": [
                     "application/json"
@@ -754,16 +1860,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/users/profile/update": {
-            "put": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/users/profile/update": {
+            "put": {
                 "description": "Update current user profile",
                 "consumes": [
                     "application/json"
@@ -811,16 +1917,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/users/profile/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/users/profile/{id}": {
+            "get": {
                 "description": "Get user profile by id",
                 "produces": [
                     "application/json"
@@ -857,7 +1963,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         }
     },
@@ -882,6 +1993,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Meme"
                     }
                 },
+                "next_cursor": {
+                    "type": "string"
+                },
                 "page": {
                     "type": "integer"
                 },
@@ -898,15 +2012,97 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.TrashResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "memes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TrashedMeme"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.GenerationInfo": {
+            "type": "object",
+            "properties": {
+                "captions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "processing_ms": {
+                    "description": "ProcessingMs - от отправки запроса генерации до получения готового результата",
+                    "type": "integer"
+                },
+                "queue_wait_ms": {
+                    "description": "QueueWaitMs - сколько задача ждала свободного воркера TaskProcessor; nil - не измерялось,\nнулевое ожидание сохраняется и входит в среднее",
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "source_url": {
+                    "description": "SourceURL - откуда импортирована картинка мема",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "style": {
+                    "type": "string",
+                    "example": "anime"
+                },
+                "task_id": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "string",
+                    "example": "drake"
+                },
+                "trace_parent": {
+                    "description": "TraceParent - W3C traceparent запроса, создавшего мем; спаны TaskProcessor ссылаются на него",
+                    "type": "string"
+                }
+            }
+        },
         "models.Meme": {
             "type": "object",
             "properties": {
+                "ai_backend": {
+                    "type": "string"
+                },
                 "aspect_ratio": {
                     "type": "string"
                 },
+                "content_hash": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "description": {
+                    "type": "string"
+                },
+                "generation": {
+                    "$ref": "#/definitions/models.GenerationInfo"
+                },
                 "generation_time_ms": {
                     "type": "integer"
                 },
@@ -922,21 +2118,44 @@ const docTemplate = `{
                 "is_public": {
                     "type": "boolean"
                 },
+                "kind": {
+                    "type": "string"
+                },
                 "metrics": {
                     "$ref": "#/definitions/models.MemeMetrics"
                 },
                 "prompt": {
                     "type": "string"
                 },
+                "search_highlight": {
+                    "description": "SearchHighlight - фрагмент промпта с подсвеченными совпадениями, заполняется только при поиске",
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
+                "status_updated_at": {
+                    "description": "StatusUpdatedAt - последнее изменение генерации; по нему ищутся зависшие задачи",
+                    "type": "string"
+                },
                 "style": {
                     "type": "string"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
                 "task_id": {
                     "type": "string"
                 },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -977,6 +2196,46 @@ const docTemplate = `{
                 }
             }
         },
+        "models.StyleOverride": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "hidden": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "preview_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "required": [
@@ -998,6 +2257,9 @@ const docTemplate = `{
                 "is_active": {
                     "type": "boolean"
                 },
+                "is_admin": {
+                    "type": "boolean"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -1008,6 +2270,20 @@ const docTemplate = `{
                 }
             }
         },
+        "repository.TagUsage": {
+            "type": "object",
+            "properties": {
+                "meme_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
         "services.AuthResponse": {
             "type": "object",
             "properties": {
@@ -1063,6 +2339,16 @@ const docTemplate = `{
                 "style": {
                     "type": "string",
                     "example": "anime"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "деньги",
+                        "компьютеры"
+                    ]
                 }
             }
         },
@@ -1084,12 +2370,127 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": true
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "коты",
+                        "кофе"
+                    ]
+                },
                 "width": {
                     "type": "integer",
                     "example": 512
                 }
             }
         },
+        "services.GenerationStatsReport": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "failure_rate": {
+                    "type": "number"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "styles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.GenerationStyleStats"
+                    }
+                },
+                "success_rate": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.GenerationStyleStats": {
+            "type": "object",
+            "properties": {
+                "avg_queue_wait_ms": {
+                    "type": "number"
+                },
+                "completed": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "failure_rate": {
+                    "type": "number",
+                    "example": 0.05
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "p50_ms": {
+                    "type": "number"
+                },
+                "p95_ms": {
+                    "type": "number"
+                },
+                "style": {
+                    "type": "string"
+                },
+                "success_rate": {
+                    "type": "number",
+                    "example": 0.95
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.ImportMemeRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "caption": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "когда пришёл на работу в понедельник"
+                },
+                "is_public": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "понедельник",
+                        "работа"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Понедельник"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/meme.png"
+                }
+            }
+        },
         "services.LoginRequest": {
             "type": "object",
             "required": [
@@ -1132,6 +2533,216 @@ const docTemplate = `{
                 }
             }
         },
+        "services.Style": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Рисованный стиль японской анимации"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Аниме"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "anime"
+                },
+                "preview_url": {
+                    "type": "string",
+                    "example": "https://example.com/styles/anime.jpg"
+                }
+            }
+        },
+        "services.StyleCatalogEntry": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Рисованный стиль японской анимации"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Аниме"
+                },
+                "hidden": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "anime"
+                },
+                "override": {
+                    "$ref": "#/definitions/models.StyleOverride"
+                },
+                "preview_url": {
+                    "type": "string",
+                    "example": "https://example.com/styles/anime.jpg"
+                }
+            }
+        },
+        "services.StyleOverrideRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Рисованный стиль японской анимации"
+                },
+                "display_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Аниме"
+                },
+                "hidden": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "preview_url": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "https://example.com/styles/anime.jpg"
+                }
+            }
+        },
+        "services.TrashedMeme": {
+            "type": "object",
+            "properties": {
+                "ai_backend": {
+                    "type": "string"
+                },
+                "aspect_ratio": {
+                    "type": "string"
+                },
+                "content_hash": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "generation": {
+                    "$ref": "#/definitions/models.GenerationInfo"
+                },
+                "generation_time_ms": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "is_public": {
+                    "type": "boolean"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "metrics": {
+                    "$ref": "#/definitions/models.MemeMetrics"
+                },
+                "prompt": {
+                    "type": "string"
+                },
+                "purge_at": {
+                    "type": "string"
+                },
+                "search_highlight": {
+                    "description": "SearchHighlight - фрагмент промпта с подсвеченными совпадениями, заполняется только при поиске",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_updated_at": {
+                    "description": "StatusUpdatedAt - последнее изменение генерации; по нему ищутся зависшие задачи",
+                    "type": "string"
+                },
+                "style": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "task_id": {
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.UpdateMemeRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Когда пришёл на работу"
+                },
+                "is_public": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "prompt": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 1,
+                    "example": "я купил компьютер за 1000000"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "понедельник",
+                        "работа"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Понедельник"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00.123456Z"
+                }
+            }
+        },
         "services.UpdateProfileRequest": {
             "type": "object",
             "properties": {
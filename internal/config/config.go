@@ -1,25 +1,74 @@
 package config
 
 import (
+	"errors"
 	"os"
-	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Режимы работы: в production запрещены небезопасные значения по умолчанию
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Значения по умолчанию для локальной разработки; в production их нужно заменить
+const (
+	defaultDBPassword     = "password"
+	defaultJWTSecret      = "your-secret-key"
+	defaultMinIOAccessKey = "minioadmin"
+	defaultMinIOSecretKey = "minioadmin123"
+)
+
 type Config struct {
+	Env           string
 	Server        ServerConfig
+	CORS          CORSConfig
+	Cookie        CookieConfig
 	Database      DatabaseConfig
 	JWT           JWTConfig
 	MinIO         MinIOConfig
+	Storage       StorageConfig
 	AI            AIConfig
+	Fetch         FetchConfig
+	Upload        UploadConfig
+	Thumbnail     ThumbnailConfig
+	Quota         QuotaConfig
 	TaskProcessor TaskProcessorConfig
+	StorageGC     StorageGCConfig
+	Trash         TrashConfig
+	Tags          TagsConfig
+	Log           LogConfig
+	Metrics       MetricsConfig
+	Tracing       TracingConfig
+	Health        HealthConfig
+	Styles        StylesConfig
+
+	// settings - откуда взято каждое значение, заполняется Load
+	settings []setting
 }
 
 type ServerConfig struct {
 	Port string
 	Host string
+	// ShutdownDelay - сколько ждать после перевода /readyz в 503, прежде чем перестать принимать запросы
+	ShutdownDelay time.Duration
+}
+
+// CORSConfig - с каких Origin браузеру разрешены запросы к API с cookie. Пустой
+// список - CORS-заголовки не отдаются, API доступен только с того же origin
+type CORSConfig struct {
+	AllowedOrigins []string
+}
+
+// CookieConfig - атрибуты cookie с токенами. SameSite: lax, strict или none
+// (none требует Secure). CSRF-токен выдаётся с теми же атрибутами
+type CookieConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite string
 }
 
 type DatabaseConfig struct {
@@ -29,6 +78,9 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate - применять новые миграции при старте сервера; при false схему
+	// обновляют отдельно командой `server migrate up`
+	AutoMigrate bool
 }
 
 type JWTConfig struct {
@@ -37,6 +89,14 @@ type JWTConfig struct {
 	RefreshTokenTTL time.Duration
 }
 
+// StorageConfig - где хранятся картинки: "minio" (по умолчанию) или "local" - каталог
+// LocalDir на диске, который сервер сам раздаёт по /storage (для разработки и тестов)
+type StorageConfig struct {
+	Backend        string
+	LocalDir       string
+	LocalPublicURL string
+}
+
 type MinIOConfig struct {
 	Endpoint  string
 	PublicURL string
@@ -46,9 +106,65 @@ type MinIOConfig struct {
 	Bucket    string
 }
 
+// AIConfig - клиент AI-сервиса. Timeout - верхняя граница для любого вызова,
+// *Timeout по endpoint ограничивают одну попытку. Повторяются только идемпотентные
+// вызовы (статус, результат, стили); после BreakerFailures неудачных вызовов подряд
+// запросы отклоняются сразу в течение BreakerCooldown. Breaker свой у каждого бэкенда
 type AIConfig struct {
+	BaseURL         string
+	Backends        []AIBackendConfig
+	Timeout         time.Duration
+	GenerateTimeout time.Duration
+	TemplateTimeout time.Duration
+	StatusTimeout   time.Duration
+	ResultTimeout   time.Duration
+	StylesTimeout   time.Duration
+	RetryAttempts   int
+	RetryBackoff    time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+// AIBackendConfig - один пул генерации. Styles пустой - бэкенд принимает любой стиль
+type AIBackendConfig struct {
+	Name    string
 	BaseURL string
-	Timeout time.Duration
+	Weight  int
+	Styles  []string
+}
+
+// FetchConfig - скачивание картинок по URL от внешних сервисов (например, memegen).
+// AllowedHosts - разрешённые хосты, "*.example.com" включает поддомены; пустой список -
+// любой публичный хост. Адреса из частных сетей запрещены, пока не включён AllowPrivateNetworks
+type FetchConfig struct {
+	AllowedHosts         []string
+	AllowPrivateNetworks bool
+	Timeout              time.Duration
+	MaxBytes             int
+	MaxRedirects         int
+}
+
+// UploadConfig - загрузка своих картинок и импорт по URL. Принимаются PNG, JPEG и GIF
+// не больше MaxBytes и MaxDimension пикселей по каждой стороне. ImportAllowedHosts -
+// с каких хостов можно импортировать (пустой список - любой публичный); остальные
+// ограничения импорта берутся из FetchConfig
+type UploadConfig struct {
+	MaxBytes           int
+	MaxDimension       int
+	ImportAllowedHosts []string
+}
+
+// ThumbnailConfig - превью мемов для лент: большая сторона не больше MaxSize пикселей.
+// 0 - превью не делаются
+type ThumbnailConfig struct {
+	MaxSize int
+}
+
+// QuotaConfig - пользователь может создать не больше Memes мемов за Window: генерацией,
+// шаблоном, загрузкой или импортом. 0 - без ограничений
+type QuotaConfig struct {
+	Memes  int
+	Window time.Duration
 }
 
 type TaskProcessorConfig struct {
@@ -57,80 +173,208 @@ type TaskProcessorConfig struct {
 	PollInterval time.Duration
 }
 
-func Load() *Config {
+// StorageGCConfig - настройки фоновой очистки объектов без записи в memes
+type StorageGCConfig struct {
+	Enabled     bool
+	Interval    time.Duration
+	GracePeriod time.Duration
+	DryRun      bool
+}
+
+// TrashConfig - сколько хранить удалённые мемы до окончательной очистки
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// TagsConfig - ограничения на теги мемов и автотеги из текста промпта
+type TagsConfig struct {
+	MaxPerMeme     int
+	AutoFromPrompt bool
+	AutoLimit      int
+}
+
+// LogConfig - уровень (debug, info, warn, error) и формат (json, text) логов
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+// MetricsConfig - отдельный listener для /metrics, чтобы метрики не были видны на публичном
+// порту API. Пустой Addr - метрики не отдаются
+type MetricsConfig struct {
+	Addr string
+}
+
+// TracingConfig - экспорт трейсов OpenTelemetry по OTLP/HTTP. Выключено - трейсы не пишутся
+type TracingConfig struct {
+	Enabled      bool
+	OTLPEndpoint string
+	Insecure     bool
+	ServiceName  string
+	SampleRatio  float64
+}
+
+// HealthConfig - параметры проверок /readyz
+type HealthConfig struct {
+	CheckTimeout time.Duration
+	AICacheTTL   time.Duration
+}
+
+// StylesConfig - каталог стилей обновляется в фоне раз в RefreshInterval;
+// если он старше CacheTTL (например, фоновое обновление не удалось), запрос обновляет его сам
+type StylesConfig struct {
+	CacheTTL        time.Duration
+	RefreshInterval time.Duration
+}
+
+// Load читает конфигурацию из файла CONFIG_FILE (если задан), переменных окружения
+// и файлов секретов и проверяет её
+func Load() (*Config, error) {
+	return LoadFile("")
+}
+
+// LoadFile - как Load, но с явным путём к конфиг-файлу (YAML или TOML). Приоритет:
+// переменная окружения, KEY_FILE из окружения, значение из файла, значение по умолчанию.
+// При ошибке проверки возвращается и сама конфигурация, чтобы --print-config мог её показать
+func LoadFile(path string) (*Config, error) {
 	godotenv.Load()
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+
+	l, err := newLoader(path)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Config{
+	aiBaseURL := l.getEnv("AI_BASE_URL", "http://localhost:7080")
+	cfg := &Config{
+		Env: l.getEnv("APP_ENV", EnvDevelopment),
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "localhost"),
+			Port:          l.getEnv("SERVER_PORT", "8080"),
+			Host:          l.getEnv("SERVER_HOST", "localhost"),
+			ShutdownDelay: l.getEnvDuration("SERVER_SHUTDOWN_DELAY", 0),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: l.getEnvList("CORS_ALLOWED_ORIGINS", "http://localhost:3000"),
+		},
+		Cookie: CookieConfig{
+			Domain:   l.getEnv("COOKIE_DOMAIN", ""),
+			Secure:   l.getEnvBool("COOKIE_SECURE", false),
+			SameSite: l.getEnv("COOKIE_SAMESITE", "lax"),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			DBName:   getEnv("DB_NAME", "memology"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:     l.getEnv("DB_HOST", "localhost"),
+			Port:     l.getEnv("DB_PORT", "5432"),
+			User:     l.getEnv("DB_USER", "postgres"),
+			Password: l.getSecret("DB_PASSWORD", defaultDBPassword),
+			DBName:   l.getEnv("DB_NAME", "memology"),
+			SSLMode:  l.getEnv("DB_SSLMODE", "disable"),
+
+			AutoMigrate: l.getEnvBool("DB_AUTO_MIGRATE", true),
 		},
 		JWT: JWTConfig{
-			SecretKey:       getEnv("JWT_SECRET", "your-secret-key"),
-			AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TTL", time.Hour),
-			RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TTL", time.Hour*24*7),
+			SecretKey:       l.getSecret("JWT_SECRET", defaultJWTSecret),
+			AccessTokenTTL:  l.getEnvDuration("JWT_ACCESS_TTL", time.Hour),
+			RefreshTokenTTL: l.getEnvDuration("JWT_REFRESH_TTL", time.Hour*24*7),
 		},
 		MinIO: MinIOConfig{
-			Endpoint:  getEnv("MINIO_ENDPOINT", "localhost:9000"),
-			PublicURL: getEnv("MINIO_PUBLIC_URL", "http://localhost:9000"),
-			AccessKey: getEnv("MINIO_ACCESS_KEY", "minioadmin"),
-			SecretKey: getEnv("MINIO_SECRET_KEY", "minioadmin123"),
-			UseSSL:    getEnvBool("MINIO_USE_SSL", false),
-			Bucket:    getEnv("MINIO_BUCKET", "memes"),
+			Endpoint:  l.getEnv("MINIO_ENDPOINT", "localhost:9000"),
+			PublicURL: l.getEnv("MINIO_PUBLIC_URL", "http://localhost:9000"),
+			AccessKey: l.getSecret("MINIO_ACCESS_KEY", defaultMinIOAccessKey),
+			SecretKey: l.getSecret("MINIO_SECRET_KEY", defaultMinIOSecretKey),
+			UseSSL:    l.getEnvBool("MINIO_USE_SSL", false),
+			Bucket:    l.getEnv("MINIO_BUCKET", "memes"),
+		},
+		Storage: StorageConfig{
+			Backend:        l.getEnv("STORAGE_BACKEND", "minio"),
+			LocalDir:       l.getEnv("STORAGE_LOCAL_DIR", "./data/storage"),
+			LocalPublicURL: l.getEnv("STORAGE_LOCAL_PUBLIC_URL", "http://localhost:8080/storage"),
 		},
 		AI: AIConfig{
-			BaseURL: getEnv("AI_BASE_URL", "http://localhost:7080"),
-			Timeout: getEnvDuration("AI_TIMEOUT", time.Second*120),
+			BaseURL:         aiBaseURL,
+			Backends:        l.getEnvAIBackends("AI_BACKENDS", aiBaseURL),
+			Timeout:         l.getEnvDuration("AI_TIMEOUT", time.Second*120),
+			GenerateTimeout: l.getEnvDuration("AI_GENERATE_TIMEOUT", time.Second*30),
+			TemplateTimeout: l.getEnvDuration("AI_TEMPLATE_TIMEOUT", time.Second*60),
+			StatusTimeout:   l.getEnvDuration("AI_STATUS_TIMEOUT", time.Second*10),
+			ResultTimeout:   l.getEnvDuration("AI_RESULT_TIMEOUT", time.Second*60),
+			StylesTimeout:   l.getEnvDuration("AI_STYLES_TIMEOUT", time.Second*10),
+			RetryAttempts:   l.getEnvInt("AI_RETRY_ATTEMPTS", 3),
+			RetryBackoff:    l.getEnvDuration("AI_RETRY_BACKOFF", time.Millisecond*500),
+			BreakerFailures: l.getEnvInt("AI_BREAKER_FAILURES", 5),
+			BreakerCooldown: l.getEnvDuration("AI_BREAKER_COOLDOWN", time.Second*30),
+		},
+		Fetch: FetchConfig{
+			AllowedHosts:         l.getEnvList("FETCH_ALLOWED_HOSTS", "api.memegen.link"),
+			AllowPrivateNetworks: l.getEnvBool("FETCH_ALLOW_PRIVATE_NETWORKS", false),
+			Timeout:              l.getEnvDuration("FETCH_TIMEOUT", time.Second*15),
+			MaxBytes:             l.getEnvInt("FETCH_MAX_BYTES", 10<<20),
+			MaxRedirects:         l.getEnvInt("FETCH_MAX_REDIRECTS", 3),
+		},
+		Upload: UploadConfig{
+			MaxBytes:           l.getEnvInt("UPLOAD_MAX_BYTES", 10<<20),
+			MaxDimension:       l.getEnvInt("UPLOAD_MAX_DIMENSION", 8192),
+			ImportAllowedHosts: l.getEnvList("UPLOAD_IMPORT_ALLOWED_HOSTS", ""),
+		},
+		Thumbnail: ThumbnailConfig{
+			MaxSize: l.getEnvInt("THUMBNAIL_MAX_SIZE", 320),
+		},
+		Quota: QuotaConfig{
+			Memes:  l.getEnvInt("QUOTA_MEMES", 100),
+			Window: l.getEnvDuration("QUOTA_WINDOW", time.Hour*24),
 		},
 		TaskProcessor: TaskProcessorConfig{
-			Workers:      getEnvInt("TASK_PROCESSOR_WORKERS", 10),
-			QueueSize:    getEnvInt("TASK_PROCESSOR_QUEUE_SIZE", 100),
-			PollInterval: getEnvDuration("TASK_PROCESSOR_POLL_INTERVAL", time.Second*5),
+			Workers:      l.getEnvInt("TASK_PROCESSOR_WORKERS", 10),
+			QueueSize:    l.getEnvInt("TASK_PROCESSOR_QUEUE_SIZE", 100),
+			PollInterval: l.getEnvDuration("TASK_PROCESSOR_POLL_INTERVAL", time.Second*5),
+		},
+		StorageGC: StorageGCConfig{
+			Enabled:     l.getEnvBool("STORAGE_GC_ENABLED", false),
+			Interval:    l.getEnvDuration("STORAGE_GC_INTERVAL", time.Hour*6),
+			GracePeriod: l.getEnvDuration("STORAGE_GC_GRACE_PERIOD", time.Hour*24),
+			DryRun:      l.getEnvBool("STORAGE_GC_DRY_RUN", false),
+		},
+		Trash: TrashConfig{
+			Retention:     l.getEnvDuration("TRASH_RETENTION", time.Hour*24*30),
+			PurgeInterval: l.getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Tags: TagsConfig{
+			MaxPerMeme:     l.getEnvInt("TAGS_MAX_PER_MEME", 10),
+			AutoFromPrompt: l.getEnvBool("TAGS_AUTO_FROM_PROMPT", false),
+			AutoLimit:      l.getEnvInt("TAGS_AUTO_LIMIT", 3),
+		},
+		Log: LogConfig{
+			Level:  l.getEnv("LOG_LEVEL", "info"),
+			Format: l.getEnv("LOG_FORMAT", "json"),
+		},
+		Metrics: MetricsConfig{
+			Addr: l.getEnv("METRICS_ADDR", ":9464"),
+		},
+		Tracing: TracingConfig{
+			Enabled:      l.getEnvBool("TRACING_ENABLED", false),
+			OTLPEndpoint: l.getEnv("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			Insecure:     l.getEnvBool("TRACING_INSECURE", true),
+			ServiceName:  l.getEnv("TRACING_SERVICE_NAME", "memology-backend"),
+			SampleRatio:  l.getEnvFloat("TRACING_SAMPLE_RATIO", 1.0),
+		},
+		Health: HealthConfig{
+			CheckTimeout: l.getEnvDuration("HEALTH_CHECK_TIMEOUT", time.Second*2),
+			AICacheTTL:   l.getEnvDuration("HEALTH_AI_CACHE_TTL", time.Second*30),
+		},
+		Styles: StylesConfig{
+			CacheTTL:        l.getEnvDuration("STYLES_CACHE_TTL", time.Minute*10),
+			RefreshInterval: l.getEnvDuration("STYLES_REFRESH_INTERVAL", time.Minute*5),
 		},
 	}
-}
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := time.ParseDuration(value); err == nil {
-			return parsed
-		}
-		if seconds, err := strconv.Atoi(value); err == nil {
-			return time.Duration(seconds) * time.Second
-		}
-	}
-	return defaultValue
-}
+	cfg.settings = l.settings
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.ParseBool(value); err == nil {
-			return parsed
-		}
+	if err := l.unknownKeys(); err != nil {
+		l.errs = append(l.errs, err)
 	}
-	return defaultValue
-}
-
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.Atoi(value); err == nil {
-			return parsed
-		}
+	if err := errors.Join(l.err(), cfg.Validate()); err != nil {
+		return cfg, err
 	}
-	return defaultValue
+	return cfg, nil
 }
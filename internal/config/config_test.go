@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv убирает переменные, которые могли прийти из окружения разработчика
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		for _, prefix := range []string{"APP_", "SERVER_", "CORS_", "COOKIE_", "DB_", "JWT_", "MINIO_", "STORAGE_", "AI_", "FETCH_", "UPLOAD_", "THUMBNAIL_", "QUOTA_", "TASK_PROCESSOR_", "TRASH_", "TAGS_", "LOG_", "METRICS_", "TRACING_", "HEALTH_", "STYLES_", "CONFIG_FILE"} {
+			if strings.HasPrefix(key, prefix) {
+				// Пустая переменная - тоже значение, поэтому её нужно удалить;
+				// t.Setenv вернёт прежнее значение после теста
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			}
+		}
+	}
+	// godotenv не должен подхватить .env из рабочего каталога
+	t.Chdir(t.TempDir())
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("defaults must be valid in development: %v", err)
+	}
+	if cfg.Env != EnvDevelopment || cfg.Server.Port != "8080" || cfg.JWT.AccessTokenTTL != time.Hour {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	secret := writeFile(t, "jwt", "from-secret-file\n")
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 9090
+db:
+  host: file-host
+  user: file-user
+jwt:
+  secret_file: `+secret+`
+  access_ttl: 30m
+ai:
+  backends:
+    - name: gpu-a
+      base_url: http://gpu-a:7080
+      weight: 3
+      styles: [anime, cartoon]
+`)
+	t.Setenv("DB_HOST", "env-host")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Host != "env-host" {
+		t.Errorf("env must override file, got DB host %q", cfg.Database.Host)
+	}
+	if cfg.Database.User != "file-user" || cfg.Server.Port != "9090" || cfg.JWT.AccessTokenTTL != 30*time.Minute {
+		t.Errorf("file values not applied: %+v %+v %+v", cfg.Database, cfg.Server, cfg.JWT)
+	}
+	if cfg.JWT.SecretKey != "from-secret-file" {
+		t.Errorf("secret_file not applied, got %q", cfg.JWT.SecretKey)
+	}
+	if len(cfg.AI.Backends) != 1 || cfg.AI.Backends[0].Weight != 3 || len(cfg.AI.Backends[0].Styles) != 2 {
+		t.Errorf("AI backends from file not applied: %+v", cfg.AI.Backends)
+	}
+
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt-env", "from-env-file"))
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.JWT.SecretKey != "from-env-file" {
+		t.Errorf("JWT_SECRET_FILE from env must override file, got %q", cfg.JWT.SecretKey)
+	}
+}
+
+func TestLoadEmptyValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("METRICS_ADDR", "")
+	t.Setenv("JWT_ACCESS_TTL", "")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Metrics.Addr != "" {
+		t.Errorf("empty METRICS_ADDR must disable metrics, got %q", cfg.Metrics.Addr)
+	}
+	if cfg.JWT.AccessTokenTTL != time.Hour {
+		t.Errorf("empty duration must fall back to the default, got %v", cfg.JWT.AccessTokenTTL)
+	}
+
+	clearEnv(t)
+	cfg, err = LoadFile(writeFile(t, "config.yaml", "metrics:\n  addr: \"\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Metrics.Addr != "" {
+		t.Errorf("empty metrics.addr in file must disable metrics, got %q", cfg.Metrics.Addr)
+	}
+}
+
+func TestLoadEmptyCORSOrigins(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 0 {
+		t.Errorf("empty CORS_ALLOWED_ORIGINS must deny all origins, got %v", cfg.CORS.AllowedOrigins)
+	}
+
+	clearEnv(t)
+	cfg, err = LoadFile(writeFile(t, "config.yaml", "cors:\n  allowed_origins: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 0 {
+		t.Errorf("empty cors.allowed_origins in file must deny all origins, got %v", cfg.CORS.AllowedOrigins)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.toml", "[log]\nformat = \"text\"\n[storage_gc]\nenabled = true\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Format != "text" || !cfg.StorageGC.Enabled {
+		t.Fatalf("TOML values not applied: %+v %+v", cfg.Log, cfg.StorageGC)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		want []string
+	}{
+		{
+			name: "malformed values",
+			env:  map[string]string{"JWT_ACCESS_TTL": "soon", "TASK_PROCESSOR_WORKERS": "many", "MINIO_USE_SSL": "maybe", "LOG_LEVEL": "loud"},
+			want: []string{"JWT_ACCESS_TTL", "TASK_PROCESSOR_WORKERS", "MINIO_USE_SSL", "LOG_LEVEL"},
+		},
+		{
+			name: "duration without unit",
+			env:  map[string]string{"AI_TIMEOUT": "30"},
+			want: []string{`AI_TIMEOUT: invalid duration "30": unit is required`},
+		},
+		{
+			name: "out of range",
+			env:  map[string]string{"SERVER_PORT": "70000", "TRACING_SAMPLE_RATIO": "2", "STORAGE_BACKEND": "s3"},
+			want: []string{"SERVER_PORT", "TRACING_SAMPLE_RATIO", "STORAGE_BACKEND"},
+		},
+		{
+			name: "metrics on the API port",
+			env:  map[string]string{"SERVER_PORT": "8080", "METRICS_ADDR": ":8080"},
+			want: []string{"METRICS_ADDR must use a port other than SERVER_PORT"},
+		},
+		{
+			name: "malformed metrics address",
+			env:  map[string]string{"METRICS_ADDR": "9090"},
+			want: []string{`METRICS_ADDR: invalid address "9090"`},
+		},
+		{
+			name: "insecure browser settings",
+			env:  map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.example.com,*,https://evil.example.com/path", "COOKIE_SAMESITE": "none"},
+			want: []string{`invalid origin "*"`, "evil.example.com/path", "COOKIE_SAMESITE=none requires COOKIE_SECURE"},
+		},
+		{
+			name: "malformed AI backends",
+			env:  map[string]string{"AI_BACKENDS": "gpu-a,gpu-b=http://gpu-b;weight=0"},
+			want: []string{"expected name=url", "backend weight"},
+		},
+		{
+			name: "unknown file key",
+			file: "db:\n  hots: localhost\n",
+			want: []string{"DB_HOTS"},
+		},
+		{
+			name: "missing secret file",
+			env:  map[string]string{"DB_PASSWORD_FILE": "/nonexistent/secret"},
+			want: []string{"DB_PASSWORD_FILE"},
+		},
+		{
+			name: "production with default secrets",
+			env:  map[string]string{"APP_ENV": "production"},
+			want: []string{"JWT_SECRET", "DB_PASSWORD", "MINIO_ACCESS_KEY", "MINIO_SECRET_KEY", "COOKIE_SECURE"},
+		},
+		{
+			name: "production with short JWT secret",
+			env:  map[string]string{"APP_ENV": "production", "JWT_SECRET": "short", "DB_PASSWORD": "db", "STORAGE_BACKEND": "local"},
+			want: []string{"at least 32 bytes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, "config.yaml", tt.file)
+			}
+
+			_, err := LoadFile(path)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error must mention %q, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadProduction(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", 32))
+	t.Setenv("DB_PASSWORD", "db-password")
+	t.Setenv("MINIO_ACCESS_KEY", "access")
+	t.Setenv("MINIO_SECRET_KEY", "minio-secret")
+	t.Setenv("COOKIE_SECURE", "true")
+
+	if _, err := LoadFile(""); err != nil {
+		t.Fatalf("production config with secrets must be valid: %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("JWT_SECRET", "very-secret-value")
+	t.Setenv("MINIO_ACCESS_KEY", "storage-access-key")
+	t.Setenv("DB_HOST", "db.internal")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	printed := out.String()
+	if strings.Contains(printed, "very-secret-value") || strings.Contains(printed, "storage-access-key") || strings.Contains(printed, defaultMinIOSecretKey) {
+		t.Fatalf("secrets must be redacted:\n%s", printed)
+	}
+	for _, want := range []string{"secret: '[REDACTED]' # env", "host: db.internal # env", "storage_gc:\n  enabled: false # default"} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("output must contain %q:\n%s", want, printed)
+		}
+	}
+
+	// Напечатанный конфиг можно снова загрузить как файл
+	clearEnv(t)
+	path := writeFile(t, "printed.yaml", strings.ReplaceAll(printed, "'[REDACTED]'", "x"))
+	reloaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("printed config must load back: %v", err)
+	}
+	if reloaded.Database.Host != "db.internal" {
+		t.Fatalf("reloaded DB host: %q", reloaded.Database.Host)
+	}
+}
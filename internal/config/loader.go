@@ -0,0 +1,364 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// Источники значения настройки в порядке убывания приоритета
+const (
+	sourceEnv        = "env"
+	sourceEnvFile    = "env *_FILE"
+	sourceConfig     = "config file"
+	sourceConfigFile = "config file *_FILE"
+	sourceDefault    = "default"
+)
+
+// setting - итоговое значение настройки и откуда оно взято; нужно для --print-config
+type setting struct {
+	key    string
+	value  string
+	source string
+	secret bool
+	// typed - значение числа, флага или длительности; печатается без кавычек
+	typed bool
+}
+
+// loader собирает настройки из переменных окружения, файлов секретов (*_FILE)
+// и конфиг-файла. Ошибки разбора копятся, чтобы показать их все сразу
+type loader struct {
+	file     map[string]string
+	settings []setting
+	errs     []error
+}
+
+func newLoader(path string) (*loader, error) {
+	l := &loader{file: map[string]string{}}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %q: expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := flatten("", raw, l.file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// flatten превращает вложенные секции в имена переменных окружения:
+// db.host и DB_HOST - одна и та же настройка. Списки строк склеиваются через запятую
+func flatten(prefix string, raw map[string]any, out map[string]string) error {
+	for name, value := range raw {
+		key := strings.ToUpper(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case []any:
+			joined, err := joinList(key, v)
+			if err != nil {
+				return err
+			}
+			out[key] = joined
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func joinList(key string, items []any) (string, error) {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case map[string]any:
+			if key != "AI_BACKENDS" {
+				return "", fmt.Errorf("%s: expected a list of values", key)
+			}
+			backend, err := formatAIBackend(v)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, backend)
+		case []any:
+			return "", fmt.Errorf("%s: nested lists are not supported", key)
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// formatAIBackend переводит бэкенд из файла ({name, base_url, weight, styles})
+// в формат AI_BACKENDS
+func formatAIBackend(raw map[string]any) (string, error) {
+	var name, baseURL string
+	var fields []string
+	for k, v := range raw {
+		switch strings.ToLower(k) {
+		case "name":
+			name = fmt.Sprint(v)
+		case "base_url":
+			baseURL = fmt.Sprint(v)
+		case "weight":
+			fields = append(fields, "weight="+fmt.Sprint(v))
+		case "styles":
+			styles, ok := v.([]any)
+			if !ok {
+				return "", fmt.Errorf("AI_BACKENDS: styles of backend must be a list")
+			}
+			names := make([]string, 0, len(styles))
+			for _, style := range styles {
+				names = append(names, fmt.Sprint(style))
+			}
+			fields = append(fields, "styles="+strings.Join(names, "|"))
+		default:
+			return "", fmt.Errorf("AI_BACKENDS: unknown backend field %q", k)
+		}
+	}
+	sort.Strings(fields)
+	return strings.Join(append([]string{name + "=" + baseURL}, fields...), ";"), nil
+}
+
+// lookup ищет значение: переменная окружения, KEY_FILE из окружения,
+// ключ конфиг-файла, KEY_FILE из конфиг-файла. Заданное пустое значение -
+// тоже значение: METRICS_ADDR= отключает метрики, а не включает адрес по умолчанию
+func (l *loader) lookup(key string) (string, string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, sourceEnv, true
+	}
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		return l.readSecret(key, path), sourceEnvFile, true
+	}
+	if value, ok := l.file[key]; ok {
+		return value, sourceConfig, true
+	}
+	if path := l.file[key+"_FILE"]; path != "" {
+		return l.readSecret(key, path), sourceConfigFile, true
+	}
+	return "", "", false
+}
+
+func (l *loader) readSecret(key, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s_FILE: %w", key, err))
+		return ""
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+func (l *loader) record(s setting) {
+	l.settings = append(l.settings, s)
+}
+
+// raw возвращает строковое значение и записывает его. Пустое число, флаг или
+// длительность ничего не означают и заменяются значением по умолчанию
+func (l *loader) raw(key string, defaultValue string, secret, typed bool) (string, bool) {
+	value, source, ok := l.lookup(key)
+	if !ok || typed && value == "" {
+		l.record(setting{key: key, value: defaultValue, source: sourceDefault, secret: secret, typed: typed})
+		return "", false
+	}
+	l.record(setting{key: key, value: value, source: source, secret: secret, typed: typed})
+	return value, true
+}
+
+func (l *loader) invalid(key, value, kind string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: invalid %s %q", key, kind, value))
+}
+
+func (l *loader) getEnv(key, defaultValue string) string {
+	if value, ok := l.raw(key, defaultValue, false, false); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// getSecret - как getEnv, но значение скрывается в --print-config
+func (l *loader) getSecret(key, defaultValue string) string {
+	if value, ok := l.raw(key, defaultValue, true, false); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (l *loader) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := l.raw(key, defaultValue.String(), false, true)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err == nil {
+		return parsed
+	}
+	// Число без единицы не угадывается: 30 - это секунды или наносекунды
+	if _, numErr := strconv.ParseFloat(value, 64); numErr == nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid duration %q: unit is required, e.g. %q", key, value, value+"s"))
+		return defaultValue
+	}
+	l.invalid(key, value, "duration")
+	return defaultValue
+}
+
+func (l *loader) getEnvBool(key string, defaultValue bool) bool {
+	value, ok := l.raw(key, strconv.FormatBool(defaultValue), false, true)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, value, "boolean")
+		return defaultValue
+	}
+	return parsed
+}
+
+func (l *loader) getEnvInt(key string, defaultValue int) int {
+	value, ok := l.raw(key, strconv.Itoa(defaultValue), false, true)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, value, "integer")
+		return defaultValue
+	}
+	return parsed
+}
+
+func (l *loader) getEnvFloat(key string, defaultValue float64) float64 {
+	value, ok := l.raw(key, strconv.FormatFloat(defaultValue, 'g', -1, 64), false, true)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, value, "number")
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvList разбирает список через запятую, пустые элементы пропускаются
+func (l *loader) getEnvList(key, defaultValue string) []string {
+	value, ok := l.raw(key, defaultValue, false, false)
+	if !ok {
+		value = defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAIBackends разбирает список бэкендов вида
+// "gpu-a=http://gpu-a:7080;weight=3;styles=anime|cartoon,gpu-b=http://gpu-b:7080".
+// Без значения (или с пустым) используется один бэкенд "default" с AI_BASE_URL
+func (l *loader) getEnvAIBackends(key, defaultBaseURL string) []AIBackendConfig {
+	defaults := []AIBackendConfig{{Name: "default", BaseURL: defaultBaseURL, Weight: 1}}
+	value, ok := l.raw(key, "", false, false)
+	if !ok || strings.TrimSpace(value) == "" {
+		return defaults
+	}
+
+	var backends []AIBackendConfig
+	for _, entry := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(entry), ";")
+		name, baseURL, ok := strings.Cut(fields[0], "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(baseURL) == "" {
+			l.invalid(key, entry, "backend (expected name=url)")
+			continue
+		}
+
+		backend := AIBackendConfig{Name: strings.TrimSpace(name), BaseURL: strings.TrimSpace(baseURL), Weight: 1}
+		for _, field := range fields[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch k {
+			case "weight":
+				weight, err := strconv.Atoi(v)
+				if err != nil || weight <= 0 {
+					l.invalid(key, field, "backend weight")
+					continue
+				}
+				backend.Weight = weight
+			case "styles":
+				for _, style := range strings.Split(v, "|") {
+					if style = strings.TrimSpace(style); style != "" {
+						backend.Styles = append(backend.Styles, style)
+					}
+				}
+			default:
+				l.invalid(key, field, "backend option")
+			}
+		}
+		backends = append(backends, backend)
+	}
+
+	if len(backends) == 0 {
+		return defaults
+	}
+	return backends
+}
+
+// unknownKeys - ключи конфиг-файла, которые не соответствуют ни одной настройке
+func (l *loader) unknownKeys() error {
+	known := make(map[string]bool, len(l.settings)*2)
+	for _, s := range l.settings {
+		known[s.key] = true
+		known[s.key+"_FILE"] = true
+	}
+
+	var unknown []string
+	for key := range l.file {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown config file keys: %s", strings.Join(unknown, ", "))
+}
+
+func (l *loader) err() error {
+	return errors.Join(l.errs...)
+}
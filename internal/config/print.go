@@ -0,0 +1,82 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const redacted = "[REDACTED]"
+
+// sections - префиксы переменных окружения, которые становятся секциями конфиг-файла.
+// Проверяются по порядку, поэтому storage_gc стоит раньше storage: STORAGE_GC_ENABLED -> storage_gc.enabled
+var sections = []string{
+	"app", "server", "cors", "cookie", "db", "jwt", "minio", "storage_gc", "storage", "ai", "fetch", "upload",
+	"thumbnail", "quota", "task_processor", "trash", "tags", "log", "metrics", "tracing", "health", "styles",
+}
+
+// Print пишет итоговую конфигурацию в формате YAML-файла конфигурации; у каждого значения
+// в комментарии указан источник. Секреты заменяются на [REDACTED]
+func (c *Config) Print(w io.Writer) error {
+	bySection := map[string]*yaml.Node{}
+	printed := map[string]bool{}
+
+	for _, s := range c.settings {
+		if printed[s.key] {
+			continue
+		}
+		printed[s.key] = true
+
+		section, name := splitKey(s.key)
+		if bySection[section] == nil {
+			bySection[section] = &yaml.Node{Kind: yaml.MappingNode}
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode}
+		switch {
+		case s.secret && s.value != "":
+			value.SetString(redacted)
+		case s.typed:
+			value.Value = s.value
+		default:
+			value.SetString(s.value)
+		}
+		value.LineComment = s.source
+		bySection[section].Content = append(bySection[section].Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+
+	// Ключи без секции идут первыми, секции - в порядке sections
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	if top := bySection[""]; top != nil {
+		root.Content = append(root.Content, top.Content...)
+	}
+	for _, section := range sections {
+		if node := bySection[section]; node != nil {
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, node)
+		}
+	}
+
+	doc := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: "Effective configuration, secrets are redacted",
+		Content:     []*yaml.Node{root},
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// splitKey делит имя переменной на секцию и ключ внутри неё: DB_HOST -> db, host
+func splitKey(key string) (string, string) {
+	lower := strings.ToLower(key)
+	for _, section := range sections {
+		if name, ok := strings.CutPrefix(lower, section+"_"); ok {
+			return section, name
+		}
+	}
+	return "", lower
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minJWTSecretLength - HS256-ключ короче 32 байт подбирается перебором
+const minJWTSecretLength = 32
+
+// Validate проверяет значения, с которыми сервер не сможет работать корректно,
+// и возвращает все найденные проблемы сразу
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("APP_ENV", c.Env, EnvDevelopment, EnvProduction)
+	v.port("SERVER_PORT", c.Server.Port)
+	v.nonNegative("SERVER_SHUTDOWN_DELAY", c.Server.ShutdownDelay)
+	if c.Metrics.Addr != "" {
+		if port := v.listenAddr("METRICS_ADDR", c.Metrics.Addr); port == c.Server.Port {
+			v.fail("METRICS_ADDR must use a port other than SERVER_PORT")
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		v.origin("CORS_ALLOWED_ORIGINS", origin)
+	}
+	v.oneOf("COOKIE_SAMESITE", c.Cookie.SameSite, "lax", "strict", "none")
+	if c.Cookie.SameSite == "none" && !c.Cookie.Secure {
+		v.fail("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+
+	v.port("DB_PORT", c.Database.Port)
+	v.oneOf("DB_SSLMODE", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	v.positive("JWT_ACCESS_TTL", c.JWT.AccessTokenTTL)
+	v.positive("JWT_REFRESH_TTL", c.JWT.RefreshTokenTTL)
+	if c.JWT.RefreshTokenTTL > 0 && c.JWT.RefreshTokenTTL < c.JWT.AccessTokenTTL {
+		v.fail("JWT_REFRESH_TTL must not be shorter than JWT_ACCESS_TTL")
+	}
+
+	v.oneOf("STORAGE_BACKEND", c.Storage.Backend, "minio", "local")
+	if c.Storage.Backend == "local" {
+		v.required("STORAGE_LOCAL_DIR", c.Storage.LocalDir)
+		v.httpURL("STORAGE_LOCAL_PUBLIC_URL", c.Storage.LocalPublicURL)
+	} else {
+		v.required("MINIO_ENDPOINT", c.MinIO.Endpoint)
+		v.required("MINIO_BUCKET", c.MinIO.Bucket)
+		v.httpURL("MINIO_PUBLIC_URL", c.MinIO.PublicURL)
+	}
+
+	seen := make(map[string]bool, len(c.AI.Backends))
+	for _, backend := range c.AI.Backends {
+		if seen[backend.Name] {
+			v.fail("AI_BACKENDS: duplicate backend name %q", backend.Name)
+		}
+		seen[backend.Name] = true
+		v.httpURL("AI_BACKENDS "+backend.Name, backend.BaseURL)
+	}
+	v.positive("AI_TIMEOUT", c.AI.Timeout)
+	v.positive("AI_GENERATE_TIMEOUT", c.AI.GenerateTimeout)
+	v.positive("AI_TEMPLATE_TIMEOUT", c.AI.TemplateTimeout)
+	v.positive("AI_STATUS_TIMEOUT", c.AI.StatusTimeout)
+	v.positive("AI_RESULT_TIMEOUT", c.AI.ResultTimeout)
+	v.positive("AI_STYLES_TIMEOUT", c.AI.StylesTimeout)
+	v.atLeast("AI_RETRY_ATTEMPTS", c.AI.RetryAttempts, 1)
+	v.nonNegative("AI_RETRY_BACKOFF", c.AI.RetryBackoff)
+	v.atLeast("AI_BREAKER_FAILURES", c.AI.BreakerFailures, 1)
+	v.positive("AI_BREAKER_COOLDOWN", c.AI.BreakerCooldown)
+
+	v.hosts("FETCH_ALLOWED_HOSTS", c.Fetch.AllowedHosts)
+	v.positive("FETCH_TIMEOUT", c.Fetch.Timeout)
+	v.atLeast("FETCH_MAX_BYTES", c.Fetch.MaxBytes, 1)
+	v.atLeast("FETCH_MAX_REDIRECTS", c.Fetch.MaxRedirects, 0)
+
+	v.atLeast("UPLOAD_MAX_BYTES", c.Upload.MaxBytes, 1)
+	v.atLeast("UPLOAD_MAX_DIMENSION", c.Upload.MaxDimension, 1)
+	v.hosts("UPLOAD_IMPORT_ALLOWED_HOSTS", c.Upload.ImportAllowedHosts)
+	v.atLeast("THUMBNAIL_MAX_SIZE", c.Thumbnail.MaxSize, 0)
+	v.atLeast("QUOTA_MEMES", c.Quota.Memes, 0)
+	v.positive("QUOTA_WINDOW", c.Quota.Window)
+
+	v.atLeast("TASK_PROCESSOR_WORKERS", c.TaskProcessor.Workers, 1)
+	v.atLeast("TASK_PROCESSOR_QUEUE_SIZE", c.TaskProcessor.QueueSize, 1)
+	v.positive("TASK_PROCESSOR_POLL_INTERVAL", c.TaskProcessor.PollInterval)
+
+	v.positive("STORAGE_GC_INTERVAL", c.StorageGC.Interval)
+	v.nonNegative("STORAGE_GC_GRACE_PERIOD", c.StorageGC.GracePeriod)
+	v.positive("TRASH_RETENTION", c.Trash.Retention)
+	v.positive("TRASH_PURGE_INTERVAL", c.Trash.PurgeInterval)
+
+	v.atLeast("TAGS_MAX_PER_MEME", c.Tags.MaxPerMeme, 1)
+	v.atLeast("TAGS_AUTO_LIMIT", c.Tags.AutoLimit, 0)
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		v.fail("LOG_LEVEL: expected debug, info, warn or error, got %q", c.Log.Level)
+	}
+	v.oneOf("LOG_FORMAT", c.Log.Format, "json", "text")
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.fail("TRACING_SAMPLE_RATIO: expected a value between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Enabled {
+		v.required("TRACING_OTLP_ENDPOINT", c.Tracing.OTLPEndpoint)
+	}
+
+	v.positive("HEALTH_CHECK_TIMEOUT", c.Health.CheckTimeout)
+	v.nonNegative("HEALTH_AI_CACHE_TTL", c.Health.AICacheTTL)
+	v.positive("STYLES_CACHE_TTL", c.Styles.CacheTTL)
+	v.positive("STYLES_REFRESH_INTERVAL", c.Styles.RefreshInterval)
+
+	if c.Env == EnvProduction {
+		c.validateSecrets(v)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// validateSecrets не даёт запустить production со значениями для локальной разработки
+func (c *Config) validateSecrets(v *validator) {
+	switch {
+	case c.JWT.SecretKey == "" || c.JWT.SecretKey == defaultJWTSecret:
+		v.fail("JWT_SECRET must be set in production")
+	case len(c.JWT.SecretKey) < minJWTSecretLength:
+		v.fail("JWT_SECRET must be at least %d bytes in production", minJWTSecretLength)
+	}
+
+	if !c.Cookie.Secure {
+		v.fail("COOKIE_SECURE must be true in production")
+	}
+
+	if c.Database.Password == "" || c.Database.Password == defaultDBPassword {
+		v.fail("DB_PASSWORD must be set in production")
+	}
+
+	if c.Storage.Backend != "local" {
+		if c.MinIO.AccessKey == "" || c.MinIO.AccessKey == defaultMinIOAccessKey {
+			v.fail("MINIO_ACCESS_KEY must be set in production")
+		}
+		if c.MinIO.SecretKey == "" || c.MinIO.SecretKey == defaultMinIOSecretKey {
+			v.fail("MINIO_SECRET_KEY must be set in production")
+		}
+	}
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) required(key, value string) {
+	if value == "" {
+		v.fail("%s must not be empty", key)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.fail("%s: expected one of %v, got %q", key, allowed, value)
+}
+
+func (v *validator) port(key, value string) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		v.fail("%s: invalid port %q", key, value)
+	}
+}
+
+// listenAddr проверяет адрес вида host:port или :port и возвращает порт
+func (v *validator) listenAddr(key, value string) string {
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		v.fail("%s: invalid address %q, expected host:port", key, value)
+		return ""
+	}
+	v.port(key, port)
+	return port
+}
+
+func (v *validator) httpURL(key, value string) {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.fail("%s: invalid URL %q", key, value)
+	}
+}
+
+// origin проверяет значение Access-Control-Allow-Origin: схема и хост без пути.
+// "*" запрещён - вместе с credentials он открывает API любому сайту
+func (v *validator) origin(key, value string) {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+		parsed.Path != "" || parsed.RawQuery != "" || parsed.User != nil {
+		v.fail("%s: invalid origin %q, expected scheme://host[:port]", key, value)
+	}
+}
+
+// hosts проверяет список хостов вида example.com или *.example.com
+func (v *validator) hosts(key string, hosts []string) {
+	for _, host := range hosts {
+		if strings.Contains(strings.TrimPrefix(host, "*."), "*") || strings.ContainsAny(host, "/:") {
+			v.fail("%s: invalid host %q, expected example.com or *.example.com", key, host)
+		}
+	}
+}
+
+func (v *validator) positive(key string, value time.Duration) {
+	if value <= 0 {
+		v.fail("%s must be positive, got %s", key, value)
+	}
+}
+
+func (v *validator) nonNegative(key string, value time.Duration) {
+	if value < 0 {
+		v.fail("%s must not be negative, got %s", key, value)
+	}
+}
+
+func (v *validator) atLeast(key string, value, min int) {
+	if value < min {
+		v.fail("%s must be at least %d, got %d", key, min, value)
+	}
+}
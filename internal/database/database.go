@@ -2,14 +2,15 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	"memology-backend/internal/config"
-	"memology-backend/internal/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
 )
 
 func Connect(cfg *config.DatabaseConfig) *gorm.DB {
@@ -24,23 +25,20 @@ func Connect(cfg *config.DatabaseConfig) *gorm.DB {
 	for i := 0; i < maxRetries; i++ {
 		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
 		if err == nil {
-			log.Printf("Successfully connected to database on attempt %d", i+1)
+			// Значения параметров не попадают в спаны: там бывают хеши паролей и токенов
+			if err := db.Use(otelgorm.NewPlugin(otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+				slog.Error("failed to enable database tracing", "error", err)
+				os.Exit(1)
+			}
+			slog.Info("connected to database", "attempt", i+1)
 			return db
 		}
 
-		log.Printf("Failed to connect to database (attempt %d/%d): %v", i+1, maxRetries, err)
+		slog.Warn("failed to connect to database", "attempt", i+1, "max_attempts", maxRetries, "error", err)
 		time.Sleep(3 * time.Second)
 	}
 
-	log.Fatal("Failed to connect to database after all retries:", err)
+	slog.Error("failed to connect to database after all retries", "error", err)
+	os.Exit(1)
 	return nil
 }
-
-func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.User{},
-		&models.UserSession{},
-		&models.Meme{},
-		&models.MemeMetrics{},
-	)
-}
@@ -0,0 +1,5 @@
+package database
+
+const MigrationLockID = migrationLockID
+
+var LoadMigrations = loadMigrations
@@ -0,0 +1,438 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"memology-backend/internal/database"
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/testdb"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	db         *gorm.DB
+	skipReason string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	flag.Parse()
+	if testing.Short() {
+		skipReason = "migration tests are skipped in -short mode"
+		return m.Run()
+	}
+
+	var stop func()
+	var err error
+	db, stop, err = testdb.OpenEmpty()
+	if err != nil {
+		if testdb.Required() {
+			fmt.Fprintln(os.Stderr, "failed to open test database:", err)
+			return 1
+		}
+		skipReason = "no test database: " + err.Error()
+		return m.Run()
+	}
+	defer stop()
+
+	return m.Run()
+}
+
+// emptyDatabase пересоздаёт схему public, чтобы тест начинал с пустой базы
+func emptyDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	if db == nil {
+		t.Skip(skipReason)
+	}
+	for _, stmt := range []string{"DROP SCHEMA public CASCADE", "CREATE SCHEMA public"} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed to reset database: %v", err)
+		}
+	}
+	return db
+}
+
+func newMigrator(t *testing.T, db *gorm.DB) *database.Migrator {
+	t.Helper()
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	migrations, err := database.LoadMigrations(fstest.MapFS{
+		"migrations/0002_second.up.sql":   file("up 2"),
+		"migrations/0002_second.down.sql": file("down 2"),
+		"migrations/0001_first.up.sql":    file("up 1"),
+		"migrations/0001_first.down.sql":  file("down 1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("expected migrations 1 and 2 in order, got %+v", migrations)
+	}
+	if migrations[0].Name != "first" || migrations[0].Up != "up 1" || migrations[0].Down != "down 1" {
+		t.Fatalf("unexpected migration %+v", migrations[0])
+	}
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			name:  "missing down",
+			files: fstest.MapFS{"migrations/0001_first.up.sql": file("up")},
+			want:  "must have both up and down",
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"migrations/0001_first.up.sql":   file("up"),
+				"migrations/0001_other.down.sql": file("down"),
+			},
+			want: "conflicting names",
+		},
+		{
+			name:  "unexpected file",
+			files: fstest.MapFS{"migrations/README.md": file("")},
+			want:  "unexpected migration file name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := database.LoadMigrations(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Встроенные миграции тоже должны загружаться
+	if _, err := database.NewMigrator(nil); err != nil {
+		t.Fatalf("embedded migrations: %v", err)
+	}
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	ctx := context.Background()
+	migrator := newMigrator(t, emptyDatabase(t))
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) == 0 || len(statuses) != len(applied) {
+		t.Fatalf("expected all %d migrations applied, got status %+v", len(applied), statuses)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Fatalf("migration %d_%s is not applied", status.Version, status.Name)
+		}
+	}
+
+	again, err := migrator.Up(ctx)
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second Up must do nothing, applied %d: %v", len(again), err)
+	}
+}
+
+// legacySchema - схема, которую создавал AutoMigrate первой версии вместе с
+// migrations/add_is_public_to_memes.sql, и данные в ней
+const legacySchema = `
+CREATE TABLE users (
+	id uuid DEFAULT gen_random_uuid(),
+	username text NOT NULL,
+	email text,
+	password_hash text NOT NULL,
+	avatar_url text,
+	is_active boolean DEFAULT true,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_users_username UNIQUE (username),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE user_sessions (
+	id uuid DEFAULT gen_random_uuid(),
+	user_id uuid NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_users_sessions FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE memes (
+	id uuid DEFAULT gen_random_uuid(),
+	user_id uuid NOT NULL,
+	prompt text NOT NULL,
+	style text,
+	image_url text,
+	width bigint DEFAULT 500,
+	height bigint DEFAULT 500,
+	aspect_ratio text DEFAULT '1:1',
+	task_id text,
+	generation_time_ms bigint,
+	status text DEFAULT 'pending',
+	is_public boolean DEFAULT true,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_users_memes FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_memes_deleted_at ON memes (deleted_at);
+CREATE INDEX idx_memes_is_public ON memes (is_public);
+CREATE INDEX idx_memes_public_created ON memes (is_public, created_at DESC) WHERE is_public = true;
+
+CREATE TABLE meme_metrics (
+	id uuid DEFAULT gen_random_uuid(),
+	meme_id uuid NOT NULL,
+	rating_score bigint DEFAULT 0,
+	click_count bigint DEFAULT 0,
+	download_count bigint DEFAULT 0,
+	other_interactions bigint DEFAULT 0,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_meme_metrics_meme_id UNIQUE (meme_id),
+	CONSTRAINT fk_memes_metrics FOREIGN KEY (meme_id) REFERENCES memes (id)
+);
+
+INSERT INTO users (id, username, email, password_hash, created_at, updated_at)
+VALUES ('10000000-0000-0000-0000-000000000001', 'legacy', 'legacy@example.com', 'hash', now(), now());
+
+INSERT INTO memes (id, user_id, prompt, style, task_id, status, is_public, created_at, updated_at) VALUES
+	('20000000-0000-0000-0000-000000000001', '10000000-0000-0000-0000-000000000001',
+		'кот программист', 'anime', 'task-1', 'completed', true, now(), now()),
+	('20000000-0000-0000-0000-000000000002', '10000000-0000-0000-0000-000000000001',
+		'понедельник', 'drake', '', 'completed', NULL, now(), now());
+
+INSERT INTO meme_metrics (meme_id, click_count, created_at, updated_at)
+VALUES ('20000000-0000-0000-0000-000000000001', 3, now(), now());
+`
+
+func TestMigrateLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	db := emptyDatabase(t)
+
+	for _, stmt := range strings.Split(legacySchema, ";\n") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed to create legacy schema: %v", err)
+		}
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate on legacy schema: %v", err)
+	}
+
+	users := repository.NewUserRepository(db)
+	memes := repository.NewMemeRepository(db)
+
+	user, err := users.GetByUsername(ctx, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsAdmin {
+		t.Fatal("legacy user must not become admin")
+	}
+
+	aiMeme, err := memes.GetByID(ctx, uuid.MustParse("20000000-0000-0000-0000-000000000001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aiMeme.Kind != models.MemeKindAI || aiMeme.Generation == nil || aiMeme.Generation.TaskID != "task-1" {
+		t.Fatalf("AI meme provenance was not restored: kind %q %+v", aiMeme.Kind, aiMeme.Generation)
+	}
+	if aiMeme.Metrics == nil || aiMeme.Metrics.ClickCount != 3 {
+		t.Fatalf("metrics were lost: %+v", aiMeme.Metrics)
+	}
+
+	templateMeme, err := memes.GetByID(ctx, uuid.MustParse("20000000-0000-0000-0000-000000000002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if templateMeme.Kind != models.MemeKindTemplate || templateMeme.Generation == nil || templateMeme.Generation.TemplateID != "drake" {
+		t.Fatalf("template meme provenance was not restored: kind %q %+v", templateMeme.Kind, templateMeme.Generation)
+	}
+	if !templateMeme.IsPublic {
+		t.Fatal("meme with NULL is_public must become public")
+	}
+
+	found, err := memes.List(ctx, repository.MemeFilter{Search: "программист"}, repository.Pagination{Limit: 10})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("legacy memes must be indexed for search, got %d: %v", len(found), err)
+	}
+
+	// Схема после обновления принимает новые мемы со всеми полями
+	meme := &models.Meme{UserID: user.ID, Prompt: "новый мем", Title: "Заголовок", Kind: models.MemeKindUpload, ContentHash: "abc"}
+	if err := memes.Create(ctx, meme); err != nil {
+		t.Fatalf("Create after upgrade: %v", err)
+	}
+}
+
+func TestMigratorDownAndStatus(t *testing.T) {
+	ctx := context.Background()
+	db := emptyDatabase(t)
+	migrator := newMigrator(t, db)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := applied[len(applied)-1]
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != last.Version {
+		t.Fatalf("Down(1) must revert migration %d, got %+v", last.Version, reverted)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if pending := status.Version == last.Version; pending != (status.AppliedAt == nil) {
+			t.Fatalf("unexpected status of migration %d: applied at %v", status.Version, status.AppliedAt)
+		}
+	}
+
+	if again, err := migrator.Up(ctx); err != nil || len(again) != 1 || again[0].Version != last.Version {
+		t.Fatalf("Up after Down must reapply migration %d, got %+v: %v", last.Version, again, err)
+	}
+
+	// Полный откат удаляет все таблицы приложения, после него схема создаётся заново
+	reverted, err = migrator.Down(ctx, len(applied))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != len(applied) {
+		t.Fatalf("expected %d reverted migrations, got %d", len(applied), len(reverted))
+	}
+	var tables int64
+	err = db.Raw(`SELECT count(*) FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name <> 'schema_migrations'`).Scan(&tables).Error
+	if err != nil || tables != 0 {
+		t.Fatalf("expected no tables after full Down, got %d: %v", tables, err)
+	}
+	if again, err := migrator.Up(ctx); err != nil || len(again) != len(applied) {
+		t.Fatalf("Up after full Down applied %d of %d: %v", len(again), len(applied), err)
+	}
+
+	// Версия из более новой сборки видна в статусе, но откатить её нельзя
+	if err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (9999, 'future')`).Error; err != nil {
+		t.Fatal(err)
+	}
+	statuses, err = migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if future := statuses[len(statuses)-1]; future.Version != 9999 || future.AppliedAt == nil {
+		t.Fatalf("Status must list unknown applied versions, got %+v", future)
+	}
+	if _, err := migrator.Down(ctx, 1); !errors.Is(err, database.ErrNoDownMigration) {
+		t.Fatalf("Down of unknown version: expected ErrNoDownMigration, got %v", err)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	ctx := context.Background()
+	db := emptyDatabase(t)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Пока блокировку держит другая реплика, миграции ждут
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, database.MigrationLockID); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- database.Migrate(db) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Migrate finished while the lock was held: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, database.MigrationLockID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Migrate after the lock was released: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("Migrate did not finish after the lock was released")
+	}
+
+	// Реплики, стартующие одновременно, применяют каждую миграцию ровно один раз
+	db = emptyDatabase(t)
+	const replicas = 4
+	errs := make(chan error, replicas)
+	for i := 0; i < replicas; i++ {
+		go func() { errs <- database.Migrate(db) }()
+	}
+	for i := 0; i < replicas; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent Migrate: %v", err)
+		}
+	}
+
+	statuses, err := newMigrator(t, db).Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied int64
+	if err := db.Raw(`SELECT count(*) FROM schema_migrations`).Scan(&applied).Error; err != nil {
+		t.Fatal(err)
+	}
+	if applied != int64(len(statuses)) {
+		t.Fatalf("expected %d applied migrations, got %d", len(statuses), applied)
+	}
+}
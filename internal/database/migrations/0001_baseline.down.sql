@@ -0,0 +1,12 @@
+-- Откат базовой схемы удаляет все таблицы приложения вместе с данными
+DROP TABLE IF EXISTS meme_tags;
+DROP TABLE IF EXISTS meme_metrics;
+DROP TABLE IF EXISTS style_overrides;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS memes;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS users;
+
+DROP FUNCTION IF EXISTS meme_tags_search_vector_trigger();
+DROP FUNCTION IF EXISTS memes_search_vector_trigger();
+DROP FUNCTION IF EXISTS meme_search_vector(uuid, text, text, text);
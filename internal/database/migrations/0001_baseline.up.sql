@@ -0,0 +1,189 @@
+-- Базовая схема: то, что до появления миграций создавали AutoMigrate, database.Migrate
+-- и migrations/add_is_public_to_memes.sql. Все операторы идемпотентны, поэтому база,
+-- уже созданная AutoMigrate, получает отметку о версии 1. В базе первой версии схемы
+-- таблицы уже есть без колонок, добавленных позже, и CREATE TABLE IF NOT EXISTS их
+-- не создаст: такие колонки добавляются отдельно через ADD COLUMN IF NOT EXISTS.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS users (
+	id uuid DEFAULT gen_random_uuid(),
+	username text NOT NULL UNIQUE,
+	email text UNIQUE,
+	password_hash text NOT NULL,
+	avatar_url text,
+	is_active boolean DEFAULT true,
+	is_admin boolean NOT NULL DEFAULT false,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id)
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+	id uuid DEFAULT gen_random_uuid(),
+	user_id uuid NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_users_sessions FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS memes (
+	id uuid DEFAULT gen_random_uuid(),
+	user_id uuid NOT NULL,
+	prompt text NOT NULL,
+	title varchar(200),
+	description text,
+	style text,
+	image_url text,
+	width bigint DEFAULT 500,
+	height bigint DEFAULT 500,
+	aspect_ratio text DEFAULT '1:1',
+	kind varchar(20) NOT NULL DEFAULT 'ai',
+	task_id text,
+	ai_backend varchar(100),
+	generation jsonb,
+	generation_time_ms bigint,
+	status text DEFAULT 'pending',
+	is_public boolean NOT NULL DEFAULT true,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_users_memes FOREIGN KEY (user_id) REFERENCES users (id)
+);
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS title varchar(200);
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS description text;
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS kind varchar(20) NOT NULL DEFAULT 'ai';
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS ai_backend varchar(100);
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS generation jsonb;
+-- is_public добавлял migrations/add_is_public_to_memes.sql без NOT NULL
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS is_public boolean NOT NULL DEFAULT true;
+UPDATE memes SET is_public = true WHERE is_public IS NULL;
+ALTER TABLE memes ALTER COLUMN is_public SET DEFAULT true, ALTER COLUMN is_public SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_memes_deleted_at ON memes (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_memes_kind ON memes (kind);
+CREATE INDEX IF NOT EXISTS idx_memes_created_id ON memes (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_memes_user_created_id ON memes (user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_memes_is_public ON memes (is_public);
+CREATE INDEX IF NOT EXISTS idx_memes_public_created ON memes (is_public, created_at DESC) WHERE is_public = true;
+
+CREATE TABLE IF NOT EXISTS meme_metrics (
+	id uuid DEFAULT gen_random_uuid(),
+	meme_id uuid NOT NULL UNIQUE,
+	rating_score bigint DEFAULT 0,
+	click_count bigint DEFAULT 0,
+	download_count bigint DEFAULT 0,
+	other_interactions bigint DEFAULT 0,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_memes_metrics FOREIGN KEY (meme_id) REFERENCES memes (id)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id uuid DEFAULT gen_random_uuid(),
+	name text NOT NULL,
+	slug text NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_slug ON tags (slug);
+
+CREATE TABLE IF NOT EXISTS meme_tags (
+	meme_id uuid,
+	tag_id uuid,
+	PRIMARY KEY (meme_id, tag_id),
+	CONSTRAINT fk_meme_tags_meme FOREIGN KEY (meme_id) REFERENCES memes (id) ON DELETE CASCADE,
+	CONSTRAINT fk_meme_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS style_overrides (
+	name varchar(100),
+	hidden boolean NOT NULL DEFAULT false,
+	display_name varchar(100),
+	description text,
+	preview_url text,
+	updated_at timestamptz,
+	PRIMARY KEY (name)
+);
+
+-- Мемы, созданные до появления kind: шаблонные не имели task_id и хранили id шаблона в style
+UPDATE memes SET kind = 'template', generation = jsonb_build_object('template_id', style), style = ''
+WHERE generation IS NULL AND kind = 'ai' AND (task_id = '' OR task_id IS NULL);
+UPDATE memes SET generation = jsonb_strip_nulls(jsonb_build_object('task_id', task_id, 'style', NULLIF(style, '')))
+WHERE generation IS NULL AND task_id <> '';
+
+-- Полнотекстовый поиск: заголовок, теги, промпт и описание индексируются в конфигурациях
+-- russian и english (веса A-D), вектор пересчитывается триггерами при изменении мема и его тегов
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION meme_search_vector(p_meme_id uuid, p_prompt text, p_title text, p_description text)
+RETURNS tsvector AS $$
+DECLARE
+	tag_text text;
+BEGIN
+	SELECT string_agg(tags.name, ' ') INTO tag_text
+	FROM meme_tags JOIN tags ON tags.id = meme_tags.tag_id
+	WHERE meme_tags.meme_id = p_meme_id;
+
+	RETURN setweight(to_tsvector('russian', coalesce(p_title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(p_title, '')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(tag_text, '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(tag_text, '')), 'B') ||
+		setweight(to_tsvector('russian', coalesce(p_prompt, '')), 'C') ||
+		setweight(to_tsvector('english', coalesce(p_prompt, '')), 'C') ||
+		setweight(to_tsvector('russian', coalesce(p_description, '')), 'D') ||
+		setweight(to_tsvector('english', coalesce(p_description, '')), 'D');
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION memes_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector := meme_search_vector(NEW.id, NEW.prompt, NEW.title, NEW.description);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS memes_search_vector_insert ON memes;
+CREATE TRIGGER memes_search_vector_insert BEFORE INSERT ON memes
+FOR EACH ROW EXECUTE FUNCTION memes_search_vector_trigger();
+
+DROP TRIGGER IF EXISTS memes_search_vector_update ON memes;
+CREATE TRIGGER memes_search_vector_update BEFORE UPDATE ON memes
+FOR EACH ROW WHEN (
+	OLD.prompt IS DISTINCT FROM NEW.prompt OR
+	OLD.title IS DISTINCT FROM NEW.title OR
+	OLD.description IS DISTINCT FROM NEW.description
+) EXECUTE FUNCTION memes_search_vector_trigger();
+
+CREATE OR REPLACE FUNCTION meme_tags_search_vector_trigger() RETURNS trigger AS $$
+DECLARE
+	changed_meme_id uuid;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		changed_meme_id := OLD.meme_id;
+	ELSE
+		changed_meme_id := NEW.meme_id;
+	END IF;
+
+	UPDATE memes
+	SET search_vector = meme_search_vector(id, prompt, title, description)
+	WHERE id = changed_meme_id;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS meme_tags_search_vector ON meme_tags;
+CREATE TRIGGER meme_tags_search_vector AFTER INSERT OR DELETE ON meme_tags
+FOR EACH ROW EXECUTE FUNCTION meme_tags_search_vector_trigger();
+
+UPDATE memes SET search_vector = meme_search_vector(id, prompt, title, description)
+WHERE search_vector IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_memes_search_vector ON memes USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_memes_prompt_trgm ON memes USING GIN (prompt gin_trgm_ops);
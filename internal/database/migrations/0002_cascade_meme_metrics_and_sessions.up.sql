@@ -0,0 +1,9 @@
+-- Метрики и сессии удаляются вместе с мемом и пользователем: GORM не создавал
+-- ON DELETE CASCADE для этих связей, и жёсткое удаление мема с метриками падало
+ALTER TABLE meme_metrics DROP CONSTRAINT IF EXISTS fk_memes_metrics;
+ALTER TABLE meme_metrics ADD CONSTRAINT fk_memes_metrics
+	FOREIGN KEY (meme_id) REFERENCES memes (id) ON DELETE CASCADE;
+
+ALTER TABLE user_sessions DROP CONSTRAINT IF EXISTS fk_users_sessions;
+ALTER TABLE user_sessions ADD CONSTRAINT fk_users_sessions
+	FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_memes_user_content_hash;
+ALTER TABLE memes DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,6 @@
+-- SHA-256 загруженной картинки: повторная загрузка того же файла пользователем
+-- возвращает уже существующий мем. У сгенерированных мемов хэш пустой
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS content_hash varchar(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_memes_user_content_hash ON memes (user_id, content_hash)
+	WHERE content_hash <> '' AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_memes_user_content_hash;
+CREATE INDEX idx_memes_user_content_hash ON memes (user_id, content_hash)
+	WHERE content_hash <> '' AND deleted_at IS NULL;
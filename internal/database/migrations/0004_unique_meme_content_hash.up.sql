@@ -0,0 +1,13 @@
+-- Дедупликация загрузок проверяла хэш перед вставкой, и параллельные загрузки одного
+-- файла могли создать несколько мемов. У более поздних копий хэш сбрасывается, чтобы
+-- индекс стал уникальным: мемы остаются, просто повторная загрузка вернёт самый ранний
+UPDATE memes m SET content_hash = ''
+WHERE m.content_hash <> '' AND m.deleted_at IS NULL AND EXISTS (
+	SELECT 1 FROM memes o
+	WHERE o.user_id = m.user_id AND o.content_hash = m.content_hash AND o.deleted_at IS NULL
+		AND (o.created_at, o.id) < (m.created_at, m.id)
+);
+
+DROP INDEX IF EXISTS idx_memes_user_content_hash;
+CREATE UNIQUE INDEX idx_memes_user_content_hash ON memes (user_id, content_hash)
+	WHERE content_hash <> '' AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_memes_stuck;
+ALTER TABLE memes DROP COLUMN IF EXISTS status_updated_at;
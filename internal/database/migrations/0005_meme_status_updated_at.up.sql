@@ -0,0 +1,7 @@
+-- Время последнего изменения статуса генерации. updated_at меняют только правки
+-- пользователя (из него строится ETag), поэтому сканер зависших задач смотрит сюда
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS status_updated_at timestamptz NOT NULL DEFAULT now();
+UPDATE memes SET status_updated_at = updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_memes_stuck ON memes (status_updated_at)
+	WHERE status IN ('pending', 'processing', 'failed') AND deleted_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE memes DROP COLUMN IF EXISTS thumbnail_url;
@@ -0,0 +1,2 @@
+-- Превью для лент лежит рядом с оригиналом. У старых мемов превью нет, клиенты берут image_url
+ALTER TABLE memes ADD COLUMN IF NOT EXISTS thumbnail_url text NOT NULL DEFAULT '';
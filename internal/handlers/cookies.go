@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"net/http"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+const refreshTokenMaxAge = 604800
+
+// authCookies выставляет cookie с токенами и CSRF-токеном с атрибутами из конфигурации
+type authCookies struct {
+	cfg *config.CookieConfig
+}
+
+// set выдаёт токены и новый CSRF-токен; он же возвращается в заголовке X-CSRF-Token
+// для фронтенда на другом домене, который не видит cookie API
+func (a authCookies) set(c *gin.Context, accessToken, refreshToken string, expiresIn int64) {
+	csrfToken := rand.Text()
+
+	a.write(c, middleware.AccessTokenCookie, accessToken, int(expiresIn), true)
+	a.write(c, middleware.RefreshTokenCookie, refreshToken, refreshTokenMaxAge, true)
+	a.write(c, middleware.CSRFCookie, csrfToken, refreshTokenMaxAge, false)
+	c.Header(middleware.CSRFHeader, csrfToken)
+}
+
+func (a authCookies) clear(c *gin.Context) {
+	a.write(c, middleware.AccessTokenCookie, "", -1, true)
+	a.write(c, middleware.RefreshTokenCookie, "", -1, true)
+	a.write(c, middleware.CSRFCookie, "", -1, false)
+}
+
+func (a authCookies) write(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     "/",
+		Domain:   a.cfg.Domain,
+		Secure:   a.cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(a.cfg.SameSite),
+	})
+}
+
+func sameSite(value string) http.SameSite {
+	switch value {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
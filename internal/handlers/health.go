@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"memology-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthChecker *services.HealthChecker
+}
+
+func NewHealthHandler(healthChecker *services.HealthChecker) *HealthHandler {
+	return &HealthHandler{healthChecker: healthChecker}
+}
+
+// Liveness - процесс жив и обрабатывает запросы, зависимости не проверяются
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": services.HealthStatusOK})
+}
+
+// Readiness - готовность принимать трафик: 503, если недоступна критичная зависимость
+// или сервер завершает работу
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthChecker.Readiness(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
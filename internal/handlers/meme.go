@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
 	"memology-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -24,11 +29,14 @@ func NewMemeHandler(memeService services.MemeService) *MemeHandler {
 	}
 }
 
+// MemeHistoryResponse - страница мемов. total отсутствует при include_total=false,
+// next_cursor - при отсутствии следующей страницы
 type MemeHistoryResponse struct {
-	Memes []*models.Meme `json:"memes"`
-	Total int64          `json:"total"`
-	Page  int            `json:"page"`
-	Limit int            `json:"limit"`
+	Memes      []*models.Meme `json:"memes"`
+	Total      *int64         `json:"total,omitempty"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
 
 // @Summary Generate new meme
@@ -40,6 +48,8 @@ type MemeHistoryResponse struct {
 // @Success 201 {object} models.Meme
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Meme quota exceeded"
+// @Failure 503 {object} ErrorResponse "AI service is unavailable"
 // @Router /memes/generate [post]
 func (h *MemeHandler) GenerateMeme(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -59,8 +69,25 @@ func (h *MemeHandler) GenerateMeme(c *gin.Context) {
 		return
 	}
 
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	meme, err := h.memeService.CreateMeme(c.Request.Context(), userID.(uuid.UUID), req)
 	if err != nil {
+		if err == services.ErrTooManyTags || err == services.ErrStyleNotSupported || err == services.ErrUnknownStyle {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrQuotaExceeded) {
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrAIUnavailable) {
+			respondAIUnavailable(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -78,6 +105,9 @@ func (h *MemeHandler) GenerateMeme(c *gin.Context) {
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Meme quota exceeded"
+// @Failure 502 {object} ErrorResponse "Template image was rejected by the fetcher"
+// @Failure 503 {object} ErrorResponse "AI service is unavailable"
 // @Router /memes/generate-template [post]
 func (h *MemeHandler) GenerateTemplateMeme(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -97,8 +127,29 @@ func (h *MemeHandler) GenerateTemplateMeme(c *gin.Context) {
 		return
 	}
 
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	meme, err := h.memeService.CreateTemplateMeme(c.Request.Context(), userID.(uuid.UUID), req)
 	if err != nil {
+		if err == services.ErrTooManyTags {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrQuotaExceeded) {
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrAIUnavailable) {
+			respondAIUnavailable(c)
+			return
+		}
+		if errors.Is(err, services.ErrFetchRejected) {
+			c.JSON(http.StatusBadGateway, ErrorResponse{Error: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -136,68 +187,306 @@ func (h *MemeHandler) GetMeme(c *gin.Context) {
 		}
 	}
 
+	c.Header("ETag", memeETag(meme))
 	c.JSON(http.StatusOK, meme)
 }
 
-// @Summary Get user memes
-// @Description Get list of memes created by current user with pagination and optional search
+// @Summary Update meme
+// @Description Update title, description, displayed prompt or visibility of own meme. Only passed fields are changed. Send the ETag from the last read in If-Match (or its updated_at in the body) to get 412 instead of overwriting a concurrent edit.
 // @Tags memes
+// @Accept json
 // @Produce json
-// @Param page query int false "Page number" default(1)
-// @Param limit query int false "Items per page" default(20)
-// @Param search query string false "Search by prompt"
-// @Success 200 {object} MemeHistoryResponse
+// @Param id path string true "Meme ID"
+// @Param If-Match header string false "ETag from previous GET"
+// @Param request body services.UpdateMemeRequest true "Fields to update"
+// @Success 200 {object} models.Meme
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
-// @Router /memes/my [get]
-func (h *MemeHandler) GetMyMemes(c *gin.Context) {
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Router /memes/{id} [patch]
+func (h *MemeHandler) UpdateMeme(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
 		return
 	}
 
-	page := 1
-	if p, exists := c.GetQuery("page"); exists {
-		if val, err := strconv.Atoi(p); err == nil && val > 0 {
-			page = val
+	memeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid meme ID"})
+		return
+	}
+
+	var req services.UpdateMemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && ifMatch != "*" {
+		updatedAt, ok := parseMemeETag(ifMatch)
+		if !ok {
+			c.JSON(http.StatusPreconditionFailed, ErrorResponse{Error: "invalid If-Match header"})
+			return
 		}
+		req.UpdatedAt = &updatedAt
 	}
 
-	limit := 20
-	if l, exists := c.GetQuery("limit"); exists {
-		if val, err := strconv.Atoi(l); err == nil && val > 0 && val <= 100 {
-			limit = val
+	meme, err := h.memeService.UpdateMeme(c.Request.Context(), userID.(uuid.UUID), memeID, req)
+	if err != nil {
+		if err == services.ErrMemeNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "meme not found"})
+			return
+		}
+		if err == services.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "unauthorized to update this meme"})
+			return
+		}
+		if err == services.ErrMemeModified {
+			c.JSON(http.StatusPreconditionFailed, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == services.ErrTooManyTags {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Header("ETag", memeETag(meme))
+	c.JSON(http.StatusOK, meme)
+}
+
+// respondAIUnavailable - AI-сервис не отвечает или circuit breaker разомкнут: клиенту стоит повторить позже
+func respondAIUnavailable(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "AI service is temporarily unavailable, try again later"})
+}
+
+// parseMemeFilter читает фильтры выдачи из query-параметров
+func parseMemeFilter(c *gin.Context) (repository.MemeFilter, error) {
+	filter := repository.MemeFilter{
+		Search:         c.Query("search"),
+		Tags:           parseTagFilter(c),
+		Style:          c.Query("style"),
+		Status:         c.Query("status"),
+		AuthorUsername: c.Query("author"),
+		AspectRatio:    c.Query("aspect_ratio"),
+		Kind:           c.Query("kind"),
+	}
+
+	switch filter.Status {
+	case "", "pending", "processing", "completed", "failed":
+	default:
+		return filter, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+
+	switch filter.Kind {
+	case "", models.MemeKindAI, models.MemeKindTemplate, models.MemeKindUpload:
+	default:
+		return filter, fmt.Errorf("invalid kind: %s", filter.Kind)
+	}
+
+	if value := c.Query("created_from"); value != "" {
+		from, _, err := parseDateParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_from: %s", value)
 		}
+		filter.CreatedFrom = &from
 	}
 
-	search := c.Query("search")
+	if value := c.Query("created_to"); value != "" {
+		to, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_to: %s", value)
+		}
+		// Дата без времени включает весь день
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = &to
+	}
 
-	offset := (page - 1) * limit
+	return filter, nil
+}
+
+// parseDateParam принимает RFC3339 или YYYY-MM-DD (UTC), второй результат - была ли передана только дата
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
 
-	memes, total, err := h.memeService.GetUserMemes(c.Request.Context(), userID.(uuid.UUID), limit, offset, search)
+// parseTagFilter читает ?tag=a&tag=b или ?tag=a,b и tag_mode=any|all
+func parseTagFilter(c *gin.Context) repository.TagFilter {
+	filter := repository.TagFilter{MatchAll: c.Query("tag_mode") == "all"}
+
+	seen := make(map[string]bool)
+	for _, value := range c.QueryArray("tag") {
+		for _, raw := range strings.Split(value, ",") {
+			slug := services.NormalizeTag(raw)
+			if slug == "" || seen[slug] {
+				continue
+			}
+			seen[slug] = true
+			filter.Slugs = append(filter.Slugs, slug)
+		}
+	}
+
+	return filter
+}
+
+// memeETag строит ETag из updated_at с точностью до микросекунд (как хранит Postgres)
+func memeETag(meme *models.Meme) string {
+	return fmt.Sprintf(`"%d"`, meme.UpdatedAt.UnixMicro())
+}
+
+func parseMemeETag(etag string) (time.Time, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	micros, err := strconv.ParseInt(strings.Trim(etag, `"`), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return time.Time{}, false
+	}
+	return time.UnixMicro(micros), true
+}
+
+// @Summary Get user memes
+// @Description Get list of memes created by current user with pagination and optional search. Pass next_cursor from the previous response as cursor to get the next page without duplicates; page is kept for backward compatibility.
+// @Tags memes
+// @Produce json
+// @Param page query int false "Page number (ignored when cursor is set)" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "next_cursor from previous response"
+// @Param include_total query bool false "Count total matching memes" default(true)
+// @Param search query string false "Full-text search by title, tags, prompt and description (word forms and typos are matched)"
+// @Param tag query []string false "Filter by tag slug (repeat or comma-separate for several)" collectionFormat(multi)
+// @Param tag_mode query string false "any - meme has at least one tag, all - meme has every tag" Enums(any, all) default(any)
+// @Param style query string false "Filter by generation style"
+// @Param status query string false "Filter by status" Enums(pending, processing, completed, failed)
+// @Param created_from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created before (RFC3339, or YYYY-MM-DD inclusive)"
+// @Param aspect_ratio query string false "Filter by aspect ratio, e.g. 1:1"
+// @Param kind query string false "ai - generated by neural network, template - memegen template, upload - uploaded image" Enums(ai, template, upload)
+// @Success 200 {object} MemeHistoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /memes/my [get]
+func (h *MemeHandler) GetMyMemes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
 		return
 	}
 
-	c.JSON(http.StatusOK, MemeHistoryResponse{
-		Memes: memes,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	})
+	page, pageReq := parsePageRequest(c)
+	filter, err := parseMemeFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter.AuthorUsername = ""
+
+	list, err := h.memeService.GetUserMemes(c.Request.Context(), userID.(uuid.UUID), filter, pageReq)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newMemeHistoryResponse(list, page, pageReq.Limit))
 }
 
 // @Summary Get public memes
-// @Description Get paginated list of public memes with optional search
+// @Description Get paginated list of public memes with optional search and tag filter. Pass next_cursor from the previous response as cursor to get the next page without duplicates; page is kept for backward compatibility.
 // @Tags memes
 // @Produce json
-// @Param page query int false "Page number" default(1)
+// @Param page query int false "Page number (ignored when cursor is set)" default(1)
 // @Param limit query int false "Items per page" default(20)
-// @Param search query string false "Search by prompt"
+// @Param cursor query string false "next_cursor from previous response"
+// @Param include_total query bool false "Count total matching memes" default(true)
+// @Param search query string false "Full-text search by title, tags, prompt and description (word forms and typos are matched)"
+// @Param tag query []string false "Filter by tag slug (repeat or comma-separate for several)" collectionFormat(multi)
+// @Param tag_mode query string false "any - meme has at least one tag, all - meme has every tag" Enums(any, all) default(any)
+// @Param style query string false "Filter by generation style"
+// @Param status query string false "Filter by status" Enums(pending, processing, completed, failed)
+// @Param created_from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created before (RFC3339, or YYYY-MM-DD inclusive)"
+// @Param aspect_ratio query string false "Filter by aspect ratio, e.g. 1:1"
+// @Param kind query string false "ai - generated by neural network, template - memegen template, upload - uploaded image" Enums(ai, template, upload)
+// @Param author query string false "Filter by author username"
 // @Success 200 {object} MemeHistoryResponse
+// @Failure 400 {object} ErrorResponse
 // @Router /memes/public [get]
 func (h *MemeHandler) GetPublicMemes(c *gin.Context) {
+	page, pageReq := parsePageRequest(c)
+	filter, err := parseMemeFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	list, err := h.memeService.GetPublicMemes(c.Request.Context(), filter, pageReq)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newMemeHistoryResponse(list, page, pageReq.Limit))
+}
+
+// @Summary List all memes (admin)
+// @Description Get paginated list of all memes, including private ones, with the same filters as public listing plus status and author
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number (ignored when cursor is set)" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "next_cursor from previous response"
+// @Param include_total query bool false "Count total memes" default(true)
+// @Param search query string false "Full-text search by title, tags, prompt and description (word forms and typos are matched)"
+// @Param tag query []string false "Filter by tag slug (repeat or comma-separate for several)" collectionFormat(multi)
+// @Param tag_mode query string false "any - meme has at least one tag, all - meme has every tag" Enums(any, all) default(any)
+// @Param style query string false "Filter by generation style"
+// @Param status query string false "Filter by status" Enums(pending, processing, completed, failed)
+// @Param created_from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created before (RFC3339, or YYYY-MM-DD inclusive)"
+// @Param aspect_ratio query string false "Filter by aspect ratio, e.g. 1:1"
+// @Param kind query string false "ai - generated by neural network, template - memegen template, upload - uploaded image" Enums(ai, template, upload)
+// @Param author query string false "Filter by author username"
+// @Security BearerAuth
+// @Success 200 {object} MemeHistoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/memes [get]
+// @Router /memes [get]
+func (h *MemeHandler) GetAllMemes(c *gin.Context) {
+	page, pageReq := parsePageRequest(c)
+	filter, err := parseMemeFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	list, err := h.memeService.GetAllMemes(c.Request.Context(), filter, pageReq)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newMemeHistoryResponse(list, page, pageReq.Limit))
+}
+
+// parsePageRequest читает page, limit, cursor и include_total. Номер страницы
+// возвращается отдельно, чтобы вернуть его в ответе
+func parsePageRequest(c *gin.Context) (int, services.PageRequest) {
 	page := 1
 	if p, exists := c.GetQuery("page"); exists {
 		if val, err := strconv.Atoi(p); err == nil && val > 0 {
@@ -212,33 +501,100 @@ func (h *MemeHandler) GetPublicMemes(c *gin.Context) {
 		}
 	}
 
-	search := c.Query("search")
+	req := services.PageRequest{
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+	if req.Cursor == "" {
+		req.Offset = (page - 1) * limit
+	}
+	if includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", "true")); err == nil {
+		req.SkipTotal = !includeTotal
+	}
 
-	offset := (page - 1) * limit
+	return page, req
+}
+
+func newMemeHistoryResponse(list *services.MemeList, page, limit int) MemeHistoryResponse {
+	return MemeHistoryResponse{
+		Memes:      list.Memes,
+		Total:      list.Total,
+		Page:       page,
+		Limit:      limit,
+		NextCursor: list.NextCursor,
+	}
+}
+
+func respondListError(c *gin.Context, err error) {
+	if err == services.ErrInvalidCursor {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+}
 
-	memes, total, err := h.memeService.GetPublicMemes(c.Request.Context(), limit, offset, search)
+// @Summary Delete meme
+// @Description Move meme to trash (only owner can delete). It can be restored via /memes/{id}/restore until the trash retention period expires.
+// @Tags memes
+// @Produce json
+// @Param id path string true "Meme ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /memes/{id} [delete]
+func (h *MemeHandler) DeleteMeme(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	memeID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid meme ID"})
+		return
+	}
+
+	err = h.memeService.DeleteMeme(c.Request.Context(), userID.(uuid.UUID), memeID)
+	if err != nil {
+		if err == services.ErrMemeNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "meme not found"})
+			return
+		}
+		if err == services.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "unauthorized to delete this meme"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, MemeHistoryResponse{
-		Memes: memes,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	})
+	c.JSON(http.StatusOK, MessageResponse{Message: "meme deleted successfully"})
+}
+
+type TrashResponse struct {
+	Memes []*services.TrashedMeme `json:"memes"`
+	Total int64                   `json:"total"`
+	Page  int                     `json:"page"`
+	Limit int                     `json:"limit"`
 }
 
-// @Summary Get all memes
-// @Description Get paginated list of all memes (admin only)
+// @Summary Get deleted memes
+// @Description Get memes deleted by current user. They can be restored until purge_at, after which they are removed permanently.
 // @Tags memes
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
-// @Success 200 {object} MemeHistoryResponse
-// @Router /memes [get]
-func (h *MemeHandler) GetAllMemes(c *gin.Context) {
+// @Success 200 {object} TrashResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /memes/trash [get]
+func (h *MemeHandler) GetTrash(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
 	page := 1
 	if p, exists := c.GetQuery("page"); exists {
 		if val, err := strconv.Atoi(p); err == nil && val > 0 {
@@ -255,13 +611,13 @@ func (h *MemeHandler) GetAllMemes(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	memes, total, err := h.memeService.GetAllMemes(c.Request.Context(), limit, offset)
+	memes, total, err := h.memeService.GetTrash(c.Request.Context(), userID.(uuid.UUID), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, MemeHistoryResponse{
+	c.JSON(http.StatusOK, TrashResponse{
 		Memes: memes,
 		Total: total,
 		Page:  page,
@@ -269,16 +625,18 @@ func (h *MemeHandler) GetAllMemes(c *gin.Context) {
 	})
 }
 
-// @Summary Delete meme
-// @Description Delete meme by ID (only owner can delete)
+// @Summary Restore deleted meme
+// @Description Restore meme from trash (only owner can restore)
 // @Tags memes
 // @Produce json
 // @Param id path string true "Meme ID"
-// @Success 200 {object} MessageResponse
+// @Success 200 {object} models.Meme
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
-// @Router /memes/{id} [delete]
-func (h *MemeHandler) DeleteMeme(c *gin.Context) {
+// @Router /memes/{id}/restore [post]
+func (h *MemeHandler) RestoreMeme(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
@@ -291,21 +649,21 @@ func (h *MemeHandler) DeleteMeme(c *gin.Context) {
 		return
 	}
 
-	err = h.memeService.DeleteMeme(c.Request.Context(), userID.(uuid.UUID), memeID)
+	meme, err := h.memeService.RestoreMeme(c.Request.Context(), userID.(uuid.UUID), memeID)
 	if err != nil {
 		if err == services.ErrMemeNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: "meme not found"})
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "meme not found in trash"})
 			return
 		}
 		if err == services.ErrUnauthorized {
-			c.JSON(http.StatusForbidden, ErrorResponse{Error: "unauthorized to delete this meme"})
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "unauthorized to restore this meme"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, MessageResponse{Message: "meme deleted successfully"})
+	c.JSON(http.StatusOK, meme)
 }
 
 // @Summary Check meme generation status
@@ -316,6 +674,7 @@ func (h *MemeHandler) DeleteMeme(c *gin.Context) {
 // @Success 200 {object} models.Meme
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "AI service is unavailable"
 // @Router /memes/{id}/status [get]
 func (h *MemeHandler) CheckMemeStatus(c *gin.Context) {
 	memeID, err := uuid.Parse(c.Param("id"))
@@ -330,6 +689,10 @@ func (h *MemeHandler) CheckMemeStatus(c *gin.Context) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "meme not found"})
 			return
 		}
+		if errors.Is(err, services.ErrAIUnavailable) {
+			respondAIUnavailable(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -338,18 +701,99 @@ func (h *MemeHandler) CheckMemeStatus(c *gin.Context) {
 }
 
 // @Summary Get available meme styles
-// @Description Get list of available meme generation styles from neural network
+// @Description Get the style catalog: name to pass in the generation request, display name, description, preview and availability. The catalog is cached and refreshed in the background; styles hidden by administrators are not listed
 // @Tags memes
 // @Produce json
-// @Success 200 {array} string
+// @Success 200 {array} services.Style
 // @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "AI service is unavailable"
 // @Router /memes/styles [get]
 func (h *MemeHandler) GetAvailableStyles(c *gin.Context) {
 	styles, err := h.memeService.GetAvailableStyles(c.Request.Context())
 	if err != nil {
+		if errors.Is(err, services.ErrAIUnavailable) {
+			respondAIUnavailable(c)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, styles)
 }
+
+// @Summary Get popular tags
+// @Description Get tags used by the largest number of public memes
+// @Tags tags
+// @Produce json
+// @Param limit query int false "Number of tags" default(20)
+// @Success 200 {array} repository.TagUsage
+// @Failure 500 {object} ErrorResponse
+// @Router /tags/popular [get]
+func (h *MemeHandler) GetPopularTags(c *gin.Context) {
+	limit := 20
+	if l, exists := c.GetQuery("limit"); exists {
+		if val, err := strconv.Atoi(l); err == nil && val > 0 && val <= 100 {
+			limit = val
+		}
+	}
+
+	tags, err := h.memeService.GetPopularTags(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// @Summary Get generation statistics (admin)
+// @Description Get generation latency percentiles (p50/p95), success and failure rates and volumes per kind and style for memes created in [from, to). Defaults to the last 7 days
+// @Tags stats
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Period start (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Period end, exclusive (RFC3339, or YYYY-MM-DD inclusive)"
+// @Success 200 {object} services.GenerationStatsReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stats/generation [get]
+func (h *MemeHandler) GetGenerationStats(c *gin.Context) {
+	to := time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		parsed, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid to: " + value})
+			return
+		}
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -7)
+	if value := c.Query("from"); value != "" {
+		parsed, _, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid from: " + value})
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to"})
+		return
+	}
+
+	report, err := h.memeService.GetGenerationStats(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
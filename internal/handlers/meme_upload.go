@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxUploadBodyBytes - жёсткий предел тела multipart-запроса, чтобы gin не сохранял
+// во временные файлы сколько угодно данных. Размер картинки ограничивает UPLOAD_MAX_BYTES
+const maxUploadBodyBytes = 64 << 20
+
+// @Summary Upload meme
+// @Description Create a completed meme from an uploaded PNG, JPEG or GIF image. Caption is stored as prompt and used for search and auto tags. Tags can be repeated or comma-separated. If the user already has a meme with the same image, it is returned with status 200 instead of creating a duplicate.
+// @Tags memes
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Image file"
+// @Param caption formData string false "Meme caption"
+// @Param title formData string false "Meme title"
+// @Param is_public formData bool false "Visibility, public by default"
+// @Param tags formData []string false "Tags" collectionFormat(multi)
+// @Success 201 {object} models.Meme
+// @Success 200 {object} models.Meme "The same image was already uploaded"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Meme quota exceeded"
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /memes/upload [post]
+func (h *MemeHandler) UploadMeme(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBodyBytes)
+
+	var req services.UploadMemeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: services.ErrFileTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	req.Tags = splitTags(req.Tags)
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "image file is required"})
+		return
+	}
+
+	meme, created, err := h.memeService.UploadMeme(c.Request.Context(), userID.(uuid.UUID), req, file)
+	respondUploadedMeme(c, meme, created, err)
+}
+
+// @Summary Import meme from URL
+// @Description Download a PNG, JPEG or GIF image from a public URL and create a completed meme from it, like /memes/upload. Private network addresses are rejected.
+// @Tags memes
+// @Accept json
+// @Produce json
+// @Param request body services.ImportMemeRequest true "Image URL and meme metadata"
+// @Success 201 {object} models.Meme
+// @Success 200 {object} models.Meme "The same image was already uploaded"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Meme quota exceeded"
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse "Image could not be downloaded"
+// @Security BearerAuth
+// @Router /memes/import [post]
+func (h *MemeHandler) ImportMeme(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req services.ImportMemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	meme, created, err := h.memeService.ImportMeme(c.Request.Context(), userID.(uuid.UUID), req)
+	respondUploadedMeme(c, meme, created, err)
+}
+
+func respondUploadedMeme(c *gin.Context, meme *models.Meme, created bool, err error) {
+	switch {
+	case err == nil && created:
+		c.JSON(http.StatusCreated, meme)
+	case err == nil:
+		c.JSON(http.StatusOK, meme)
+	case errors.Is(err, services.ErrFileTooLarge) || errors.Is(err, services.ErrFetchTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrInvalidFile) || errors.Is(err, services.ErrFetchRejected) || err == services.ErrTooManyTags:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrQuotaExceeded):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrImportFailed):
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
+
+// splitTags разворачивает теги из формы: tags=a&tags=b или tags=a,b
+func splitTags(values []string) []string {
+	var tags []string
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"memology-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type StyleHandler struct {
+	styleCatalog *services.StyleCatalog
+	validator    *validator.Validate
+}
+
+func NewStyleHandler(styleCatalog *services.StyleCatalog) *StyleHandler {
+	return &StyleHandler{
+		styleCatalog: styleCatalog,
+		validator:    validator.New(),
+	}
+}
+
+// @Summary List style catalog (admin)
+// @Description Get all styles including hidden ones, with administrator overrides
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.StyleCatalogEntry
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "AI service is unavailable"
+// @Router /admin/styles [get]
+func (h *StyleHandler) ListStyles(c *gin.Context) {
+	entries, err := h.styleCatalog.Entries(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrAIUnavailable) {
+			respondAIUnavailable(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// @Summary Override style (admin)
+// @Description Hide a style or change its display name, description and preview. Empty fields keep values from the AI service
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Style name"
+// @Param request body services.StyleOverrideRequest true "Style override"
+// @Success 200 {object} models.StyleOverride
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/styles/{name} [put]
+func (h *StyleHandler) SetStyleOverride(c *gin.Context) {
+	var req services.StyleOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	override, err := h.styleCatalog.SetOverride(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		if err == services.ErrInvalidStyleOverride {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// @Summary Remove style override (admin)
+// @Description Restore the style as reported by the AI service
+// @Tags admin
+// @Security BearerAuth
+// @Param name path string true "Style name"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/styles/{name} [delete]
+func (h *StyleHandler) DeleteStyleOverride(c *gin.Context) {
+	if err := h.styleCatalog.DeleteOverride(c.Request.Context(), c.Param("name")); err != nil {
+		if err == services.ErrStyleOverrideNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
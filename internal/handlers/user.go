@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"memology-backend/internal/config"
+	"memology-backend/internal/middleware"
 	"memology-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -13,12 +15,14 @@ import (
 
 type AuthHandler struct {
 	authService services.AuthService
+	cookies     authCookies
 	validator   *validator.Validate
 }
 
-func NewAuthHandler(authService services.AuthService) *AuthHandler {
+func NewAuthHandler(authService services.AuthService, cookieCfg *config.CookieConfig) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
+		cookies:     authCookies{cfg: cookieCfg},
 		validator:   validator.New(),
 	}
 }
@@ -55,7 +59,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	setAuthCookies(c, response.AccessToken, response.RefreshToken, response.ExpiresIn)
+	h.cookies.set(c, response.AccessToken, response.RefreshToken, response.ExpiresIn)
 
 	c.JSON(http.StatusCreated, response)
 }
@@ -92,21 +96,23 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	setAuthCookies(c, response.AccessToken, response.RefreshToken, response.ExpiresIn)
+	h.cookies.set(c, response.AccessToken, response.RefreshToken, response.ExpiresIn)
 
 	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Refresh access token
-// @Description Get new access token using refresh token from cookies
+// @Description Get new access token using refresh token from cookies. Requires X-CSRF-Token header matching the csrf_token cookie
 // @Tags auth
 // @Produce json
+// @Param X-CSRF-Token header string false "CSRF token from the csrf_token cookie"
 // @Success 200 {object} services.AuthResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	refreshToken, err := c.Cookie("refresh_token")
+	refreshToken, err := c.Cookie(middleware.RefreshTokenCookie)
 	if err != nil || refreshToken == "" {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "refresh token required in cookies"})
 		return
@@ -118,7 +124,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	setAuthCookies(c, response.AccessToken, response.RefreshToken, response.ExpiresIn)
+	h.cookies.set(c, response.AccessToken, response.RefreshToken, response.ExpiresIn)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -138,7 +144,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	refreshToken, err := c.Cookie("refresh_token")
+	refreshToken, err := c.Cookie(middleware.RefreshTokenCookie)
 	if err != nil || refreshToken == "" {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "refresh token required in cookies"})
 		return
@@ -150,7 +156,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	clearAuthCookies(c)
+	h.cookies.clear(c)
 
 	c.JSON(http.StatusOK, MessageResponse{Message: "logged out successfully"})
 }
@@ -176,19 +182,21 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 		return
 	}
 
-	clearAuthCookies(c)
+	h.cookies.clear(c)
 
 	c.JSON(http.StatusOK, MessageResponse{Message: "logged out from all devices"})
 }
 
 type UserHandler struct {
 	userService services.UserService
+	cookies     authCookies
 	validator   *validator.Validate
 }
 
-func NewUserHandler(userService services.UserService) *UserHandler {
+func NewUserHandler(userService services.UserService, cookieCfg *config.CookieConfig) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		cookies:     authCookies{cfg: cookieCfg},
 		validator:   validator.New(),
 	}
 }
@@ -383,7 +391,7 @@ func (h *UserHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	clearAuthCookies(c)
+	h.cookies.clear(c)
 
 	c.JSON(http.StatusOK, MessageResponse{Message: "account deleted successfully"})
 }
@@ -399,30 +407,3 @@ type ErrorResponse struct {
 type MessageResponse struct {
 	Message string `json:"message"`
 }
-
-func setAuthCookies(c *gin.Context, accessToken, refreshToken string, expiresIn int64) {
-	c.SetCookie(
-		"access_token",
-		accessToken,
-		int(expiresIn),
-		"/",
-		"",
-		false,
-		true,
-	)
-
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		604800,
-		"/",
-		"",
-		false,
-		true,
-	)
-}
-
-func clearAuthCookies(c *gin.Context) {
-	c.SetCookie("access_token", "", -1, "/", "", false, true)
-	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
-}
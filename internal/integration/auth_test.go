@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"memology-backend/internal/middleware"
+	"memology-backend/internal/models"
+	"memology-backend/internal/services"
+)
+
+func TestRegisterLoginRefresh(t *testing.T) {
+	user := newUser(t)
+
+	t.Run("duplicate username", func(t *testing.T) {
+		resp := anonymous(t).do(http.MethodPost, "/api/v1/auth/register", services.RegisterRequest{
+			Username: user.user.Username,
+			Email:    "other-" + user.user.Email,
+			Password: "password123",
+		})
+		resp.expect(t, http.StatusConflict)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		resp := anonymous(t).do(http.MethodPost, "/api/v1/auth/login", services.LoginRequest{
+			Username: user.user.Username,
+			Password: "wrong-password",
+		})
+		resp.expect(t, http.StatusUnauthorized)
+	})
+
+	t.Run("login by username and email", func(t *testing.T) {
+		for _, login := range []string{user.user.Username, user.user.Email} {
+			resp := anonymous(t).do(http.MethodPost, "/api/v1/auth/login", services.LoginRequest{
+				Username: login,
+				Password: "password123",
+			})
+			resp.expect(t, http.StatusOK)
+			if auth := decode[services.AuthResponse](t, resp); auth.AccessToken == "" {
+				t.Fatalf("login with %q returned no access token", login)
+			}
+		}
+	})
+
+	t.Run("profile requires token", func(t *testing.T) {
+		anonymous(t).do(http.MethodGet, "/api/v1/users/profile", nil).expect(t, http.StatusUnauthorized)
+
+		resp := user.do(http.MethodGet, "/api/v1/users/profile", nil)
+		resp.expect(t, http.StatusOK)
+		if profile := decode[models.User](t, resp); profile.ID != user.user.ID {
+			t.Fatalf("expected profile %s, got %s", user.user.ID, profile.ID)
+		}
+	})
+
+	t.Run("refresh rotates tokens", func(t *testing.T) {
+		oldRefresh := user.refreshToken
+
+		resp := user.do(http.MethodPost, "/api/v1/auth/refresh", nil)
+		resp.expect(t, http.StatusOK)
+		user.setAuth(resp)
+
+		user.do(http.MethodGet, "/api/v1/users/profile", nil).expect(t, http.StatusOK)
+
+		stale := anonymous(t)
+		stale.refreshToken = oldRefresh
+		stale.csrfToken = user.csrfToken
+		stale.do(http.MethodPost, "/api/v1/auth/refresh", nil).expect(t, http.StatusUnauthorized)
+	})
+
+	t.Run("logout all revokes refresh token", func(t *testing.T) {
+		user.do(http.MethodPost, "/api/v1/auth/logout-all", nil).expect(t, http.StatusOK)
+		user.do(http.MethodPost, "/api/v1/auth/refresh", nil).expect(t, http.StatusUnauthorized)
+	})
+}
+
+// TestCookieAuthCSRF - браузерный сценарий: авторизация только cookie, изменяющие
+// запросы без X-CSRF-Token отклоняются
+func TestCookieAuthCSRF(t *testing.T) {
+	user := newUser(t)
+
+	cookieRequest := func(method, path, csrf string) *response {
+		req, err := http.NewRequest(method, user.baseURL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{Name: middleware.AccessTokenCookie, Value: user.accessToken})
+		req.AddCookie(&http.Cookie{Name: middleware.RefreshTokenCookie, Value: user.refreshToken})
+		req.AddCookie(&http.Cookie{Name: middleware.CSRFCookie, Value: user.csrfToken})
+		if csrf != "" {
+			req.Header.Set(middleware.CSRFHeader, csrf)
+		}
+		return user.send(req)
+	}
+
+	if user.csrfToken == "" {
+		t.Fatal("register must return a CSRF token")
+	}
+
+	cookieRequest(http.MethodGet, "/api/v1/users/profile", "").expect(t, http.StatusOK)
+	cookieRequest(http.MethodPost, "/api/v1/auth/logout-all", "").expect(t, http.StatusForbidden)
+	cookieRequest(http.MethodPost, "/api/v1/auth/logout-all", "forged").expect(t, http.StatusForbidden)
+	cookieRequest(http.MethodPost, "/api/v1/auth/refresh", "").expect(t, http.StatusForbidden)
+
+	resp := cookieRequest(http.MethodPost, "/api/v1/auth/refresh", user.csrfToken)
+	resp.expect(t, http.StatusOK)
+	user.setAuth(resp)
+	cookieRequest(http.MethodPost, "/api/v1/auth/logout-all", user.csrfToken).expect(t, http.StatusOK)
+}
+
+func TestCORSAllowlist(t *testing.T) {
+	preflight := func(origin string) *response {
+		req, err := http.NewRequest(http.MethodOptions, requireEnv(t).server.URL+"/api/v1/auth/login", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		return anonymous(t).send(req)
+	}
+
+	allowed := preflight(allowedOrigin)
+	allowed.expect(t, http.StatusNoContent)
+	if got := allowed.header.Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+		t.Fatalf("expected allowed origin %q, got %q", allowedOrigin, got)
+	}
+
+	denied := preflight("https://evil.example.com")
+	denied.expect(t, http.StatusForbidden)
+	if got := denied.header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("foreign origin must not be allowed, got %q", got)
+	}
+}
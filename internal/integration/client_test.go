@@ -0,0 +1,187 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"memology-backend/internal/middleware"
+	"memology-backend/internal/models"
+	"memology-backend/internal/services"
+)
+
+// client - HTTP-клиент теста; с токеном ходит от имени пользователя
+type client struct {
+	t            *testing.T
+	baseURL      string
+	accessToken  string
+	refreshToken string
+	csrfToken    string
+	user         *models.User
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func anonymous(t *testing.T) *client {
+	return &client{t: t, baseURL: requireEnv(t).server.URL}
+}
+
+// newUser регистрирует пользователя со случайным именем и возвращает его клиента
+func newUser(t *testing.T) *client {
+	t.Helper()
+	c := anonymous(t)
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := "user_" + hex.EncodeToString(suffix)
+
+	resp := c.do(http.MethodPost, "/api/v1/auth/register", services.RegisterRequest{
+		Username: name,
+		Email:    name + "@example.com",
+		Password: "password123",
+	})
+	resp.expect(t, http.StatusCreated)
+
+	c.setAuth(resp)
+	return c
+}
+
+// setAuth запоминает токены из ответа register, login или refresh
+func (c *client) setAuth(resp *response) {
+	auth := decode[services.AuthResponse](c.t, resp)
+	c.csrfToken = resp.header.Get(middleware.CSRFHeader)
+	c.accessToken = auth.AccessToken
+	c.refreshToken = auth.RefreshToken
+	c.user = auth.User
+}
+
+func (c *client) do(method, path string, body any) *response {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("failed to marshal request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		c.t.Fatalf("failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+	if c.refreshToken != "" {
+		req.AddCookie(&http.Cookie{Name: middleware.RefreshTokenCookie, Value: c.refreshToken})
+	}
+	if c.csrfToken != "" {
+		req.AddCookie(&http.Cookie{Name: middleware.CSRFCookie, Value: c.csrfToken})
+		req.Header.Set(middleware.CSRFHeader, c.csrfToken)
+	}
+
+	return c.send(req)
+}
+
+// upload отправляет multipart-форму с файлом в поле image
+func (c *client) upload(path string, fields map[string]string, image []byte) *response {
+	c.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			c.t.Fatalf("failed to write form field: %v", err)
+		}
+	}
+	part, err := form.CreateFormFile("image", "meme.png")
+	if err != nil {
+		c.t.Fatalf("failed to create form file: %v", err)
+	}
+	if _, err := part.Write(image); err != nil {
+		c.t.Fatalf("failed to write form file: %v", err)
+	}
+	if err := form.Close(); err != nil {
+		c.t.Fatalf("failed to close form: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, &body)
+	if err != nil {
+		c.t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+
+	return c.send(req)
+}
+
+func (c *client) send(req *http.Request) *response {
+	c.t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s failed: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("failed to read response: %v", err)
+	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: data}
+}
+
+func (r *response) expect(t *testing.T, status int) {
+	t.Helper()
+	if r.status != status {
+		t.Fatalf("expected status %d, got %d: %s", status, r.status, r.body)
+	}
+}
+
+func decode[T any](t *testing.T, r *response) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(r.body, &v); err != nil {
+		t.Fatalf("failed to decode %s: %v", r.body, err)
+	}
+	return v
+}
+
+// waitForStatus опрашивает мем, пока его статус не станет одним из ожидаемых
+func (c *client) waitForStatus(memeID string, statuses ...string) *models.Meme {
+	c.t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp := c.do(http.MethodGet, "/api/v1/memes/"+memeID+"/status", nil)
+		resp.expect(c.t, http.StatusOK)
+		meme := decode[models.Meme](c.t, resp)
+
+		for _, status := range statuses {
+			if meme.Status == status {
+				return &meme
+			}
+		}
+		if time.Now().After(deadline) {
+			c.t.Fatalf("meme %s stuck in status %q, want one of %v", memeID, meme.Status, statuses)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,203 @@
+// Package integration - сквозные тесты: настоящий роутер, PostgreSQL из testdb, локальное
+// хранилище и заглушка AI-сервиса. Если базу поднять не удалось, тесты пропускаются
+// (с TEST_DATABASE_REQUIRED=1 пакет падает). go test -short их тоже пропускает
+package integration
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/mockai"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/router"
+	"memology-backend/internal/services"
+	"memology-backend/internal/testdb"
+	"memology-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testEnv - запущенное приложение, общее для всех тестов пакета
+type testEnv struct {
+	server     *httptest.Server
+	ai         *mockai.Server
+	storageDir string
+	storageURL string
+}
+
+// allowedOrigin - единственный origin из CORS_ALLOWED_ORIGINS тестового сервера
+const allowedOrigin = "http://app.test"
+
+var (
+	env        *testEnv
+	skipReason string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	gin.SetMode(gin.TestMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	flag.Parse()
+	if testing.Short() {
+		skipReason = "integration tests are skipped in -short mode"
+		return m.Run()
+	}
+
+	db, stopDB, err := testdb.Open()
+	if err != nil {
+		if testdb.Required() {
+			fmt.Fprintln(os.Stderr, "failed to open test database:", err)
+			return 1
+		}
+		skipReason = "no test database: " + err.Error()
+		return m.Run()
+	}
+	defer stopDB()
+
+	storageDir, err := os.MkdirTemp("", "memology-storage-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create storage dir:", err)
+		return 1
+	}
+	defer os.RemoveAll(storageDir)
+
+	stop, err := startApp(db, storageDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to start application:", err)
+		return 1
+	}
+	defer stop()
+
+	return m.Run()
+}
+
+// requireEnv пропускает тест, если окружение не поднялось
+func requireEnv(t *testing.T) *testEnv {
+	t.Helper()
+	if env == nil {
+		t.Skip(skipReason)
+	}
+	return env
+}
+
+// startApp собирает приложение так же, как cmd/server, но с заглушками вместо внешних сервисов
+func startApp(db *gorm.DB, storageDir string) (func(), error) {
+	ai := mockai.New(mockai.Config{Seed: 1})
+	aiServer := httptest.NewServer(ai)
+
+	// URL хранилища нужен до сборки роутера, поэтому порт занимается заранее
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		aiServer.Close()
+		return nil, err
+	}
+	storageURL := "http://" + listener.Addr().String() + services.LocalStoragePath
+
+	cfg := &config.Config{
+		CORS:   config.CORSConfig{AllowedOrigins: []string{allowedOrigin}},
+		Cookie: config.CookieConfig{SameSite: "lax"},
+		JWT: config.JWTConfig{
+			SecretKey:       "integration-test-secret",
+			AccessTokenTTL:  time.Hour,
+			RefreshTokenTTL: 24 * time.Hour,
+		},
+		Storage: config.StorageConfig{
+			Backend:        "local",
+			LocalDir:       storageDir,
+			LocalPublicURL: storageURL,
+		},
+		AI: config.AIConfig{
+			BaseURL:         aiServer.URL,
+			Timeout:         10 * time.Second,
+			RetryAttempts:   2,
+			RetryBackoff:    10 * time.Millisecond,
+			BreakerFailures: 100,
+			BreakerCooldown: time.Second,
+		},
+		// Заглушка AI отдаёт картинки шаблонов с 127.0.0.1
+		Fetch: config.FetchConfig{
+			AllowedHosts:         []string{"127.0.0.1"},
+			AllowPrivateNetworks: true,
+			Timeout:              5 * time.Second,
+			MaxBytes:             10 << 20,
+			MaxRedirects:         3,
+		},
+		Upload: config.UploadConfig{MaxBytes: 1 << 20, MaxDimension: 2048},
+		TaskProcessor: config.TaskProcessorConfig{
+			Workers:      2,
+			QueueSize:    50,
+			PollInterval: 20 * time.Millisecond,
+		},
+		Trash:  config.TrashConfig{Retention: 24 * time.Hour, PurgeInterval: time.Hour},
+		Tags:   config.TagsConfig{MaxPerMeme: 10, AutoLimit: 3},
+		Health: config.HealthConfig{CheckTimeout: time.Second, AICacheTTL: time.Second},
+		Styles: config.StylesConfig{CacheTTL: time.Minute, RefreshInterval: time.Minute},
+	}
+
+	storage, err := services.NewStorageService(cfg)
+	if err != nil {
+		aiServer.Close()
+		listener.Close()
+		return nil, err
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	memeRepo := repository.NewMemeRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	styleOverrideRepo := repository.NewStyleOverrideRepository(db)
+
+	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
+	aiService := services.NewAIService(&cfg.AI)
+	authService := services.NewAuthService(userRepo, sessionRepo, jwtManager)
+	userService := services.NewUserService(userRepo)
+
+	taskProcessor := services.NewTaskProcessor(cfg, memeRepo, aiService, storage)
+	taskProcessor.Start()
+
+	styleCatalog := services.NewStyleCatalog(&cfg.Styles, aiService, styleOverrideRepo)
+	memeService := services.NewMemeServiceWithProcessor(cfg, memeRepo, tagRepo, storage, aiService, styleCatalog, taskProcessor)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		taskProcessor.Stop()
+		aiServer.Close()
+		listener.Close()
+		return nil, err
+	}
+	healthChecker := services.NewHealthChecker(&cfg.Health, sqlDB, storage, aiService, taskProcessor)
+
+	r := router.SetupRouter(authService, userService, memeService, styleCatalog, healthChecker, &cfg.CORS, &cfg.Cookie)
+	r.Static(services.LocalStoragePath, storageDir)
+
+	appServer := httptest.NewUnstartedServer(r)
+	appServer.Listener.Close()
+	appServer.Listener = listener
+	appServer.Start()
+
+	env = &testEnv{
+		server:     appServer,
+		ai:         ai,
+		storageDir: storageDir,
+		storageURL: storageURL,
+	}
+
+	return func() {
+		appServer.Close()
+		taskProcessor.Stop()
+		aiServer.Close()
+	}, nil
+}
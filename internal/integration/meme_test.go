@@ -0,0 +1,277 @@
+package integration
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"memology-backend/internal/handlers"
+	"memology-backend/internal/models"
+	"memology-backend/internal/services"
+)
+
+func TestGenerateMemeCompletes(t *testing.T) {
+	env := requireEnv(t)
+	user := newUser(t)
+
+	resp := user.do(http.MethodPost, "/api/v1/memes/generate", services.CreateMemeRequest{
+		Prompt: "кот программист",
+		Style:  "anime",
+		Tags:   []string{"коты"},
+	})
+	resp.expect(t, http.StatusCreated)
+	created := decode[models.Meme](t, resp)
+
+	if created.Status != "pending" || created.TaskID == "" {
+		t.Fatalf("expected pending meme with task_id, got status %q task %q", created.Status, created.TaskID)
+	}
+	if created.Kind != models.MemeKindAI {
+		t.Fatalf("expected kind %q, got %q", models.MemeKindAI, created.Kind)
+	}
+
+	meme := user.waitForStatus(created.ID.String(), "completed", "failed")
+	if meme.Status != "completed" {
+		t.Fatalf("expected completed meme, got %q", meme.Status)
+	}
+	if !strings.HasPrefix(meme.ImageURL, env.storageURL+"/") {
+		t.Fatalf("image_url %q is not in local storage %q", meme.ImageURL, env.storageURL)
+	}
+	if meme.Generation == nil || meme.Generation.Model == "" || meme.Generation.CompletedAt == nil {
+		t.Fatalf("expected generation provenance with model and completion time, got %+v", meme.Generation)
+	}
+
+	objectName := strings.TrimPrefix(meme.ImageURL, env.storageURL+"/")
+	if _, err := os.Stat(filepath.Join(env.storageDir, filepath.FromSlash(objectName))); err != nil {
+		t.Fatalf("image was not stored: %v", err)
+	}
+
+	image := user.send(mustRequest(t, http.MethodGet, meme.ImageURL))
+	image.expect(t, http.StatusOK)
+	if contentType := http.DetectContentType(image.body); contentType != "image/jpeg" {
+		t.Fatalf("expected stored JPEG, got %s", contentType)
+	}
+}
+
+func TestGenerateMemeFailed(t *testing.T) {
+	env := requireEnv(t)
+	user := newUser(t)
+
+	env.ai.SetNextStatuses("pending", "processing", "failed")
+
+	resp := user.do(http.MethodPost, "/api/v1/memes/generate", services.CreateMemeRequest{Prompt: "неудачный мем"})
+	resp.expect(t, http.StatusCreated)
+	created := decode[models.Meme](t, resp)
+
+	meme := user.waitForStatus(created.ID.String(), "completed", "failed")
+	if meme.Status != "failed" {
+		t.Fatalf("expected failed meme, got %q", meme.Status)
+	}
+	if meme.ImageURL != "" {
+		t.Fatalf("failed meme has image_url %q", meme.ImageURL)
+	}
+}
+
+func TestGenerateMemeValidation(t *testing.T) {
+	user := newUser(t)
+
+	user.do(http.MethodPost, "/api/v1/memes/generate", services.CreateMemeRequest{Prompt: "мем", Style: "no-such-style"}).
+		expect(t, http.StatusBadRequest)
+	user.do(http.MethodPost, "/api/v1/memes/generate", map[string]string{"style": "anime"}).
+		expect(t, http.StatusBadRequest)
+	anonymous(t).do(http.MethodPost, "/api/v1/memes/generate", services.CreateMemeRequest{Prompt: "мем"}).
+		expect(t, http.StatusUnauthorized)
+}
+
+func TestTemplateMeme(t *testing.T) {
+	env := requireEnv(t)
+	user := newUser(t)
+
+	resp := user.do(http.MethodPost, "/api/v1/memes/generate-template", services.CreateTemplateMemeRequest{
+		Context: "Кот пьет кофе",
+		Width:   320,
+		Height:  240,
+	})
+	resp.expect(t, http.StatusCreated)
+	meme := decode[models.Meme](t, resp)
+
+	if meme.Status != "completed" || meme.Kind != models.MemeKindTemplate {
+		t.Fatalf("expected completed template meme, got status %q kind %q", meme.Status, meme.Kind)
+	}
+	if !strings.HasPrefix(meme.ImageURL, env.storageURL+"/") {
+		t.Fatalf("template image %q was not copied to storage", meme.ImageURL)
+	}
+	if meme.Generation == nil || meme.Generation.TemplateID == "" || len(meme.Generation.Captions) == 0 {
+		t.Fatalf("expected template provenance, got %+v", meme.Generation)
+	}
+}
+
+func TestUploadAndImportMeme(t *testing.T) {
+	env := requireEnv(t)
+	user := newUser(t)
+
+	data := pngImage(t, 64, 48)
+	resp := user.upload("/api/v1/memes/upload", map[string]string{
+		"caption":   "кот на клавиатуре",
+		"tags":      "коты, работа",
+		"is_public": "false",
+	}, data)
+	resp.expect(t, http.StatusCreated)
+	meme := decode[models.Meme](t, resp)
+
+	if meme.Kind != models.MemeKindUpload || meme.Status != "completed" || meme.IsPublic {
+		t.Fatalf("expected private completed upload, got kind %q status %q public %v", meme.Kind, meme.Status, meme.IsPublic)
+	}
+	if meme.Width != 64 || meme.Height != 48 || len(meme.Tags) != 2 {
+		t.Fatalf("unexpected size %dx%d or tags %+v", meme.Width, meme.Height, meme.Tags)
+	}
+	if !strings.HasPrefix(meme.ImageURL, env.storageURL+"/") {
+		t.Fatalf("uploaded image %q is not in storage", meme.ImageURL)
+	}
+
+	// Та же картинка повторно не сохраняется ни загрузкой, ни импортом
+	duplicate := user.upload("/api/v1/memes/upload", nil, data)
+	duplicate.expect(t, http.StatusOK)
+	if got := decode[models.Meme](t, duplicate); got.ID != meme.ID {
+		t.Fatalf("duplicate upload created meme %s, expected %s", got.ID, meme.ID)
+	}
+	imported := user.do(http.MethodPost, "/api/v1/memes/import", services.ImportMemeRequest{URL: meme.ImageURL})
+	imported.expect(t, http.StatusOK)
+	if got := decode[models.Meme](t, imported); got.ID != meme.ID {
+		t.Fatalf("import of the same image created meme %s, expected %s", got.ID, meme.ID)
+	}
+
+	// У другого пользователя импорт создаёт свой мем с источником
+	other := newUser(t)
+	resp = other.do(http.MethodPost, "/api/v1/memes/import", services.ImportMemeRequest{URL: meme.ImageURL})
+	resp.expect(t, http.StatusCreated)
+	copied := decode[models.Meme](t, resp)
+	if copied.ID == meme.ID || copied.Generation == nil || copied.Generation.SourceURL != meme.ImageURL {
+		t.Fatalf("expected new meme with source_url, got %+v", copied.Generation)
+	}
+
+	user.upload("/api/v1/memes/upload", nil, []byte("<html></html>")).expect(t, http.StatusBadRequest)
+	user.upload("/api/v1/memes/upload", nil, pngImage(t, 4096, 1)).expect(t, http.StatusBadRequest)
+	user.do(http.MethodPost, "/api/v1/memes/import", services.ImportMemeRequest{URL: "ftp://127.0.0.1/meme.png"}).
+		expect(t, http.StatusBadRequest)
+}
+
+func TestVisibility(t *testing.T) {
+	owner := newUser(t)
+	other := newUser(t)
+
+	private := createTemplateMeme(t, owner, false)
+	public := createTemplateMeme(t, owner, true)
+
+	t.Run("private meme is hidden from others", func(t *testing.T) {
+		anonymous(t).do(http.MethodGet, "/api/v1/memes/"+private.ID.String(), nil).expect(t, http.StatusForbidden)
+		anonymous(t).do(http.MethodGet, "/api/v1/memes/"+public.ID.String(), nil).expect(t, http.StatusOK)
+	})
+
+	t.Run("public feed lists only public memes", func(t *testing.T) {
+		resp := anonymous(t).do(http.MethodGet, "/api/v1/memes/public?limit=100", nil)
+		resp.expect(t, http.StatusOK)
+		ids := memeIDs(decode[handlers.MemeHistoryResponse](t, resp).Memes)
+
+		if !ids[public.ID.String()] {
+			t.Fatalf("public meme %s is missing from the public feed", public.ID)
+		}
+		if ids[private.ID.String()] {
+			t.Fatalf("private meme %s is listed in the public feed", private.ID)
+		}
+	})
+
+	t.Run("owner sees own private memes", func(t *testing.T) {
+		resp := owner.do(http.MethodGet, "/api/v1/memes/my?limit=100", nil)
+		resp.expect(t, http.StatusOK)
+		ids := memeIDs(decode[handlers.MemeHistoryResponse](t, resp).Memes)
+
+		if !ids[private.ID.String()] || !ids[public.ID.String()] {
+			t.Fatalf("owner's list is missing memes: %v", ids)
+		}
+
+		resp = other.do(http.MethodGet, "/api/v1/memes/my?limit=100", nil)
+		resp.expect(t, http.StatusOK)
+		if ids := memeIDs(decode[handlers.MemeHistoryResponse](t, resp).Memes); len(ids) != 0 {
+			t.Fatalf("other user sees foreign memes: %v", ids)
+		}
+	})
+
+	t.Run("only owner can edit", func(t *testing.T) {
+		title := "чужой заголовок"
+		resp := other.do(http.MethodPatch, "/api/v1/memes/"+public.ID.String(), services.UpdateMemeRequest{Title: &title})
+		if resp.status != http.StatusForbidden && resp.status != http.StatusNotFound {
+			t.Fatalf("expected 403 or 404 for foreign meme, got %d: %s", resp.status, resp.body)
+		}
+
+		isPublic := true
+		owner.do(http.MethodPatch, "/api/v1/memes/"+private.ID.String(), services.UpdateMemeRequest{IsPublic: &isPublic}).
+			expect(t, http.StatusOK)
+		anonymous(t).do(http.MethodGet, "/api/v1/memes/"+private.ID.String(), nil).expect(t, http.StatusOK)
+	})
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	owner := newUser(t)
+	other := newUser(t)
+	meme := createTemplateMeme(t, owner, true)
+	path := "/api/v1/memes/" + meme.ID.String()
+
+	resp := other.do(http.MethodDelete, path, nil)
+	if resp.status != http.StatusForbidden && resp.status != http.StatusNotFound {
+		t.Fatalf("expected 403 or 404 when deleting foreign meme, got %d: %s", resp.status, resp.body)
+	}
+
+	owner.do(http.MethodDelete, path, nil).expect(t, http.StatusOK)
+	anonymous(t).do(http.MethodGet, path, nil).expect(t, http.StatusNotFound)
+
+	resp = owner.do(http.MethodGet, "/api/v1/memes/trash", nil)
+	resp.expect(t, http.StatusOK)
+	trash := decode[handlers.TrashResponse](t, resp)
+	if len(trash.Memes) != 1 || trash.Memes[0].ID != meme.ID {
+		t.Fatalf("expected deleted meme in trash, got %d memes", len(trash.Memes))
+	}
+
+	owner.do(http.MethodPost, path+"/restore", nil).expect(t, http.StatusOK)
+	anonymous(t).do(http.MethodGet, path, nil).expect(t, http.StatusOK)
+}
+
+func createTemplateMeme(t *testing.T, user *client, isPublic bool) models.Meme {
+	t.Helper()
+	resp := user.do(http.MethodPost, "/api/v1/memes/generate-template", services.CreateTemplateMemeRequest{
+		Context:  "Понедельник",
+		IsPublic: &isPublic,
+	})
+	resp.expect(t, http.StatusCreated)
+	return decode[models.Meme](t, resp)
+}
+
+func memeIDs(memes []*models.Meme) map[string]bool {
+	ids := make(map[string]bool, len(memes))
+	for _, meme := range memes {
+		ids[meme.ID.String()] = true
+	}
+	return ids
+}
+
+func mustRequest(t *testing.T, method, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	return req
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
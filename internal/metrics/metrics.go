@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "memology"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	aiDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "AI service call latency by backend and endpoint.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"backend", "endpoint"})
+
+	aiErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_request_errors_total",
+		Help:      "Failed AI service calls (transport errors and non-2xx responses) by backend and endpoint.",
+	}, []string{"backend", "endpoint"})
+
+	aiCircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ai_circuit_state",
+		Help:      "AI backend circuit breaker state: 0 - closed, 1 - half-open, 2 - open.",
+	}, []string{"backend"})
+
+	storageUploadBytes = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_upload_size_bytes",
+		Help:      "Size of objects uploaded to storage.",
+		Buckets:   prometheus.ExponentialBuckets(16*1024, 2, 10),
+	})
+
+	storageUploadFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_upload_failures_total",
+		Help:      "Failed uploads to storage.",
+	})
+)
+
+// Handler отдаёт метрики в формате Prometheus на /metrics. Он обслуживается отдельным
+// listener (METRICS_ADDR), а не роутером API
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+// GinMiddleware считает запросы и их длительность. Маршрут берётся из шаблона Gin
+// (/memes/:id), чтобы id не раздували число временных рядов
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveAIRequest записывает вызов AI-сервиса; failed - ошибка транспорта или не-2xx ответ
+func ObserveAIRequest(backend, endpoint string, duration time.Duration, failed bool) {
+	aiDuration.WithLabelValues(backend, endpoint).Observe(duration.Seconds())
+	if failed {
+		aiErrors.WithLabelValues(backend, endpoint).Inc()
+	}
+}
+
+// SetAICircuitState публикует состояние circuit breaker бэкенда AI-сервиса
+func SetAICircuitState(backend string, state int) {
+	aiCircuitState.WithLabelValues(backend).Set(float64(state))
+}
+
+// ObserveStorageUpload записывает загрузку объекта в хранилище
+func ObserveStorageUpload(size int64, err error) {
+	if err != nil {
+		storageUploadFailures.Inc()
+		return
+	}
+	storageUploadBytes.Observe(float64(size))
+}
+
+// TaskQueueStats - состояние очереди генерации, реализуется TaskProcessor
+type TaskQueueStats interface {
+	QueueDepth() int
+	InFlight() int
+	BusyWorkers() int
+	Workers() int
+}
+
+// RegisterTaskProcessor публикует состояние очереди; значения читаются в момент сбора метрик
+func RegisterTaskProcessor(stats TaskQueueStats) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_queue_depth",
+		Help:      "Tasks waiting in the TaskProcessor queue.",
+	}, func() float64 { return float64(stats.QueueDepth()) })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_in_flight",
+		Help:      "Memes queued or being processed by TaskProcessor.",
+	}, func() float64 { return float64(stats.InFlight()) })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_workers_busy",
+		Help:      "TaskProcessor workers currently processing a task.",
+	}, func() float64 { return float64(stats.BusyWorkers()) })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_worker_utilization",
+		Help:      "Share of busy TaskProcessor workers (0..1).",
+	}, func() float64 {
+		if stats.Workers() == 0 {
+			return 0
+		}
+		return float64(stats.BusyWorkers()) / float64(stats.Workers())
+	})
+}
+
+// RegisterDB публикует статистику пула соединений с базой
+func RegisterDB(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
@@ -7,26 +7,28 @@ import (
 	"memology-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func JWTAuth(authService services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var token string
-
-		token, err := c.Cookie("access_token")
-		if err != nil || token == "" {
-			authHeader := c.GetHeader("Authorization")
-			if authHeader == "" {
+		// Заголовок Authorization браузер сам не подставляет, поэтому такие запросы
+		// не требуют CSRF-токена; при авторизации по cookie он обязателен
+		token := c.GetHeader("Authorization")
+		if token != "" {
+			token = strings.TrimPrefix(token, "Bearer ")
+		} else {
+			cookie, err := c.Cookie(AccessTokenCookie)
+			if err != nil || cookie == "" {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
 				c.Abort()
 				return
 			}
-
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				token = strings.TrimPrefix(authHeader, "Bearer ")
-			} else {
-				token = authHeader
+			if !validCSRF(c) {
+				abortCSRF(c)
+				return
 			}
+			token = cookie
 		}
 
 		claims, err := authService.ValidateToken(c.Request.Context(), token)
@@ -41,3 +43,25 @@ func JWTAuth(authService services.AuthService) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAdmin пропускает только администраторов. Флаг читается из базы на каждый
+// запрос, а не из токена, чтобы отзыв прав действовал сразу. Ставится после JWTAuth
+func RequireAdmin(userService services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
+			c.Abort()
+			return
+		}
+
+		user, err := userService.GetProfile(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"memology-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	corsAllowHeaders  = strings.Join([]string{"Content-Type", "Content-Length", "Accept-Encoding", CSRFHeader, RequestIDHeader, "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With"}, ", ")
+	corsExposeHeaders = strings.Join([]string{RequestIDHeader, "ETag", CSRFHeader}, ", ")
+	corsAllowMethods  = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}, ", ")
+)
+
+// CORS разрешает запросы с cookie только с origin из списка. Остальным origin
+// заголовки не отдаются, и браузер не покажет им ответ; их preflight получает 403
+func CORS(cfg *config.CORSConfig) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !allowed[origin] {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Expose-Headers", corsExposeHeaders)
+		if c.Request.Method == http.MethodOptions {
+			c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Header("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"memology-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	// Слэш в конце настройки не должен мешать совпадению с Origin браузера
+	r.Use(CORS(&config.CORSConfig{AllowedOrigins: []string{"https://app.example.com/", "http://localhost:3000"}}))
+	r.GET("/memes", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/memes", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		wantStatus  int
+		wantAllowed bool
+	}{
+		{name: "same origin request", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "allowed origin", method: http.MethodGet, origin: "https://app.example.com", wantStatus: http.StatusOK, wantAllowed: true},
+		{name: "second allowed origin", method: http.MethodPost, origin: "http://localhost:3000", wantStatus: http.StatusCreated, wantAllowed: true},
+		{name: "allowed preflight", method: http.MethodOptions, origin: "https://app.example.com", wantStatus: http.StatusNoContent, wantAllowed: true},
+		{name: "foreign origin gets no headers", method: http.MethodGet, origin: "https://evil.example.com", wantStatus: http.StatusOK},
+		{name: "origin differs by scheme", method: http.MethodGet, origin: "http://app.example.com", wantStatus: http.StatusOK},
+		{name: "foreign preflight", method: http.MethodOptions, origin: "https://evil.example.com", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/memes", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			allowOrigin := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.wantAllowed {
+				if allowOrigin != tt.origin || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+					t.Fatalf("expected CORS headers for %s, got %v", tt.origin, w.Header())
+				}
+			} else if allowOrigin != "" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+				t.Fatalf("unexpected CORS headers: %v", w.Header())
+			}
+
+			if tt.origin != "" && w.Header().Get("Vary") != "Origin" {
+				t.Fatalf("responses to cross-origin requests must vary by Origin, got %q", w.Header().Get("Vary"))
+			}
+			if tt.wantAllowed && tt.method == http.MethodOptions && w.Header().Get("Access-Control-Allow-Headers") == "" {
+				t.Fatal("preflight must list allowed headers")
+			}
+		})
+	}
+}
+
+func TestCORSEmptyAllowlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CORS(&config.CORSConfig{}))
+	r.GET("/memes", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/memes", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("empty allowlist must reject every origin, got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookie с токенами. csrf_token не HttpOnly: фронтенд читает его и повторяет
+// в заголовке X-CSRF-Token (double-submit) - чужой сайт прочитать cookie не может
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFCookie         = "csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+)
+
+// CSRF защищает маршруты, которые авторизуются только cookie (например, обновление
+// токена). Для JWTAuth та же проверка выполняется при авторизации по cookie
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" && !validCSRF(c) {
+			abortCSRF(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// validCSRF пропускает безопасные методы, остальные - только если заголовок
+// X-CSRF-Token совпадает с cookie csrf_token
+func validCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	cookie, err := c.Cookie(CSRFCookie)
+	header := c.GetHeader(CSRFHeader)
+	if err != nil || cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func abortCSRF(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": "invalid CSRF token"})
+	c.Abort()
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CSRF())
+	r.Any("/auth/refresh", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name          string
+		method        string
+		cookie        string
+		header        string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "GET passes without token", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "HEAD passes without token", method: http.MethodHead, wantStatus: http.StatusOK},
+		{name: "OPTIONS passes without token", method: http.MethodOptions, wantStatus: http.StatusOK},
+		{name: "matching token", method: http.MethodPost, cookie: "token-a", header: "token-a", wantStatus: http.StatusOK},
+		{name: "mismatched token", method: http.MethodPost, cookie: "token-a", header: "token-b", wantStatus: http.StatusForbidden},
+		{name: "missing header", method: http.MethodPost, cookie: "token-a", wantStatus: http.StatusForbidden},
+		{name: "missing cookie", method: http.MethodDelete, header: "token-a", wantStatus: http.StatusForbidden},
+		{name: "no token at all", method: http.MethodPatch, wantStatus: http.StatusForbidden},
+		{name: "bearer token is exempt", method: http.MethodPost, authorization: "Bearer access-token", wantStatus: http.StatusOK},
+		{name: "bearer token with mismatched cookie", method: http.MethodPut, cookie: "token-a", header: "token-b", authorization: "Bearer access-token", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/auth/refresh", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
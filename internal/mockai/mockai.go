@@ -0,0 +1,387 @@
+// Package mockai - заглушка AI-сервиса для локальной разработки и тестов.
+// Реализует те же endpoint, что и настоящий сервис, и отдаёт сгенерированные
+// картинки-заглушки. Запускается отдельно (cmd/mockai) или внутри теста:
+//
+//	srv := httptest.NewServer(mockai.New(mockai.Config{}))
+//	cfg.AI.BaseURL = srv.URL
+package mockai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// DefaultStatuses - статусы задачи по умолчанию: каждый опрос статуса сдвигает задачу на шаг
+var DefaultStatuses = []string{StatusPending, StatusProcessing, StatusCompleted}
+
+// DefaultStyles - стили по умолчанию
+var DefaultStyles = []Style{
+	{Name: "anime", Description: "Anime style illustration"},
+	{Name: "cartoon", Description: "Flat cartoon drawing"},
+	{Name: "photo", Description: "Photorealistic picture"},
+}
+
+var defaultTemplates = []string{"drake", "distracted", "buzz", "fry", "doge"}
+
+type Style struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PreviewURL  string `json:"preview_url,omitempty"`
+}
+
+// Config - поведение заглушки. Нулевое значение - рабочая конфигурация без задержек и ошибок
+type Config struct {
+	// Latency + случайная часть до Jitter добавляется к каждому ответу
+	Latency time.Duration
+	Jitter  time.Duration
+	// FailureRate - доля запросов (0..1), на которые отвечается 503
+	FailureRate float64
+	// TaskFailureRate - доля задач, которые заканчиваются статусом failed
+	TaskFailureRate float64
+	// Statuses - последовательность статусов задачи, по умолчанию DefaultStatuses
+	Statuses []string
+	// Styles - поддерживаемые стили; генерация с другим стилем отвечает 400
+	Styles []Style
+	Model  string
+	// Seed делает случайные ошибки воспроизводимыми; 0 - случайное зерно
+	Seed uint64
+}
+
+type task struct {
+	id       string
+	prompt   string
+	style    string
+	statuses []string
+	polls    int
+}
+
+// status - текущий статус задачи; опрос сдвигает задачу к следующему
+func (t *task) status() string {
+	idx := t.polls
+	if idx >= len(t.statuses) {
+		idx = len(t.statuses) - 1
+	}
+	return t.statuses[idx]
+}
+
+type templateImage struct {
+	seed   string
+	width  int
+	height int
+}
+
+// Server - http.Handler заглушки
+type Server struct {
+	cfg Config
+	mux *http.ServeMux
+
+	mu           sync.Mutex
+	rnd          *rand.Rand
+	tasks        map[string]*task
+	templates    map[string]templateImage
+	nextStatuses [][]string
+	requests     map[string]int
+}
+
+func New(cfg Config) *Server {
+	if len(cfg.Statuses) == 0 {
+		cfg.Statuses = DefaultStatuses
+	}
+	if len(cfg.Styles) == 0 {
+		cfg.Styles = DefaultStyles
+	}
+	if cfg.Model == "" {
+		cfg.Model = "mock-diffusion"
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	s := &Server{
+		cfg:       cfg,
+		mux:       http.NewServeMux(),
+		rnd:       rand.New(rand.NewPCG(seed, seed)),
+		tasks:     make(map[string]*task),
+		templates: make(map[string]templateImage),
+		requests:  make(map[string]int),
+	}
+
+	s.mux.HandleFunc("POST /api/memes/generate", s.handle("generate", s.generate))
+	s.mux.HandleFunc("GET /api/memes/task/{id}", s.handle("task_status", s.taskStatus))
+	s.mux.HandleFunc("GET /api/memes/task/{id}/result", s.handle("task_result", s.taskResult))
+	s.mux.HandleFunc("GET /api/memes/styles", s.handle("styles", s.styles))
+	s.mux.HandleFunc("POST /api/memes/generate-template", s.handle("generate_template", s.generateTemplate))
+	s.mux.HandleFunc("GET /images/{id}", s.templateImage)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetNextStatuses задаёт последовательность статусов для следующей созданной задачи;
+// повторные вызовы выстраивают очередь. Нужна тестам, которым важен исход конкретной задачи
+func (s *Server) SetNextStatuses(statuses ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextStatuses = append(s.nextStatuses, statuses)
+}
+
+// Requests - сколько запросов пришло на endpoint (generate, task_status, task_result, styles, generate_template)
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// TaskStatus - текущий статус задачи без сдвига, false - задачи нет
+func (s *Server) TaskStatus(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return "", false
+	}
+	return t.status(), true
+}
+
+// handle добавляет задержку, случайные отказы и счётчик запросов
+func (s *Server) handle(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		delay := s.cfg.Latency
+		if s.cfg.Jitter > 0 {
+			delay += time.Duration(s.rnd.Int64N(int64(s.cfg.Jitter)))
+		}
+		fail := s.cfg.FailureRate > 0 && s.rnd.Float64() < s.cfg.FailureRate
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		if fail {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "injected failure"})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+type generateRequest struct {
+	UserInput string `json:"user_input"`
+	Style     string `json:"style"`
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	var req generateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.UserInput) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "user_input is required"})
+		return
+	}
+	if req.Style != "" && !s.supportsStyle(req.Style) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown style: " + req.Style})
+		return
+	}
+
+	s.mu.Lock()
+	statuses := s.cfg.Statuses
+	if len(s.nextStatuses) > 0 {
+		statuses = s.nextStatuses[0]
+		s.nextStatuses = s.nextStatuses[1:]
+	} else if s.cfg.TaskFailureRate > 0 && s.rnd.Float64() < s.cfg.TaskFailureRate {
+		statuses = append(append([]string{}, statuses[:len(statuses)-1]...), StatusFailed)
+	}
+
+	t := &task{
+		id:       uuid.New().String(),
+		prompt:   req.UserInput,
+		style:    req.Style,
+		statuses: statuses,
+	}
+	s.tasks[t.id] = t
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"task_id": t.id})
+}
+
+func (s *Server) taskStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.tasks[r.PathValue("id")]
+	var status string
+	if ok {
+		status = t.status()
+		t.polls++
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+
+	resp := map[string]string{"task_id": t.id, "status": status}
+	if status == StatusCompleted {
+		resp["result_path"] = "/api/memes/task/" + t.id + "/result"
+		resp["model"] = s.cfg.Model
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) taskResult(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.tasks[r.PathValue("id")]
+	var status string
+	if ok {
+		status = t.status()
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if status != StatusCompleted {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task is " + status})
+		return
+	}
+
+	writeImage(w, t.prompt+"|"+t.style, 512, 512)
+}
+
+func (s *Server) styles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.cfg.Styles)
+}
+
+type templateRequest struct {
+	Context string `json:"context"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// generateTemplate отвечает как memegen-обёртка: URL картинки ведёт на саму заглушку
+func (s *Server) generateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req templateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Context) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "context is required"})
+		return
+	}
+	if req.Width <= 0 || req.Width > 2048 {
+		req.Width = 512
+	}
+	if req.Height <= 0 || req.Height > 2048 {
+		req.Height = 512
+	}
+
+	id := uuid.New().String()
+	s.mu.Lock()
+	template := defaultTemplates[s.rnd.IntN(len(defaultTemplates))]
+	s.templates[id] = templateImage{seed: req.Context, width: req.Width, height: req.Height}
+	s.mu.Unlock()
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"url":      fmt.Sprintf("%s://%s/images/%s.jpg", scheme, r.Host, id),
+		"template": template,
+		"text":     []string{req.Context, "mock caption"},
+	})
+}
+
+func (s *Server) templateImage(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(r.PathValue("id"), ".jpg")
+
+	s.mu.Lock()
+	img, ok := s.templates[id]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeImage(w, img.seed, img.width, img.height)
+}
+
+func (s *Server) supportsStyle(style string) bool {
+	for _, st := range s.cfg.Styles {
+		if st.Name == style {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeImage(w http.ResponseWriter, seed string, width, height int) {
+	data, err := Placeholder(seed, width, height)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// Placeholder рисует JPEG-заглушку: диагональные полосы двух цветов, выбранных по seed,
+// так что одинаковый промпт даёт одинаковую картинку
+func Placeholder(seed string, width, height int) ([]byte, error) {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	sum := h.Sum32()
+
+	base := color.RGBA{R: uint8(sum), G: uint8(sum >> 8), B: uint8(sum >> 16), A: 255}
+	stripe := color.RGBA{R: 255 - base.R, G: 255 - base.G, B: 255 - base.B, A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x+y)/32%2 == 0 {
+				img.SetRGBA(x, y, base)
+			} else {
+				img.SetRGBA(x, y, stripe)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
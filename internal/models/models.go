@@ -14,6 +14,7 @@ type User struct {
 	PasswordHash string         `json:"-" gorm:"not null"`
 	AvatarURL    string         `json:"avatar_url,omitempty"`
 	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	IsAdmin      bool           `json:"is_admin,omitempty" gorm:"not null;default:false"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
@@ -32,25 +33,87 @@ type UserSession struct {
 	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// Способ создания мема
+const (
+	MemeKindAI       = "ai"
+	MemeKindTemplate = "template"
+	MemeKindUpload   = "upload"
+)
+
 type Meme struct {
-	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID           uuid.UUID      `json:"user_id" gorm:"not null"`
-	Prompt           string         `json:"prompt" gorm:"not null"`
-	Style            string         `json:"style,omitempty"`
-	ImageURL         string         `json:"image_url"`
-	Width            int            `json:"width" gorm:"default:500"`
-	Height           int            `json:"height" gorm:"default:500"`
-	AspectRatio      string         `json:"aspect_ratio" gorm:"default:'1:1'"`
-	TaskID           string         `json:"task_id,omitempty"`
-	GenerationTimeMs int            `json:"generation_time_ms,omitempty"`
-	Status           string         `json:"status" gorm:"default:pending"`
-	IsPublic         bool           `json:"is_public" gorm:"default:true"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID       `json:"user_id" gorm:"not null"`
+	Prompt           string          `json:"prompt" gorm:"not null"`
+	Title            string          `json:"title,omitempty" gorm:"size:200"`
+	Description      string          `json:"description,omitempty"`
+	Style            string          `json:"style,omitempty"`
+	ImageURL         string          `json:"image_url"`
+	ThumbnailURL     string          `json:"thumbnail_url,omitempty" gorm:"not null;default:''"`
+	Width            int             `json:"width" gorm:"default:500"`
+	Height           int             `json:"height" gorm:"default:500"`
+	AspectRatio      string          `json:"aspect_ratio" gorm:"default:'1:1'"`
+	Kind             string          `json:"kind" gorm:"size:20;not null;default:ai;index"`
+	TaskID           string          `json:"task_id,omitempty"`
+	AIBackend        string          `json:"ai_backend,omitempty" gorm:"size:100"`
+	ContentHash      string          `json:"content_hash,omitempty" gorm:"size:64;not null;default:''"`
+	Generation       *GenerationInfo `json:"generation,omitempty" gorm:"type:jsonb;serializer:json"`
+	GenerationTimeMs int             `json:"generation_time_ms,omitempty"`
+	Status           string          `json:"status" gorm:"default:pending"`
+	IsPublic         bool            `json:"is_public" gorm:"not null"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt  `json:"-" gorm:"index"`
+
+	// StatusUpdatedAt - последнее изменение генерации; по нему ищутся зависшие задачи
+	StatusUpdatedAt time.Time `json:"status_updated_at" gorm:"not null;autoCreateTime"`
+
+	// SearchHighlight - фрагмент промпта с подсвеченными совпадениями, заполняется только при поиске
+	SearchHighlight string `json:"search_highlight,omitempty" gorm:"->;-:migration"`
+	// SearchRank - релевантность при поиске, используется для курсора пагинации
+	SearchRank float64 `json:"-" gorm:"->;-:migration"`
 
 	User    User         `json:"-" gorm:"foreignKey:UserID"`
 	Metrics *MemeMetrics `json:"metrics,omitempty" gorm:"foreignKey:MemeID"`
+	Tags    []Tag        `json:"tags,omitempty" gorm:"many2many:meme_tags;constraint:OnDelete:CASCADE"`
+}
+
+// GenerationInfo - как был получен мем: параметры запроса к AI-сервису или memegen и время генерации
+type GenerationInfo struct {
+	TemplateID string   `json:"template_id,omitempty" example:"drake"`
+	Captions   []string `json:"captions,omitempty"`
+	TaskID     string   `json:"task_id,omitempty"`
+	RequestID  string   `json:"request_id,omitempty"`
+	// SourceURL - откуда импортирована картинка мема
+	SourceURL string `json:"source_url,omitempty"`
+	// TraceParent - W3C traceparent запроса, создавшего мем; спаны TaskProcessor ссылаются на него
+	TraceParent string     `json:"trace_parent,omitempty"`
+	Model       string     `json:"model,omitempty"`
+	Style       string     `json:"style,omitempty" example:"anime"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// QueueWaitMs - сколько задача ждала свободного воркера TaskProcessor; nil - не измерялось,
+	// нулевое ожидание сохраняется и входит в среднее
+	QueueWaitMs *int64 `json:"queue_wait_ms,omitempty"`
+	// ProcessingMs - от отправки запроса генерации до получения готового результата
+	ProcessingMs int64 `json:"processing_ms,omitempty"`
+}
+
+type Tag struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StyleOverride - правка администратора поверх стиля из AI-сервиса: скрыть стиль
+// или показать его под другим именем, описанием и превью. Пустые поля не меняют исходные значения
+type StyleOverride struct {
+	Name        string    `json:"name" gorm:"primary_key;size:100"`
+	Hidden      bool      `json:"hidden" gorm:"not null;default:false"`
+	DisplayName string    `json:"display_name,omitempty" gorm:"size:100"`
+	Description string    `json:"description,omitempty"`
+	PreviewURL  string    `json:"preview_url,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type MemeMetrics struct {
@@ -65,3 +128,34 @@ type MemeMetrics struct {
 
 	Meme Meme `json:"-" gorm:"foreignKey:MemeID;constraint:OnDelete:CASCADE"`
 }
+
+// SetGenerationModel запоминает модель, которой AI-сервис сгенерировал мем
+func (m *Meme) SetGenerationModel(model string) {
+	if m.Generation == nil {
+		m.Generation = &GenerationInfo{TaskID: m.TaskID, Style: m.Style}
+	}
+	m.Generation.Model = model
+}
+
+// SetQueueWait запоминает время ожидания в очереди TaskProcessor
+func (m *Meme) SetQueueWait(wait time.Duration) {
+	if m.Generation == nil {
+		m.Generation = &GenerationInfo{TaskID: m.TaskID, Style: m.Style}
+	}
+	ms := wait.Milliseconds()
+	m.Generation.QueueWaitMs = &ms
+}
+
+// MarkGenerationCompleted фиксирует время получения готового изображения и,
+// если известно время начала, длительность генерации (GenerationTimeMs)
+func (m *Meme) MarkGenerationCompleted(at time.Time) {
+	if m.Generation == nil {
+		m.Generation = &GenerationInfo{TaskID: m.TaskID, Style: m.Style}
+	}
+	m.Generation.CompletedAt = &at
+
+	if m.Generation.StartedAt != nil {
+		m.Generation.ProcessingMs = at.Sub(*m.Generation.StartedAt).Milliseconds()
+		m.GenerationTimeMs = int(m.Generation.ProcessingMs)
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemeFilter - условия выборки мемов, пустые поля не ограничивают выдачу.
+// CreatedFrom включительно, CreatedTo - не включительно
+type MemeFilter struct {
+	Search         string
+	Tags           TagFilter
+	Style          string
+	Status         string
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	AuthorUsername string
+	AspectRatio    string
+	Kind           string
+}
+
+func applyMemeFilter(query *gorm.DB, filter MemeFilter) *gorm.DB {
+	query = applySearch(query, filter.Search)
+	query = applyTagFilter(query, filter.Tags)
+
+	if filter.Style != "" {
+		query = query.Where("memes.style = ?", filter.Style)
+	}
+	if filter.Status != "" {
+		query = query.Where("memes.status = ?", filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("memes.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("memes.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.AuthorUsername != "" {
+		query = query.Where("memes.user_id IN (SELECT id FROM users WHERE username = ? AND deleted_at IS NULL)", filter.AuthorUsername)
+	}
+	if filter.AspectRatio != "" {
+		query = query.Where("memes.aspect_ratio = ?", filter.AspectRatio)
+	}
+	if filter.Kind != "" {
+		query = query.Where("memes.kind = ?", filter.Kind)
+	}
+
+	return query
+}
@@ -32,15 +32,62 @@ type SessionRepository interface {
 type MemeRepository interface {
 	Create(ctx context.Context, meme *models.Meme) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Meme, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int, search string) ([]*models.Meme, error)
-	GetPublicMemes(ctx context.Context, limit, offset int, search string) ([]*models.Meme, error)
-	Update(ctx context.Context, meme *models.Meme) error
+	GetByContentHash(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Meme, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, filter MemeFilter, page Pagination) ([]*models.Meme, error)
+	GetPublicMemes(ctx context.Context, filter MemeFilter, page Pagination) ([]*models.Meme, error)
+	UpdateGeneration(ctx context.Context, meme *models.Meme) error
+	UpdateMetadata(ctx context.Context, meme *models.Meme, tags []models.Tag, unmodifiedSince time.Time) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]*models.Meme, error)
-	CountByUserID(ctx context.Context, userID uuid.UUID, search string) (int64, error)
-	CountPublicMemes(ctx context.Context, search string) (int64, error)
-	Count(ctx context.Context) (int64, error)
+	List(ctx context.Context, filter MemeFilter, page Pagination) ([]*models.Meme, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID, filter MemeFilter) (int64, error)
+	CountPublicMemes(ctx context.Context, filter MemeFilter) (int64, error)
+	Count(ctx context.Context, filter MemeFilter) (int64, error)
 	FindStuckMemes(ctx context.Context, olderThan time.Duration) ([]*models.Meme, error)
+	ListImageURLs(ctx context.Context) ([]string, error)
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Meme, error)
+	GetDeletedByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Meme, error)
+	CountDeletedByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	CountCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.Meme, error)
+	HardDelete(ctx context.Context, id uuid.UUID) error
+	GetGenerationStats(ctx context.Context, from, to time.Time) ([]*GenerationStats, error)
+}
+
+type StyleOverrideRepository interface {
+	List(ctx context.Context) ([]*models.StyleOverride, error)
+	Upsert(ctx context.Context, override *models.StyleOverride) error
+	Delete(ctx context.Context, name string) error
+}
+
+type TagRepository interface {
+	GetOrCreate(ctx context.Context, tags []models.Tag) ([]models.Tag, error)
+	GetPopular(ctx context.Context, limit int) ([]*TagUsage, error)
+}
+
+// TagFilter - фильтр по slug тегов: MatchAll требует все теги сразу, иначе достаточно любого
+type TagFilter struct {
+	Slugs    []string
+	MatchAll bool
+}
+
+type TagUsage struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	MemeCount int64  `json:"meme_count"`
+}
+
+// GenerationStats - агрегаты по мемам одного вида и стиля, созданным за период.
+// Перцентили считаются только по завершённым мемам с известной длительностью
+type GenerationStats struct {
+	Kind           string   `json:"kind"`
+	Style          string   `json:"style"`
+	Total          int64    `json:"total"`
+	Completed      int64    `json:"completed"`
+	Failed         int64    `json:"failed"`
+	P50Ms          *float64 `json:"p50_ms"`
+	P95Ms          *float64 `json:"p95_ms"`
+	AvgQueueWaitMs *float64 `json:"avg_queue_wait_ms"`
 }
 
 type MetricsRepository interface {
@@ -2,13 +2,28 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"memology-backend/internal/models"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
+// ErrMemeModified - мем был изменён другим запросом после того, как его прочитали
+var ErrMemeModified = errors.New("meme was modified concurrently")
+
+// ErrDuplicateContent - у пользователя уже есть живой мем с той же картинкой (content_hash)
+var ErrDuplicateContent = errors.New("meme with the same content already exists")
+
+const (
+	// contentHashIndex - уникальный индекс по (user_id, content_hash) живых мемов
+	contentHashIndex = "idx_memes_user_content_hash"
+	// uniqueViolation - SQLSTATE нарушения уникальности в PostgreSQL
+	uniqueViolation = "23505"
+)
+
 type memeRepository struct {
 	db *gorm.DB
 }
@@ -18,7 +33,12 @@ func NewMemeRepository(db *gorm.DB) MemeRepository {
 }
 
 func (r *memeRepository) Create(ctx context.Context, meme *models.Meme) error {
-	return r.db.WithContext(ctx).Create(meme).Error
+	err := r.db.WithContext(ctx).Create(meme).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == contentHashIndex {
+		return ErrDuplicateContent
+	}
+	return err
 }
 
 func (r *memeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Meme, error) {
@@ -26,6 +46,7 @@ func (r *memeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Mem
 	err := r.db.WithContext(ctx).
 		Preload("User").
 		Preload("Metrics").
+		Preload("Tags").
 		First(&meme, "id = ?", id).Error
 	if err != nil {
 		return nil, err
@@ -33,90 +54,162 @@ func (r *memeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Mem
 	return &meme, nil
 }
 
-func (r *memeRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int, search string) ([]*models.Meme, error) {
+// GetByContentHash ищет живой мем пользователя с той же картинкой (самый ранний)
+func (r *memeRepository) GetByContentHash(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Meme, error) {
+	var meme models.Meme
+	err := r.db.WithContext(ctx).
+		Preload("Metrics").
+		Preload("Tags").
+		Where("user_id = ? AND content_hash = ? AND content_hash <> ''", userID, contentHash).
+		Order("created_at").
+		First(&meme).Error
+	if err != nil {
+		return nil, err
+	}
+	return &meme, nil
+}
+
+func (r *memeRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter MemeFilter, page Pagination) ([]*models.Meme, error) {
 	var memes []*models.Meme
 	query := r.db.WithContext(ctx).
 		Where("user_id = ?", userID).
 		Preload("Metrics").
-		Order("created_at DESC")
+		Preload("Tags")
 
-	if search != "" {
-		query = query.Where("prompt ILIKE ?", "%"+search+"%")
-	}
+	query = orderBySearchRank(applyMemeFilter(query, filter), filter.Search)
+	query = applyPagination(query, page, filter.Search)
 
-	err := query.Limit(limit).Offset(offset).Find(&memes).Error
+	err := query.Find(&memes).Error
 	return memes, err
 }
 
-func (r *memeRepository) Update(ctx context.Context, meme *models.Meme) error {
-	return r.db.WithContext(ctx).Save(meme).Error
+// generationColumns - поля, которые меняет генерация. Правки пользователя и updated_at
+// (из него строится ETag) в них не входят, вместо него отмечается status_updated_at
+var generationColumns = []string{"status", "task_id", "ai_backend", "image_url", "thumbnail_url", "generation", "generation_time_ms", "status_updated_at"}
+
+// UpdateGeneration сохраняет только поля генерации, не перезаписывая остальные поля
+// мема, которые могли измениться с момента чтения. Мем в корзине не обновляется:
+// возвращается gorm.ErrRecordNotFound
+func (r *memeRepository) UpdateGeneration(ctx context.Context, meme *models.Meme) error {
+	meme.StatusUpdatedAt = time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&models.Meme{ID: meme.ID}).
+		Select(generationColumns).
+		UpdateColumns(meme)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateMetadata сохраняет редактируемые пользователем поля и, если tags не nil, заменяет
+// теги мема - в одной транзакции и только если updated_at в базе всё ещё равен
+// unmodifiedSince. Иначе возвращает ErrMemeModified.
+func (r *memeRepository) UpdateMetadata(ctx context.Context, meme *models.Meme, tags []models.Tag, unmodifiedSince time.Time) error {
+	now := time.Now().Truncate(time.Microsecond)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&models.Meme{}).
+			Where("id = ? AND updated_at = ?", meme.ID, unmodifiedSince).
+			Updates(map[string]interface{}{
+				"title":       meme.Title,
+				"description": meme.Description,
+				"prompt":      meme.Prompt,
+				"is_public":   meme.IsPublic,
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMemeModified
+		}
+
+		if tags != nil {
+			return tx.Model(meme).Association("Tags").Replace(tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	meme.UpdatedAt = now
+	if tags != nil {
+		meme.Tags = tags
+	}
+	return nil
 }
 
 func (r *memeRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.Meme{}, "id = ?", id).Error
 }
 
-func (r *memeRepository) GetPublicMemes(ctx context.Context, limit, offset int, search string) ([]*models.Meme, error) {
+func (r *memeRepository) GetPublicMemes(ctx context.Context, filter MemeFilter, page Pagination) ([]*models.Meme, error) {
 	var memes []*models.Meme
 	query := r.db.WithContext(ctx).
 		Where("is_public = ?", true).
 		Preload("User").
 		Preload("Metrics").
-		Order("created_at DESC")
+		Preload("Tags")
 
-	if search != "" {
-		query = query.Where("prompt ILIKE ?", "%"+search+"%")
-	}
+	query = orderBySearchRank(applyMemeFilter(query, filter), filter.Search)
+	query = applyPagination(query, page, filter.Search)
 
-	err := query.Limit(limit).Offset(offset).Find(&memes).Error
+	err := query.Find(&memes).Error
 	return memes, err
 }
 
-func (r *memeRepository) List(ctx context.Context, limit, offset int) ([]*models.Meme, error) {
+func (r *memeRepository) List(ctx context.Context, filter MemeFilter, page Pagination) ([]*models.Meme, error) {
 	var memes []*models.Meme
-	err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Preload("User").
 		Preload("Metrics").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&memes).Error
+		Preload("Tags")
+
+	query = orderBySearchRank(applyMemeFilter(query, filter), filter.Search)
+	query = applyPagination(query, page, filter.Search)
+
+	err := query.Find(&memes).Error
 	return memes, err
 }
 
-func (r *memeRepository) CountByUserID(ctx context.Context, userID uuid.UUID, search string) (int64, error) {
+func (r *memeRepository) CountByUserID(ctx context.Context, userID uuid.UUID, filter MemeFilter) (int64, error) {
 	var count int64
 	query := r.db.WithContext(ctx).
 		Model(&models.Meme{}).
 		Where("user_id = ?", userID)
 
-	if search != "" {
-		query = query.Where("prompt ILIKE ?", "%"+search+"%")
-	}
+	query = applyMemeFilter(query, filter)
 
 	err := query.Count(&count).Error
 	return count, err
 }
 
-func (r *memeRepository) CountPublicMemes(ctx context.Context, search string) (int64, error) {
+func (r *memeRepository) CountPublicMemes(ctx context.Context, filter MemeFilter) (int64, error) {
 	var count int64
 	query := r.db.WithContext(ctx).
 		Model(&models.Meme{}).
 		Where("is_public = ?", true)
 
-	if search != "" {
-		query = query.Where("prompt ILIKE ?", "%"+search+"%")
-	}
+	query = applyMemeFilter(query, filter)
 
 	err := query.Count(&count).Error
 	return count, err
 }
 
-func (r *memeRepository) Count(ctx context.Context) (int64, error) {
+func (r *memeRepository) Count(ctx context.Context, filter MemeFilter) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.Meme{}).
-		Count(&count).Error
+	query := r.db.WithContext(ctx).
+		Model(&models.Meme{})
+
+	query = applyMemeFilter(query, filter)
+
+	err := query.Count(&count).Error
 	return count, err
 }
 
@@ -128,9 +221,171 @@ func (r *memeRepository) FindStuckMemes(ctx context.Context, olderThan time.Dura
 	err := r.db.WithContext(ctx).
 		Model(&models.Meme{}).
 		Where("status IN (?, ?, ?)", "pending", "processing", "failed").
-		Where("updated_at < ?", threshold).
-		Order("updated_at ASC").
+		Where("status_updated_at < ?", threshold).
+		Order("status_updated_at ASC").
 		Find(&memes).Error
 
 	return memes, err
 }
+
+// ListImageURLs возвращает URL оригиналов и превью. Учитываются и мемы в корзине:
+// их файлы нужны до окончательного удаления
+func (r *memeRepository) ListImageURLs(ctx context.Context) ([]string, error) {
+	var urls []string
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT image_url FROM memes WHERE image_url <> ''
+			UNION SELECT thumbnail_url FROM memes WHERE thumbnail_url <> ''`).
+		Scan(&urls).Error
+	return urls, err
+}
+
+func (r *memeRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Meme, error) {
+	var meme models.Meme
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&meme, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &meme, nil
+}
+
+func (r *memeRepository) GetDeletedByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Meme, error) {
+	var memes []*models.Meme
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&memes).Error
+	return memes, err
+}
+
+func (r *memeRepository) CountDeletedByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Meme{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// CountCreatedSince считает мемы пользователя, созданные начиная с since, вместе с корзиной:
+// удаление мема не возвращает квоту
+func (r *memeRepository) CountCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Meme{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// Restore возвращает мем из корзины. Если пока он лежал в корзине, пользователь загрузил
+// ту же картинку ещё раз, у восстановленного мема сбрасывается content_hash: живой мем
+// с таким хэшем может быть только один
+func (r *memeRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Meme{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"content_hash": gorm.Expr(`CASE WHEN EXISTS (
+				SELECT 1 FROM memes o WHERE o.user_id = memes.user_id AND o.content_hash = memes.content_hash
+					AND o.content_hash <> '' AND o.deleted_at IS NULL
+			) THEN '' ELSE content_hash END`),
+		}).Error
+}
+
+func (r *memeRepository) FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.Meme, error) {
+	var memes []*models.Meme
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&memes).Error
+	return memes, err
+}
+
+func (r *memeRepository) HardDelete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&models.Meme{}, "id = ?", id).Error
+}
+
+// GetGenerationStats учитывает и удалённые мемы: генерация уже состоялась
+func (r *memeRepository) GetGenerationStats(ctx context.Context, from, to time.Time) ([]*GenerationStats, error) {
+	var stats []*GenerationStats
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Meme{}).
+		Select(`kind, style,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY generation_time_ms)
+				FILTER (WHERE status = 'completed' AND generation_time_ms > 0) AS p50_ms,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY generation_time_ms)
+				FILTER (WHERE status = 'completed' AND generation_time_ms > 0) AS p95_ms,
+			AVG((generation->>'queue_wait_ms')::bigint)
+				FILTER (WHERE generation->>'queue_wait_ms' IS NOT NULL) AS avg_queue_wait_ms`).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("kind, style").
+		Order("total DESC, kind, style").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// searchTSQuery объединяет запрос пользователя в обеих конфигурациях; параметр передаётся дважды
+const searchTSQuery = "(websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?))"
+
+// applySearch ищет по search_vector, а для опечаток - по триграммам промпта
+func applySearch(query *gorm.DB, search string) *gorm.DB {
+	if search == "" {
+		return query
+	}
+
+	return query.Where("(memes.search_vector @@ "+searchTSQuery+" OR ? <% memes.prompt)", search, search, search)
+}
+
+// searchScoreSQL - релевантность для сортировки и курсора: ранг full-text плюс
+// триграммное сходство, чтобы совпадения с опечатками тоже упорядочивались
+const searchScoreSQL = "(ts_rank(memes.search_vector, " + searchTSQuery + ") + word_similarity(?, memes.prompt))"
+
+// orderBySearchRank сортирует результаты поиска по релевантности и добавляет подсветку,
+// без поиска - по дате создания. id в конце делает порядок однозначным для курсора
+func orderBySearchRank(query *gorm.DB, search string) *gorm.DB {
+	if search == "" {
+		return query.Order("memes.created_at DESC, memes.id DESC")
+	}
+
+	return query.
+		Select("memes.*, "+searchScoreSQL+" AS search_rank, "+
+			"ts_headline('russian', memes.prompt, "+searchTSQuery+", 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS search_highlight",
+			search, search, search, search, search).
+		Order("search_rank DESC, memes.id DESC")
+}
+
+func applyTagFilter(query *gorm.DB, tags TagFilter) *gorm.DB {
+	if len(tags.Slugs) == 0 {
+		return query
+	}
+
+	if tags.MatchAll {
+		return query.Where(`memes.id IN (
+			SELECT meme_tags.meme_id FROM meme_tags
+			JOIN tags ON tags.id = meme_tags.tag_id
+			WHERE tags.slug IN ?
+			GROUP BY meme_tags.meme_id
+			HAVING COUNT(DISTINCT tags.slug) = ?)`, tags.Slugs, len(tags.Slugs))
+	}
+
+	return query.Where(`memes.id IN (
+		SELECT meme_tags.meme_id FROM meme_tags
+		JOIN tags ON tags.id = meme_tags.tag_id
+		WHERE tags.slug IN ?)`, tags.Slugs)
+}
@@ -0,0 +1,85 @@
+// Package memory - потокобезопасные in-memory реализации интерфейсов repository для
+// юнит-тестов сервисов. Поведение повторяет GORM-реализации: мягкое удаление, порядок
+// выдачи, пагинация, ошибки для отсутствующих записей. Полнотекстовый поиск упрощён:
+// совпадением считается вхождение каждого слова запроса в заголовок, теги, промпт или описание
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"memology-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// errDuplicateKey и errForeignKey соответствуют нарушению ограничений в PostgreSQL
+	errDuplicateKey = errors.New("duplicate key value violates unique constraint")
+	errForeignKey   = errors.New("insert violates foreign key constraint")
+)
+
+// DB - общее хранилище репозиториев: мемам нужны пользователи, теги и метрики.
+// Репозитории, созданные над одной DB, видят данные друг друга
+type DB struct {
+	mu       sync.RWMutex
+	users    map[uuid.UUID]*models.User
+	sessions map[uuid.UUID]*models.UserSession
+	memes    map[uuid.UUID]*models.Meme
+	memeTags map[uuid.UUID][]uuid.UUID
+	tags     map[uuid.UUID]*models.Tag
+	metrics  map[uuid.UUID]*models.MemeMetrics
+}
+
+func NewDB() *DB {
+	return &DB{
+		users:    make(map[uuid.UUID]*models.User),
+		sessions: make(map[uuid.UUID]*models.UserSession),
+		memes:    make(map[uuid.UUID]*models.Meme),
+		memeTags: make(map[uuid.UUID][]uuid.UUID),
+		tags:     make(map[uuid.UUID]*models.Tag),
+		metrics:  make(map[uuid.UUID]*models.MemeMetrics),
+	}
+}
+
+// now возвращает время с точностью PostgreSQL, чтобы сравнения по updated_at
+// вели себя так же, как после записи в базу
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// stamp заполняет незаданное время создания и изменения, как это делает GORM при Create
+func stamp(createdAt, updatedAt *time.Time) {
+	t := now()
+	if createdAt.IsZero() {
+		*createdAt = t
+	}
+	if updatedAt != nil && updatedAt.IsZero() {
+		*updatedAt = t
+	}
+	*createdAt = createdAt.Truncate(time.Microsecond)
+	if updatedAt != nil {
+		*updatedAt = updatedAt.Truncate(time.Microsecond)
+	}
+}
+
+// paginate применяет LIMIT/OFFSET по правилам GORM: отрицательный limit снимает ограничение
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return items[:0]
+		}
+		items = items[offset:]
+	}
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// compareIDs упорядочивает UUID так же, как PostgreSQL - побайтово
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
@@ -0,0 +1,385 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memeRepository struct {
+	db *DB
+}
+
+func NewMemeRepository(db *DB) repository.MemeRepository {
+	return &memeRepository{db: db}
+}
+
+func cloneMeme(meme *models.Meme) *models.Meme {
+	clone := *meme
+	if meme.Generation != nil {
+		generation := *meme.Generation
+		generation.Captions = append([]string(nil), meme.Generation.Captions...)
+		clone.Generation = &generation
+	}
+	clone.User = models.User{}
+	clone.Metrics = nil
+	clone.Tags = nil
+	clone.SearchHighlight = ""
+	clone.SearchRank = 0
+	return &clone
+}
+
+// preload возвращает копию мема со связями, как Preload в GORM: удалённый автор не подгружается
+func (db *DB) preload(meme *models.Meme, withUser bool) *models.Meme {
+	clone := cloneMeme(meme)
+	clone.Tags = db.memeTagList(meme.ID)
+	if metrics, ok := db.metrics[meme.ID]; ok {
+		clone.Metrics = cloneMetrics(metrics)
+	}
+	if withUser {
+		if user, ok := db.users[meme.UserID]; ok && !user.DeletedAt.Valid {
+			clone.User = *cloneUser(user)
+		}
+	}
+	return clone
+}
+
+func (db *DB) memeTagList(memeID uuid.UUID) []models.Tag {
+	tagIDs := db.memeTags[memeID]
+	tags := make([]models.Tag, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		tags = append(tags, *db.tags[id])
+	}
+	return tags
+}
+
+// saveTags сохраняет теги, которых ещё нет, и возвращает их id без повторов
+func (db *DB) saveTags(tags []models.Tag) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(tags))
+	seen := make(map[uuid.UUID]bool, len(tags))
+	for i := range tags {
+		tag := &tags[i]
+		if tag.ID == uuid.Nil {
+			if existing := db.tagBySlug(tag.Slug); existing != nil {
+				*tag = *existing
+			} else {
+				tag.ID = uuid.New()
+			}
+		}
+		if _, ok := db.tags[tag.ID]; !ok {
+			stamp(&tag.CreatedAt, nil)
+			stored := *tag
+			db.tags[tag.ID] = &stored
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			ids = append(ids, tag.ID)
+		}
+	}
+	return ids
+}
+
+func (r *memeRepository) Create(ctx context.Context, meme *models.Meme) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[meme.UserID]; !ok {
+		return errForeignKey
+	}
+	if meme.ID == uuid.Nil {
+		meme.ID = uuid.New()
+	} else if _, ok := r.db.memes[meme.ID]; ok {
+		return errDuplicateKey
+	}
+	if r.hasLiveContentHash(meme.UserID, meme.ContentHash, meme.ID) {
+		return repository.ErrDuplicateContent
+	}
+
+	// Значения по умолчанию из тегов модели
+	if meme.Width == 0 {
+		meme.Width = 500
+	}
+	if meme.Height == 0 {
+		meme.Height = 500
+	}
+	if meme.AspectRatio == "" {
+		meme.AspectRatio = "1:1"
+	}
+	if meme.Kind == "" {
+		meme.Kind = models.MemeKindAI
+	}
+	if meme.Status == "" {
+		meme.Status = "pending"
+	}
+	stamp(&meme.CreatedAt, &meme.UpdatedAt)
+	if meme.StatusUpdatedAt.IsZero() {
+		meme.StatusUpdatedAt = now()
+	}
+	meme.StatusUpdatedAt = meme.StatusUpdatedAt.Truncate(time.Microsecond)
+
+	r.db.memes[meme.ID] = cloneMeme(meme)
+	if len(meme.Tags) > 0 {
+		r.db.memeTags[meme.ID] = r.db.saveTags(meme.Tags)
+	}
+	return nil
+}
+
+// hasLiveContentHash повторяет уникальный индекс idx_memes_user_content_hash:
+// живой мем пользователя с той же картинкой, кроме мема except
+func (r *memeRepository) hasLiveContentHash(userID uuid.UUID, contentHash string, except uuid.UUID) bool {
+	if contentHash == "" {
+		return false
+	}
+	for _, meme := range r.db.memes {
+		if meme.ID != except && !meme.DeletedAt.Valid && meme.UserID == userID && meme.ContentHash == contentHash {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Meme, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	meme, ok := r.db.memes[id]
+	if !ok || meme.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.db.preload(meme, true), nil
+}
+
+func (r *memeRepository) GetByContentHash(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Meme, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var found *models.Meme
+	for _, meme := range r.db.memes {
+		if meme.DeletedAt.Valid || meme.UserID != userID || contentHash == "" || meme.ContentHash != contentHash {
+			continue
+		}
+		if found == nil || meme.CreatedAt.Before(found.CreatedAt) {
+			found = meme
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.db.preload(found, false), nil
+}
+
+func (r *memeRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter repository.MemeFilter, page repository.Pagination) ([]*models.Meme, error) {
+	return r.find(func(m *models.Meme) bool { return m.UserID == userID }, filter, page, false), nil
+}
+
+func (r *memeRepository) GetPublicMemes(ctx context.Context, filter repository.MemeFilter, page repository.Pagination) ([]*models.Meme, error) {
+	return r.find(func(m *models.Meme) bool { return m.IsPublic }, filter, page, true), nil
+}
+
+func (r *memeRepository) List(ctx context.Context, filter repository.MemeFilter, page repository.Pagination) ([]*models.Meme, error) {
+	return r.find(nil, filter, page, true), nil
+}
+
+func (r *memeRepository) UpdateGeneration(ctx context.Context, meme *models.Meme) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.memes[meme.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+
+	updated := cloneMeme(meme)
+	stored.Status = updated.Status
+	stored.TaskID = updated.TaskID
+	stored.AIBackend = updated.AIBackend
+	stored.ImageURL = updated.ImageURL
+	stored.ThumbnailURL = updated.ThumbnailURL
+	stored.Generation = updated.Generation
+	stored.GenerationTimeMs = updated.GenerationTimeMs
+	stored.StatusUpdatedAt = now()
+	meme.StatusUpdatedAt = stored.StatusUpdatedAt
+	return nil
+}
+
+func (r *memeRepository) UpdateMetadata(ctx context.Context, meme *models.Meme, tags []models.Tag, unmodifiedSince time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.memes[meme.ID]
+	if !ok || stored.DeletedAt.Valid || !stored.UpdatedAt.Equal(unmodifiedSince) {
+		return repository.ErrMemeModified
+	}
+
+	stored.Title = meme.Title
+	stored.Description = meme.Description
+	stored.Prompt = meme.Prompt
+	stored.IsPublic = meme.IsPublic
+	// updated_at обязан измениться, иначе следующая правка с тем же значением не заметит эту
+	updated := now()
+	if !updated.After(stored.UpdatedAt) {
+		updated = stored.UpdatedAt.Add(time.Microsecond)
+	}
+	stored.UpdatedAt = updated
+
+	if tags != nil {
+		if len(tags) == 0 {
+			delete(r.db.memeTags, meme.ID)
+		} else {
+			r.db.memeTags[meme.ID] = r.db.saveTags(tags)
+		}
+		meme.Tags = tags
+	}
+
+	meme.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if meme, ok := r.db.memes[id]; ok && !meme.DeletedAt.Valid {
+		meme.DeletedAt = gorm.DeletedAt{Time: now(), Valid: true}
+	}
+	return nil
+}
+
+func (r *memeRepository) CountByUserID(ctx context.Context, userID uuid.UUID, filter repository.MemeFilter) (int64, error) {
+	return r.count(func(m *models.Meme) bool { return m.UserID == userID }, filter), nil
+}
+
+func (r *memeRepository) CountPublicMemes(ctx context.Context, filter repository.MemeFilter) (int64, error) {
+	return r.count(func(m *models.Meme) bool { return m.IsPublic }, filter), nil
+}
+
+func (r *memeRepository) Count(ctx context.Context, filter repository.MemeFilter) (int64, error) {
+	return r.count(nil, filter), nil
+}
+
+func (r *memeRepository) FindStuckMemes(ctx context.Context, olderThan time.Duration) ([]*models.Meme, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	threshold := time.Now().Add(-olderThan)
+
+	var memes []*models.Meme
+	for _, meme := range r.db.memes {
+		if meme.DeletedAt.Valid || !meme.StatusUpdatedAt.Before(threshold) {
+			continue
+		}
+		switch meme.Status {
+		case "pending", "processing", "failed":
+			memes = append(memes, cloneMeme(meme))
+		}
+	}
+	sort.Slice(memes, func(i, j int) bool {
+		return memes[i].StatusUpdatedAt.Before(memes[j].StatusUpdatedAt)
+	})
+	return memes, nil
+}
+
+func (r *memeRepository) ListImageURLs(ctx context.Context) ([]string, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, meme := range r.db.memes {
+		for _, url := range []string{meme.ImageURL, meme.ThumbnailURL} {
+			if url != "" && !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls, nil
+}
+
+func (r *memeRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Meme, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	meme, ok := r.db.memes[id]
+	if !ok || !meme.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return cloneMeme(meme), nil
+}
+
+func (r *memeRepository) GetDeletedByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Meme, error) {
+	memes := r.deleted(func(m *models.Meme) bool { return m.UserID == userID })
+	sort.Slice(memes, func(i, j int) bool {
+		return memes[i].DeletedAt.Time.After(memes[j].DeletedAt.Time)
+	})
+	return paginate(memes, limit, offset), nil
+}
+
+func (r *memeRepository) CountDeletedByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return int64(len(r.deleted(func(m *models.Meme) bool { return m.UserID == userID }))), nil
+}
+
+func (r *memeRepository) CountCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var count int64
+	for _, meme := range r.db.memes {
+		if meme.UserID == userID && !meme.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memeRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if meme, ok := r.db.memes[id]; ok && meme.DeletedAt.Valid {
+		if r.hasLiveContentHash(meme.UserID, meme.ContentHash, meme.ID) {
+			meme.ContentHash = ""
+		}
+		meme.DeletedAt = gorm.DeletedAt{}
+		meme.UpdatedAt = now()
+	}
+	return nil
+}
+
+func (r *memeRepository) FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.Meme, error) {
+	memes := r.deleted(func(m *models.Meme) bool { return m.DeletedAt.Time.Before(before) })
+	sort.Slice(memes, func(i, j int) bool {
+		return memes[i].DeletedAt.Time.Before(memes[j].DeletedAt.Time)
+	})
+	return paginate(memes, limit, 0), nil
+}
+
+// HardDelete удаляет мем вместе со связями с тегами и метриками (ON DELETE CASCADE)
+func (r *memeRepository) HardDelete(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.memes, id)
+	delete(r.db.memeTags, id)
+	delete(r.db.metrics, id)
+	return nil
+}
+
+func (r *memeRepository) deleted(match func(*models.Meme) bool) []*models.Meme {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var memes []*models.Meme
+	for _, meme := range r.db.memes {
+		if meme.DeletedAt.Valid && match(meme) {
+			memes = append(memes, cloneMeme(meme))
+		}
+	}
+	return memes
+}
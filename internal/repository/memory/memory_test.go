@@ -0,0 +1,79 @@
+package memory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/repository/memory"
+	"memology-backend/internal/repository/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := memory.NewDB()
+		return repotest.Repositories{
+			Users:    memory.NewUserRepository(db),
+			Sessions: memory.NewSessionRepository(db),
+			Memes:    memory.NewMemeRepository(db),
+			Metrics:  memory.NewMetricsRepository(db),
+			Tags:     memory.NewTagRepository(db),
+		}
+	})
+}
+
+// TestConcurrentAccess имеет смысл с -race: репозитории используются из горутин TaskProcessor
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	users := memory.NewUserRepository(db)
+	memes := memory.NewMemeRepository(db)
+	metrics := memory.NewMetricsRepository(db)
+
+	user := &models.User{Username: "author", Email: "author@example.com"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	meme := &models.Meme{UserID: user.ID, Prompt: "мем", IsPublic: true}
+	if err := memes.Create(ctx, meme); err != nil {
+		t.Fatal(err)
+	}
+	if err := metrics.Create(ctx, &models.MemeMetrics{MemeID: meme.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, iterations = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				metrics.IncrementClick(ctx, meme.ID)
+				memes.Create(ctx, &models.Meme{UserID: user.ID, Prompt: "мем"})
+				memes.List(ctx, repository.MemeFilter{Search: "мем"}, repository.Pagination{Limit: 10})
+				if m, err := memes.GetByID(ctx, meme.ID); err == nil {
+					memes.UpdateGeneration(ctx, m)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := metrics.GetByMemeID(ctx, meme.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClickCount != workers*iterations {
+		t.Fatalf("expected %d clicks, got %d", workers*iterations, got.ClickCount)
+	}
+	count, err := memes.Count(ctx, repository.MemeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != workers*iterations+1 {
+		t.Fatalf("expected %d memes, got %d", workers*iterations+1, count)
+	}
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type metricsRepository struct {
+	db *DB
+}
+
+func NewMetricsRepository(db *DB) repository.MetricsRepository {
+	return &metricsRepository{db: db}
+}
+
+func cloneMetrics(metrics *models.MemeMetrics) *models.MemeMetrics {
+	clone := *metrics
+	clone.Meme = models.Meme{}
+	return &clone
+}
+
+func (r *metricsRepository) Create(ctx context.Context, metrics *models.MemeMetrics) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.memes[metrics.MemeID]; !ok {
+		return errForeignKey
+	}
+	if _, ok := r.db.metrics[metrics.MemeID]; ok {
+		return errDuplicateKey
+	}
+
+	if metrics.ID == uuid.Nil {
+		metrics.ID = uuid.New()
+	}
+	stamp(&metrics.CreatedAt, &metrics.UpdatedAt)
+	r.db.metrics[metrics.MemeID] = cloneMetrics(metrics)
+	return nil
+}
+
+func (r *metricsRepository) GetByMemeID(ctx context.Context, memeID uuid.UUID) (*models.MemeMetrics, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	metrics, ok := r.db.metrics[memeID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return cloneMetrics(metrics), nil
+}
+
+func (r *metricsRepository) Update(ctx context.Context, metrics *models.MemeMetrics) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.memes[metrics.MemeID]; !ok {
+		return errForeignKey
+	}
+	metrics.UpdatedAt = now()
+	stamp(&metrics.CreatedAt, nil)
+	r.db.metrics[metrics.MemeID] = cloneMetrics(metrics)
+	return nil
+}
+
+func (r *metricsRepository) IncrementClick(ctx context.Context, memeID uuid.UUID) error {
+	return r.increment(memeID, func(m *models.MemeMetrics) { m.ClickCount++ })
+}
+
+func (r *metricsRepository) IncrementDownload(ctx context.Context, memeID uuid.UUID) error {
+	return r.increment(memeID, func(m *models.MemeMetrics) { m.DownloadCount++ })
+}
+
+func (r *metricsRepository) UpdateRating(ctx context.Context, memeID uuid.UUID, delta int) error {
+	return r.increment(memeID, func(m *models.MemeMetrics) { m.RatingScore += delta })
+}
+
+func (r *metricsRepository) increment(memeID uuid.UUID, apply func(*models.MemeMetrics)) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	metrics, ok := r.db.metrics[memeID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	apply(metrics)
+	metrics.UpdatedAt = now()
+	return nil
+}
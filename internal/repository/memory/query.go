@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+)
+
+// Веса полей при поиске, как у ts_rank для весов A-D в search_vector
+const (
+	titleWeight       = 1.0
+	tagsWeight        = 0.4
+	promptWeight      = 0.2
+	descriptionWeight = 0.1
+)
+
+// match - мем, прошедший фильтр, и его релевантность для поиска
+type match struct {
+	meme  *models.Meme
+	score float64
+}
+
+// find повторяет applyMemeFilter, orderBySearchRank и applyPagination из GORM-реализации
+func (r *memeRepository) find(scope func(*models.Meme) bool, filter repository.MemeFilter, page repository.Pagination, withUser bool) []*models.Meme {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	matches := r.db.filterMemes(scope, filter)
+	terms := searchTerms(filter.Search)
+
+	if len(terms) > 0 {
+		sort.Slice(matches, func(i, j int) bool {
+			if matches[i].score != matches[j].score {
+				return matches[i].score > matches[j].score
+			}
+			return compareIDs(matches[i].meme.ID, matches[j].meme.ID) > 0
+		})
+	} else {
+		sort.Slice(matches, func(i, j int) bool {
+			a, b := matches[i].meme, matches[j].meme
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return compareIDs(a.ID, b.ID) > 0
+		})
+	}
+
+	matches = applyCursor(matches, page, len(terms) > 0)
+	offset := page.Offset
+	if page.After != nil {
+		offset = 0
+	}
+	matches = paginate(matches, page.Limit, offset)
+
+	memes := make([]*models.Meme, 0, len(matches))
+	for _, m := range matches {
+		meme := r.db.preload(m.meme, withUser)
+		if len(terms) > 0 {
+			meme.SearchRank = m.score
+			meme.SearchHighlight = highlight(meme.Prompt, terms)
+		}
+		memes = append(memes, meme)
+	}
+	return memes
+}
+
+func (r *memeRepository) count(scope func(*models.Meme) bool, filter repository.MemeFilter) int64 {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return int64(len(r.db.filterMemes(scope, filter)))
+}
+
+// applyCursor оставляет мемы после курсора в порядке выдачи
+func applyCursor(matches []match, page repository.Pagination, search bool) []match {
+	cursor := page.After
+	if cursor == nil {
+		return matches
+	}
+
+	result := matches[:0]
+	for _, m := range matches {
+		var after bool
+		if search && cursor.Score != nil {
+			after = m.score < *cursor.Score ||
+				(m.score == *cursor.Score && compareIDs(m.meme.ID, cursor.ID) < 0)
+		} else {
+			after = m.meme.CreatedAt.Before(cursor.CreatedAt) ||
+				(m.meme.CreatedAt.Equal(cursor.CreatedAt) && compareIDs(m.meme.ID, cursor.ID) < 0)
+		}
+		if after {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// filterMemes возвращает мемы не в корзине, подходящие под scope и фильтр
+func (db *DB) filterMemes(scope func(*models.Meme) bool, filter repository.MemeFilter) []match {
+	terms := searchTerms(filter.Search)
+
+	var matches []match
+	for _, meme := range db.memes {
+		if meme.DeletedAt.Valid || (scope != nil && !scope(meme)) || !db.matchFilter(meme, filter) {
+			continue
+		}
+
+		var score float64
+		if len(terms) > 0 {
+			var ok bool
+			if score, ok = db.searchScore(meme, terms); !ok {
+				continue
+			}
+		}
+		matches = append(matches, match{meme: meme, score: score})
+	}
+	return matches
+}
+
+func (db *DB) matchFilter(meme *models.Meme, filter repository.MemeFilter) bool {
+	switch {
+	case filter.Style != "" && meme.Style != filter.Style,
+		filter.Status != "" && meme.Status != filter.Status,
+		filter.CreatedFrom != nil && meme.CreatedAt.Before(*filter.CreatedFrom),
+		filter.CreatedTo != nil && !meme.CreatedAt.Before(*filter.CreatedTo),
+		filter.AspectRatio != "" && meme.AspectRatio != filter.AspectRatio,
+		filter.Kind != "" && meme.Kind != filter.Kind:
+		return false
+	}
+
+	if filter.AuthorUsername != "" {
+		user, ok := db.users[meme.UserID]
+		if !ok || user.DeletedAt.Valid || user.Username != filter.AuthorUsername {
+			return false
+		}
+	}
+
+	return db.matchTags(meme, filter.Tags)
+}
+
+// matchTags повторяет applyTagFilter: MatchAll требует, чтобы число разных совпавших
+// slug равнялось длине списка
+func (db *DB) matchTags(meme *models.Meme, filter repository.TagFilter) bool {
+	if len(filter.Slugs) == 0 {
+		return true
+	}
+
+	wanted := make(map[string]bool, len(filter.Slugs))
+	for _, slug := range filter.Slugs {
+		wanted[slug] = true
+	}
+
+	matched := make(map[string]bool)
+	for _, tag := range db.memeTagList(meme.ID) {
+		if wanted[tag.Slug] {
+			matched[tag.Slug] = true
+		}
+	}
+
+	if filter.MatchAll {
+		return len(matched) == len(filter.Slugs)
+	}
+	return len(matched) > 0
+}
+
+// searchTerms разбивает запрос на слова в нижнем регистре
+func searchTerms(search string) []string {
+	return strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchScore проверяет, что каждое слово запроса встречается в меме, и считает
+// релевантность по весам полей, в которых нашлись слова
+func (db *DB) searchScore(meme *models.Meme, terms []string) (float64, bool) {
+	tagNames := make([]string, 0, len(db.memeTags[meme.ID]))
+	for _, tag := range db.memeTagList(meme.ID) {
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{strings.ToLower(meme.Title), titleWeight},
+		{strings.ToLower(strings.Join(tagNames, " ")), tagsWeight},
+		{strings.ToLower(meme.Prompt), promptWeight},
+		{strings.ToLower(meme.Description), descriptionWeight},
+	}
+
+	var score float64
+	for _, term := range terms {
+		found := false
+		for _, field := range fields {
+			if strings.Contains(field.text, term) {
+				score += field.weight
+				found = true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// highlight оборачивает в <mark> слова промпта, содержащие слова запроса
+func highlight(prompt string, terms []string) string {
+	var b strings.Builder
+	word := func(w string) {
+		lower := strings.ToLower(w)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				b.WriteString("<mark>" + w + "</mark>")
+				return
+			}
+		}
+		b.WriteString(w)
+	}
+
+	start := -1
+	for i, r := range prompt {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			word(prompt[start:i])
+			start = -1
+		}
+		if !isWord {
+			b.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		word(prompt[start:])
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type sessionRepository struct {
+	db *DB
+}
+
+func NewSessionRepository(db *DB) repository.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func cloneSession(session *models.UserSession) *models.UserSession {
+	clone := *session
+	clone.User = models.User{}
+	return &clone
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.UserSession) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[session.UserID]; !ok {
+		return errForeignKey
+	}
+
+	session.ID = uuid.New()
+	stamp(&session.CreatedAt, nil)
+	r.db.sessions[session.ID] = cloneSession(session)
+	return nil
+}
+
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, session := range r.db.sessions {
+		if session.TokenHash == tokenHash {
+			return cloneSession(session), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *sessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserSession, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var sessions []*models.UserSession
+	for _, session := range r.db.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (r *sessionRepository) Update(ctx context.Context, session *models.UserSession) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[session.UserID]; !ok {
+		return errForeignKey
+	}
+
+	stamp(&session.CreatedAt, nil)
+	r.db.sessions[session.ID] = cloneSession(session)
+	return nil
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.sessions, id)
+	return nil
+}
+
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, session := range r.db.sessions {
+		if session.UserID == userID {
+			delete(r.db.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *sessionRepository) DeleteExpired(ctx context.Context) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.db.sessions {
+		if session.ExpiresAt.Before(now) {
+			delete(r.db.sessions, id)
+		}
+	}
+	return nil
+}
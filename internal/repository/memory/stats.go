@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"memology-backend/internal/repository"
+)
+
+// GetGenerationStats учитывает и удалённые мемы, перцентили считаются как percentile_cont
+func (r *memeRepository) GetGenerationStats(ctx context.Context, from, to time.Time) ([]*repository.GenerationStats, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	type group struct {
+		stats      repository.GenerationStats
+		durations  []float64
+		queueWaits []float64
+	}
+
+	groups := make(map[[2]string]*group)
+	for _, meme := range r.db.memes {
+		if meme.CreatedAt.Before(from) || !meme.CreatedAt.Before(to) {
+			continue
+		}
+
+		key := [2]string{meme.Kind, meme.Style}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{stats: repository.GenerationStats{Kind: meme.Kind, Style: meme.Style}}
+			groups[key] = g
+		}
+
+		g.stats.Total++
+		switch meme.Status {
+		case "completed":
+			g.stats.Completed++
+			if meme.GenerationTimeMs > 0 {
+				g.durations = append(g.durations, float64(meme.GenerationTimeMs))
+			}
+		case "failed":
+			g.stats.Failed++
+		}
+		// Мемы без измеренного ожидания (queue_wait_ms нет в generation) в среднее не входят
+		if meme.Generation != nil && meme.Generation.QueueWaitMs != nil {
+			g.queueWaits = append(g.queueWaits, float64(*meme.Generation.QueueWaitMs))
+		}
+	}
+
+	result := make([]*repository.GenerationStats, 0, len(groups))
+	for _, g := range groups {
+		stats := g.stats
+		stats.P50Ms = percentile(g.durations, 0.5)
+		stats.P95Ms = percentile(g.durations, 0.95)
+		stats.AvgQueueWaitMs = average(g.queueWaits)
+		result = append(result, &stats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Style < b.Style
+	})
+	return result, nil
+}
+
+// percentile - непрерывный перцентиль с линейной интерполяцией, nil для пустой выборки
+func percentile(values []float64, p float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+
+	pos := p * float64(len(values)-1)
+	lower := math.Floor(pos)
+	result := values[int(lower)]
+	if upper := int(math.Ceil(pos)); upper != int(lower) {
+		result += (pos - lower) * (values[upper] - values[int(lower)])
+	}
+	return &result
+}
+
+func average(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	result := sum / float64(len(values))
+	return &result
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type tagRepository struct {
+	db *DB
+}
+
+func NewTagRepository(db *DB) repository.TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) GetOrCreate(ctx context.Context, tags []models.Tag) ([]models.Tag, error) {
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	result := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		existing := r.db.tagBySlug(tag.Slug)
+		if existing == nil {
+			if tag.ID == uuid.Nil {
+				tag.ID = uuid.New()
+			}
+			stamp(&tag.CreatedAt, nil)
+			existing = &tag
+			r.db.tags[tag.ID] = existing
+		}
+		result = append(result, *existing)
+	}
+	return result, nil
+}
+
+func (db *DB) tagBySlug(slug string) *models.Tag {
+	for _, tag := range db.tags {
+		if tag.Slug == slug {
+			return tag
+		}
+	}
+	return nil
+}
+
+// GetPopular считает только публичные мемы не в корзине
+func (r *tagRepository) GetPopular(ctx context.Context, limit int) ([]*repository.TagUsage, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	counts := make(map[uuid.UUID]int64)
+	for memeID, tagIDs := range r.db.memeTags {
+		meme := r.db.memes[memeID]
+		if meme == nil || meme.DeletedAt.Valid || !meme.IsPublic {
+			continue
+		}
+		for _, tagID := range tagIDs {
+			counts[tagID]++
+		}
+	}
+
+	usages := make([]*repository.TagUsage, 0, len(counts))
+	for tagID, count := range counts {
+		tag := r.db.tags[tagID]
+		usages = append(usages, &repository.TagUsage{Name: tag.Name, Slug: tag.Slug, MemeCount: count})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].MemeCount != usages[j].MemeCount {
+			return usages[i].MemeCount > usages[j].MemeCount
+		}
+		return usages[i].Slug < usages[j].Slug
+	})
+	return paginate(usages, limit, 0), nil
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
+func cloneUser(user *models.User) *models.User {
+	clone := *user
+	clone.Sessions = nil
+	clone.Memes = nil
+	return &clone
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user.ID = uuid.New()
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+
+	stamp(&user.CreatedAt, &user.UpdatedAt)
+	// GORM заменяет false на default:true из тега
+	user.IsActive = true
+	r.db.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+// checkUnique проверяет уникальность имени и email; как и индекс в базе, учитывает удалённых
+func (r *userRepository) checkUnique(user *models.User) error {
+	for _, existing := range r.db.users {
+		if existing.ID == user.ID {
+			continue
+		}
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return errDuplicateKey
+		}
+	}
+	return nil
+}
+
+func (r *userRepository) find(match func(*models.User) bool) *models.User {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, user := range r.db.users {
+		if !user.DeletedAt.Valid && match(user) {
+			return cloneUser(user)
+		}
+	}
+	return nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.ID == id }), nil
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Username == username }), nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email }), nil
+}
+
+// Update сохраняет все поля, как Save в GORM
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+
+	if existing, ok := r.db.users[user.ID]; ok {
+		user.CreatedAt = existing.CreatedAt
+	}
+	user.UpdatedAt = now()
+	stamp(&user.CreatedAt, nil)
+	r.db.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if user, ok := r.db.users[id]; ok && !user.DeletedAt.Valid {
+		user.DeletedAt = gorm.DeletedAt{Time: now(), Valid: true}
+	}
+	return nil
+}
+
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.db.users))
+	for _, user := range r.db.users {
+		if !user.DeletedAt.Valid {
+			users = append(users, cloneUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return compareIDs(users[i].ID, users[j].ID) < 0
+	})
+	return paginate(users, limit, offset), nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memology-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type metricsRepository struct {
+	db *gorm.DB
+}
+
+func NewMetricsRepository(db *gorm.DB) MetricsRepository {
+	return &metricsRepository{db: db}
+}
+
+func (r *metricsRepository) Create(ctx context.Context, metrics *models.MemeMetrics) error {
+	return r.db.WithContext(ctx).Create(metrics).Error
+}
+
+func (r *metricsRepository) GetByMemeID(ctx context.Context, memeID uuid.UUID) (*models.MemeMetrics, error) {
+	var metrics models.MemeMetrics
+	err := r.db.WithContext(ctx).First(&metrics, "meme_id = ?", memeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (r *metricsRepository) Update(ctx context.Context, metrics *models.MemeMetrics) error {
+	return r.db.WithContext(ctx).Save(metrics).Error
+}
+
+func (r *metricsRepository) IncrementClick(ctx context.Context, memeID uuid.UUID) error {
+	return r.increment(ctx, memeID, "click_count", 1)
+}
+
+func (r *metricsRepository) IncrementDownload(ctx context.Context, memeID uuid.UUID) error {
+	return r.increment(ctx, memeID, "download_count", 1)
+}
+
+func (r *metricsRepository) UpdateRating(ctx context.Context, memeID uuid.UUID, delta int) error {
+	return r.increment(ctx, memeID, "rating_score", delta)
+}
+
+// increment меняет счётчик одним UPDATE, чтобы параллельные запросы не теряли изменения.
+// Если метрик для мема ещё нет, возвращает gorm.ErrRecordNotFound
+func (r *metricsRepository) increment(ctx context.Context, memeID uuid.UUID, column string, delta int) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.MemeMetrics{}).
+		Where("meme_id = ?", memeID).
+		Updates(map[string]interface{}{
+			column:       gorm.Expr(column+" + ?", delta),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
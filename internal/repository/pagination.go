@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Cursor - последний показанный мем для keyset-пагинации. Score заполняется,
+// когда выдача отсортирована по релевантности поиска, иначе используется CreatedAt
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+	Score     *float64  `json:"s,omitempty"`
+}
+
+// Pagination - страница выдачи: либо After (keyset), либо Offset для старого API
+type Pagination struct {
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+func applyPagination(query *gorm.DB, page Pagination, search string) *gorm.DB {
+	switch {
+	case page.After != nil && search != "" && page.After.Score != nil:
+		query = query.Where("("+searchScoreSQL+", memes.id) < (?, ?)",
+			search, search, search, *page.After.Score, page.After.ID)
+	case page.After != nil:
+		query = query.Where("(memes.created_at, memes.id) < (?, ?)", page.After.CreatedAt, page.After.ID)
+	case page.Offset > 0:
+		query = query.Offset(page.Offset)
+	}
+
+	return query.Limit(page.Limit)
+}
@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"memology-backend/internal/repository"
+	"memology-backend/internal/repository/repotest"
+	"memology-backend/internal/testdb"
+
+	"gorm.io/gorm"
+)
+
+var (
+	db         *gorm.DB
+	skipReason string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	flag.Parse()
+	if testing.Short() {
+		skipReason = "postgres contract tests are skipped in -short mode"
+		return m.Run()
+	}
+
+	var stop func()
+	var err error
+	db, stop, err = testdb.Open()
+	if err != nil {
+		if testdb.Required() {
+			fmt.Fprintln(os.Stderr, "failed to open test database:", err)
+			return 1
+		}
+		skipReason = "no test database: " + err.Error()
+		return m.Run()
+	}
+	defer stop()
+
+	return m.Run()
+}
+
+func TestPostgresContract(t *testing.T) {
+	if db == nil {
+		t.Skip(skipReason)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		err := db.Exec("TRUNCATE users, user_sessions, memes, meme_tags, meme_metrics, tags, style_overrides CASCADE").Error
+		if err != nil {
+			t.Fatalf("failed to clean database: %v", err)
+		}
+		return repotest.Repositories{
+			Users:    repository.NewUserRepository(db),
+			Sessions: repository.NewSessionRepository(db),
+			Memes:    repository.NewMemeRepository(db),
+			Metrics:  repository.NewMetricsRepository(db),
+			Tags:     repository.NewTagRepository(db),
+		}
+	})
+}
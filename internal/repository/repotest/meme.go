@@ -0,0 +1,694 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func testMemeCreateAndGet(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+
+	meme := &models.Meme{UserID: user.ID, Prompt: "кот программист", IsPublic: false}
+	must(t, "Create", r.Memes.Create(ctx, meme))
+	if meme.ID == uuid.Nil || meme.CreatedAt.IsZero() || meme.UpdatedAt.IsZero() {
+		t.Fatalf("Create must assign id and timestamps, got %+v", meme)
+	}
+
+	got, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID", err)
+	if got.Prompt != meme.Prompt || got.UserID != user.ID {
+		t.Fatalf("GetByID returned %+v", got)
+	}
+	// Значения по умолчанию, при этом явный false в is_public не заменяется на true
+	if got.Kind != models.MemeKindAI || got.Status != "pending" || got.Width != 500 || got.Height != 500 || got.AspectRatio != "1:1" {
+		t.Fatalf("Create must apply defaults, got kind %q status %q %dx%d %q",
+			got.Kind, got.Status, got.Width, got.Height, got.AspectRatio)
+	}
+	if got.IsPublic {
+		t.Fatal("Create must keep is_public = false")
+	}
+	if got.User.Username != user.Username {
+		t.Fatalf("GetByID must preload the author, got %q", got.User.Username)
+	}
+	if got.Metrics != nil || len(got.Tags) != 0 {
+		t.Fatalf("new meme must have no metrics and tags, got %+v %v", got.Metrics, got.Tags)
+	}
+
+	// Изменение возвращённой копии не меняет хранилище
+	got.Prompt = "changed"
+	again, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID", err)
+	if again.Prompt != meme.Prompt {
+		t.Fatal("GetByID must return an independent copy")
+	}
+
+	if _, err := r.Memes.GetByID(ctx, uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID of unknown meme: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	if err := r.Memes.Create(ctx, &models.Meme{UserID: uuid.New(), Prompt: "сирота"}); err == nil {
+		t.Fatal("Create for unknown user must fail")
+	}
+
+	generation := &models.GenerationInfo{TaskID: "task-1", Style: "anime", Captions: []string{"верх", "низ"}}
+	withGeneration := createMeme(t, r, user.ID, func(m *models.Meme) { m.Generation = generation })
+	got, err = r.Memes.GetByID(ctx, withGeneration.ID)
+	must(t, "GetByID", err)
+	if got.Generation == nil || got.Generation.TaskID != "task-1" || len(got.Generation.Captions) != 2 {
+		t.Fatalf("generation was not stored, got %+v", got.Generation)
+	}
+}
+
+func testMemeUpdate(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	meme := createMeme(t, r, user.ID, func(m *models.Meme) { m.Status = "pending" })
+
+	stored, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID", err)
+	stored.Status = "completed"
+	stored.ImageURL = "http://storage/memes/1.jpg"
+	must(t, "UpdateGeneration", r.Memes.UpdateGeneration(ctx, stored))
+
+	got, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID after UpdateGeneration", err)
+	if got.Status != "completed" || got.ImageURL != stored.ImageURL {
+		t.Fatalf("UpdateGeneration did not persist fields, got status %q image %q", got.Status, got.ImageURL)
+	}
+	if !sameTime(got.CreatedAt, meme.CreatedAt) {
+		t.Fatalf("UpdateGeneration must keep created_at %v, got %v", meme.CreatedAt, got.CreatedAt)
+	}
+
+	// UpdateMetadata - оптимистичная блокировка по updated_at
+	unmodifiedSince := got.UpdatedAt
+	got.Title = "Понедельник"
+	got.IsPublic = false
+	must(t, "UpdateMetadata", r.Memes.UpdateMetadata(ctx, got, nil, unmodifiedSince))
+	if !got.UpdatedAt.After(unmodifiedSince) {
+		t.Fatalf("UpdateMetadata must advance updated_at, was %v, got %v", unmodifiedSince, got.UpdatedAt)
+	}
+
+	updated, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID after UpdateMetadata", err)
+	if updated.Title != "Понедельник" || updated.IsPublic {
+		t.Fatalf("UpdateMetadata did not persist fields, got title %q public %v", updated.Title, updated.IsPublic)
+	}
+	if !updated.UpdatedAt.Equal(got.UpdatedAt) {
+		t.Fatalf("UpdateMetadata must report stored updated_at %v, got %v", updated.UpdatedAt, got.UpdatedAt)
+	}
+
+	got.Title = "устаревшая правка"
+	if err := r.Memes.UpdateMetadata(ctx, got, nil, unmodifiedSince); !errors.Is(err, repository.ErrMemeModified) {
+		t.Fatalf("UpdateMetadata with stale updated_at: expected ErrMemeModified, got %v", err)
+	}
+	if err := r.Memes.UpdateMetadata(ctx, &models.Meme{ID: uuid.New()}, nil, unmodifiedSince); !errors.Is(err, repository.ErrMemeModified) {
+		t.Fatalf("UpdateMetadata of unknown meme: expected ErrMemeModified, got %v", err)
+	}
+
+	// UpdateGeneration из копии, прочитанной до правки, не откатывает правку и не меняет updated_at
+	stored.Status = "failed"
+	stored.Generation = &models.GenerationInfo{TaskID: "task-2", Model: "sdxl"}
+	stored.GenerationTimeMs = 1500
+	must(t, "UpdateGeneration", r.Memes.UpdateGeneration(ctx, stored))
+
+	generated, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID after UpdateGeneration", err)
+	if generated.Status != "failed" || generated.GenerationTimeMs != 1500 || generated.Generation == nil || generated.Generation.Model != "sdxl" {
+		t.Fatalf("UpdateGeneration did not persist generation fields, got status %q %dms %+v",
+			generated.Status, generated.GenerationTimeMs, generated.Generation)
+	}
+	if generated.Title != "Понедельник" || generated.IsPublic {
+		t.Fatalf("UpdateGeneration must keep metadata edits, got title %q public %v", generated.Title, generated.IsPublic)
+	}
+	if !generated.UpdatedAt.Equal(updated.UpdatedAt) {
+		t.Fatalf("UpdateGeneration must keep updated_at %v, got %v", updated.UpdatedAt, generated.UpdatedAt)
+	}
+	if generated.StatusUpdatedAt.Before(updated.UpdatedAt) {
+		t.Fatalf("UpdateGeneration must advance status_updated_at past %v, got %v", updated.UpdatedAt, generated.StatusUpdatedAt)
+	}
+	if err := r.Memes.UpdateGeneration(ctx, &models.Meme{ID: uuid.New(), Status: "completed"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("UpdateGeneration of unknown meme: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+}
+
+func testMemeSoftDelete(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	other := createUser(t, r)
+
+	first := createMeme(t, r, user.ID, func(m *models.Meme) { m.ImageURL = "http://storage/memes/first.jpg" })
+	second := createMeme(t, r, user.ID, func(m *models.Meme) { m.ImageURL = "http://storage/memes/second.jpg" })
+	kept := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.ImageURL = "http://storage/memes/kept.jpg"
+		m.ThumbnailURL = "http://storage/memes/kept_thumb.jpg"
+	})
+	foreign := createMeme(t, r, other.ID, nil)
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.Status = "failed" })
+
+	must(t, "Delete", r.Memes.Delete(ctx, first.ID))
+	time.Sleep(2 * time.Millisecond)
+	must(t, "Delete", r.Memes.Delete(ctx, second.ID))
+	must(t, "Delete", r.Memes.Delete(ctx, foreign.ID))
+	must(t, "Delete of unknown meme", r.Memes.Delete(ctx, uuid.New()))
+
+	if _, err := r.Memes.GetByID(ctx, first.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID of deleted meme: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	deleted, err := r.Memes.GetDeletedByID(ctx, first.ID)
+	must(t, "GetDeletedByID", err)
+	if deleted.ID != first.ID {
+		t.Fatalf("GetDeletedByID returned %s", deleted.ID)
+	}
+	if _, err := r.Memes.GetDeletedByID(ctx, kept.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetDeletedByID of live meme: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+
+	// Обновление генерации из копии, прочитанной до удаления, не восстанавливает мем из корзины
+	first.Status = "failed"
+	if err := r.Memes.UpdateGeneration(ctx, first); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("UpdateGeneration of deleted meme: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	if _, err := r.Memes.GetByID(ctx, first.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("UpdateGeneration must not restore deleted meme, GetByID returned %v", err)
+	}
+	if deleted, err := r.Memes.GetDeletedByID(ctx, first.ID); err != nil || deleted.Status == "failed" {
+		t.Fatalf("UpdateGeneration must not change deleted meme, got %+v, %v", deleted, err)
+	}
+
+	count, err := r.Memes.CountByUserID(ctx, user.ID, repository.MemeFilter{})
+	expectCount(t, "CountByUserID", count, err, 2)
+	memes, err := r.Memes.GetByUserID(ctx, user.ID, repository.MemeFilter{}, repository.Pagination{Limit: 10})
+	must(t, "GetByUserID", err)
+	if len(memes) != 2 {
+		t.Fatalf("GetByUserID must skip deleted memes, got %d", len(memes))
+	}
+
+	trash, err := r.Memes.GetDeletedByUserID(ctx, user.ID, 10, 0)
+	must(t, "GetDeletedByUserID", err)
+	expectIDs(t, "GetDeletedByUserID (newest first)", trash, second, first)
+	trash, err = r.Memes.GetDeletedByUserID(ctx, user.ID, 1, 1)
+	must(t, "GetDeletedByUserID with offset", err)
+	expectIDs(t, "GetDeletedByUserID with offset", trash, first)
+	count, err = r.Memes.CountDeletedByUserID(ctx, user.ID)
+	expectCount(t, "CountDeletedByUserID", count, err, 2)
+
+	purge, err := r.Memes.FindDeletedBefore(ctx, time.Now().Add(time.Minute), 2)
+	must(t, "FindDeletedBefore", err)
+	if len(purge) != 2 || purge[0].ID != first.ID {
+		t.Fatalf("FindDeletedBefore must return oldest deleted first, got %v", memeIDs(purge))
+	}
+	if purge, err := r.Memes.FindDeletedBefore(ctx, time.Now().Add(-time.Hour), 10); err != nil || len(purge) != 0 {
+		t.Fatalf("FindDeletedBefore in the past: expected nothing, got %v, %v", memeIDs(purge), err)
+	}
+
+	urls, err := r.Memes.ListImageURLs(ctx)
+	must(t, "ListImageURLs", err)
+	if !containsAll(urls, first.ImageURL, second.ImageURL, kept.ImageURL, kept.ThumbnailURL) || len(urls) != 4 {
+		t.Fatalf("ListImageURLs must include thumbnails and deleted memes and skip empty urls, got %v", urls)
+	}
+
+	must(t, "Restore", r.Memes.Restore(ctx, first.ID))
+	if _, err := r.Memes.GetByID(ctx, first.ID); err != nil {
+		t.Fatalf("GetByID after Restore: %v", err)
+	}
+	if _, err := r.Memes.GetDeletedByID(ctx, first.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetDeletedByID after Restore: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+
+	must(t, "HardDelete", r.Memes.HardDelete(ctx, second.ID))
+	if _, err := r.Memes.GetDeletedByID(ctx, second.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetDeletedByID after HardDelete: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	urls, err = r.Memes.ListImageURLs(ctx)
+	must(t, "ListImageURLs after HardDelete", err)
+	if containsAll(urls, second.ImageURL) {
+		t.Fatalf("ListImageURLs must not include hard-deleted memes, got %v", urls)
+	}
+}
+
+func testMemeContentHash(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	other := createUser(t, r)
+
+	original := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.ContentHash = "hash-a"
+		m.CreatedAt = baseTime()
+	})
+	createMeme(t, r, other.ID, func(m *models.Meme) { m.ContentHash = "hash-b" })
+	createMeme(t, r, user.ID, nil)
+	createMeme(t, r, user.ID, nil)
+
+	got, err := r.Memes.GetByContentHash(ctx, user.ID, "hash-a")
+	must(t, "GetByContentHash", err)
+	if got.ID != original.ID {
+		t.Fatalf("GetByContentHash must return meme %s, got %s", original.ID, got.ID)
+	}
+
+	// Живой мем с той же картинкой у пользователя может быть только один
+	duplicate := &models.Meme{UserID: user.ID, Prompt: "копия", Status: "completed", ContentHash: "hash-a"}
+	if err := r.Memes.Create(ctx, duplicate); !errors.Is(err, repository.ErrDuplicateContent) {
+		t.Fatalf("Create with duplicate content hash: expected ErrDuplicateContent, got %v", err)
+	}
+	if _, err := r.Memes.GetByID(ctx, duplicate.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("rejected duplicate must not be stored, got %v", err)
+	}
+	createMeme(t, r, other.ID, func(m *models.Meme) { m.ContentHash = "hash-a" })
+
+	// После удаления в корзину картинку можно загрузить снова; восстановленный мем
+	// теряет хэш, чтобы не нарушить уникальность
+	must(t, "Delete", r.Memes.Delete(ctx, original.ID))
+	reuploaded := createMeme(t, r, user.ID, func(m *models.Meme) { m.ContentHash = "hash-a" })
+	must(t, "Restore", r.Memes.Restore(ctx, original.ID))
+	restored, err := r.Memes.GetByID(ctx, original.ID)
+	must(t, "GetByID after Restore", err)
+	if restored.ContentHash != "" {
+		t.Fatalf("restored duplicate must lose its content hash, got %q", restored.ContentHash)
+	}
+	got, err = r.Memes.GetByContentHash(ctx, user.ID, "hash-a")
+	must(t, "GetByContentHash after Restore", err)
+	if got.ID != reuploaded.ID {
+		t.Fatalf("GetByContentHash must return re-uploaded meme %s, got %s", reuploaded.ID, got.ID)
+	}
+	if _, err := r.Memes.GetByContentHash(ctx, user.ID, "hash-b"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByContentHash of another user's meme: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	if _, err := r.Memes.GetByContentHash(ctx, user.ID, ""); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByContentHash of empty hash: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+
+	single := createMeme(t, r, other.ID, func(m *models.Meme) { m.ContentHash = "hash-c" })
+	must(t, "Delete", r.Memes.Delete(ctx, single.ID))
+	if _, err := r.Memes.GetByContentHash(ctx, other.ID, "hash-c"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByContentHash must skip deleted memes, got %v", err)
+	}
+}
+
+func testMemeVisibility(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	other := createUser(t, r)
+
+	public := createMeme(t, r, user.ID, nil)
+	private := createMeme(t, r, user.ID, func(m *models.Meme) { m.IsPublic = false })
+	foreign := createMeme(t, r, other.ID, nil)
+	page := repository.Pagination{Limit: 10}
+
+	memes, err := r.Memes.GetPublicMemes(ctx, repository.MemeFilter{}, page)
+	must(t, "GetPublicMemes", err)
+	expectSet(t, "GetPublicMemes", memes, public, foreign)
+	for _, meme := range memes {
+		if meme.User.ID != meme.UserID {
+			t.Fatalf("GetPublicMemes must preload authors, meme %s has %s", meme.ID, meme.User.ID)
+		}
+	}
+	count, err := r.Memes.CountPublicMemes(ctx, repository.MemeFilter{})
+	expectCount(t, "CountPublicMemes", count, err, 2)
+
+	memes, err = r.Memes.GetByUserID(ctx, user.ID, repository.MemeFilter{}, page)
+	must(t, "GetByUserID", err)
+	expectSet(t, "GetByUserID", memes, public, private)
+
+	memes, err = r.Memes.List(ctx, repository.MemeFilter{}, page)
+	must(t, "List", err)
+	expectSet(t, "List", memes, public, private, foreign)
+	count, err = r.Memes.Count(ctx, repository.MemeFilter{})
+	expectCount(t, "Count", count, err, 3)
+}
+
+func testMemeOrderingAndPagination(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	base := baseTime()
+
+	// Один created_at у двух мемов: порядок решает id
+	memes := make([]*models.Meme, 0, 5)
+	for i, offset := range []int{0, 1, 2, 2, 3} {
+		memes = append(memes, createMeme(t, r, user.ID, func(m *models.Meme) {
+			m.CreatedAt = base.Add(time.Duration(offset) * time.Minute)
+			m.Prompt = "мем " + string(rune('a'+i))
+		}))
+	}
+	tieHigh, tieLow := memes[2], memes[3]
+	if strings.Compare(tieHigh.ID.String(), tieLow.ID.String()) < 0 {
+		tieHigh, tieLow = tieLow, tieHigh
+	}
+	want := []*models.Meme{memes[4], tieHigh, tieLow, memes[1], memes[0]}
+
+	all, err := r.Memes.List(ctx, repository.MemeFilter{}, repository.Pagination{Limit: 10})
+	must(t, "List", err)
+	expectIDs(t, "List (created_at DESC, id DESC)", all, want...)
+
+	paged, err := r.Memes.List(ctx, repository.MemeFilter{}, repository.Pagination{Limit: 2, Offset: 2})
+	must(t, "List with offset", err)
+	expectIDs(t, "List with offset", paged, want[2:4]...)
+
+	// Keyset: страницы по курсору последнего мема проходят всю выдачу без повторов
+	var collected []*models.Meme
+	var cursor *repository.Cursor
+	for {
+		page, err := r.Memes.GetByUserID(ctx, user.ID, repository.MemeFilter{}, repository.Pagination{Limit: 2, After: cursor})
+		must(t, "GetByUserID with cursor", err)
+		if len(page) == 0 {
+			break
+		}
+		collected = append(collected, page...)
+		last := page[len(page)-1]
+		cursor = &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	expectIDs(t, "keyset pagination", collected, want...)
+
+	// Курсор отменяет offset
+	paged, err = r.Memes.List(ctx, repository.MemeFilter{}, repository.Pagination{
+		Limit:  10,
+		Offset: 3,
+		After:  &repository.Cursor{CreatedAt: want[0].CreatedAt, ID: want[0].ID},
+	})
+	must(t, "List with cursor and offset", err)
+	expectIDs(t, "List with cursor and offset", paged, want[1:]...)
+
+	if empty, err := r.Memes.List(ctx, repository.MemeFilter{}, repository.Pagination{Limit: 0}); err != nil || len(empty) != 0 {
+		t.Fatalf("List with limit 0: expected nothing, got %d, %v", len(empty), err)
+	}
+}
+
+func testMemeFilters(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	author := createUser(t, r)
+	deletedAuthor := createUser(t, r)
+	base := baseTime()
+
+	anime := createMeme(t, r, author.ID, func(m *models.Meme) {
+		m.Style = "anime"
+		m.CreatedAt = base
+	})
+	template := createMeme(t, r, author.ID, func(m *models.Meme) {
+		m.Kind = models.MemeKindTemplate
+		m.AspectRatio = "16:9"
+		m.CreatedAt = base.Add(time.Hour)
+	})
+	failed := createMeme(t, r, deletedAuthor.ID, func(m *models.Meme) {
+		m.Status = "failed"
+		m.CreatedAt = base.Add(2 * time.Hour)
+	})
+	must(t, "Delete user", r.Users.Delete(ctx, deletedAuthor.ID))
+
+	from, to := base.Add(time.Hour), base.Add(2*time.Hour)
+	tests := []struct {
+		name   string
+		filter repository.MemeFilter
+		want   []*models.Meme
+	}{
+		{"style", repository.MemeFilter{Style: "anime"}, []*models.Meme{anime}},
+		{"status", repository.MemeFilter{Status: "failed"}, []*models.Meme{failed}},
+		{"kind", repository.MemeFilter{Kind: models.MemeKindTemplate}, []*models.Meme{template}},
+		{"aspect ratio", repository.MemeFilter{AspectRatio: "16:9"}, []*models.Meme{template}},
+		{"created range is half-open", repository.MemeFilter{CreatedFrom: &from, CreatedTo: &to}, []*models.Meme{template}},
+		{"created from", repository.MemeFilter{CreatedFrom: &from}, []*models.Meme{failed, template}},
+		{"author", repository.MemeFilter{AuthorUsername: author.Username}, []*models.Meme{template, anime}},
+		{"deleted author", repository.MemeFilter{AuthorUsername: deletedAuthor.Username}, nil},
+		{"combined", repository.MemeFilter{AuthorUsername: author.Username, Style: "anime"}, []*models.Meme{anime}},
+	}
+
+	for _, tt := range tests {
+		memes, err := r.Memes.List(ctx, tt.filter, repository.Pagination{Limit: 10})
+		must(t, tt.name, err)
+		expectIDs(t, tt.name, memes, tt.want...)
+
+		count, err := r.Memes.Count(ctx, tt.filter)
+		expectCount(t, tt.name+" count", count, err, int64(len(tt.want)))
+	}
+}
+
+func testMemeTags(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+
+	tags, err := r.Tags.GetOrCreate(ctx, []models.Tag{
+		{Name: "Коты", Slug: "koty"},
+		{Name: "Работа", Slug: "rabota"},
+		{Name: "Кофе", Slug: "kofe"},
+	})
+	must(t, "GetOrCreate", err)
+	koty, rabota, kofe := tags[0], tags[1], tags[2]
+
+	both := createMeme(t, r, user.ID, func(m *models.Meme) { m.Tags = []models.Tag{koty, rabota} })
+	onlyCats := createMeme(t, r, user.ID, func(m *models.Meme) { m.Tags = []models.Tag{koty} })
+	createMeme(t, r, user.ID, nil)
+
+	got, err := r.Memes.GetByID(ctx, both.ID)
+	must(t, "GetByID", err)
+	if len(got.Tags) != 2 {
+		t.Fatalf("GetByID must preload tags, got %v", got.Tags)
+	}
+
+	page := repository.Pagination{Limit: 10}
+	memes, err := r.Memes.List(ctx, repository.MemeFilter{Tags: repository.TagFilter{Slugs: []string{"koty", "rabota"}}}, page)
+	must(t, "List by any tag", err)
+	expectSet(t, "List by any tag", memes, both, onlyCats)
+
+	memes, err = r.Memes.List(ctx, repository.MemeFilter{Tags: repository.TagFilter{Slugs: []string{"koty", "rabota"}, MatchAll: true}}, page)
+	must(t, "List by all tags", err)
+	expectSet(t, "List by all tags", memes, both)
+
+	count, err := r.Memes.Count(ctx, repository.MemeFilter{Tags: repository.TagFilter{Slugs: []string{"kofe"}}})
+	expectCount(t, "Count by unused tag", count, err, 0)
+
+	// UpdateMetadata заменяет теги вместе с остальными полями
+	cats, err := r.Memes.GetByID(ctx, onlyCats.ID)
+	must(t, "GetByID", err)
+	unmodifiedSince := cats.UpdatedAt
+	must(t, "UpdateMetadata with tags", r.Memes.UpdateMetadata(ctx, cats, []models.Tag{kofe}, unmodifiedSince))
+	if len(cats.Tags) != 1 || cats.Tags[0].Slug != "kofe" {
+		t.Fatalf("UpdateMetadata must update meme.Tags, got %v", cats.Tags)
+	}
+	got, err = r.Memes.GetByID(ctx, onlyCats.ID)
+	must(t, "GetByID after UpdateMetadata", err)
+	if len(got.Tags) != 1 || got.Tags[0].Slug != "kofe" {
+		t.Fatalf("UpdateMetadata must replace stored tags, got %v", got.Tags)
+	}
+
+	// Устаревшая правка не меняет ни поля, ни теги
+	if err := r.Memes.UpdateMetadata(ctx, got, []models.Tag{rabota}, unmodifiedSince); !errors.Is(err, repository.ErrMemeModified) {
+		t.Fatalf("UpdateMetadata with stale updated_at: expected ErrMemeModified, got %v", err)
+	}
+	got, err = r.Memes.GetByID(ctx, onlyCats.ID)
+	must(t, "GetByID after stale UpdateMetadata", err)
+	if len(got.Tags) != 1 || got.Tags[0].Slug != "kofe" {
+		t.Fatalf("stale UpdateMetadata must keep stored tags, got %v", got.Tags)
+	}
+
+	// nil оставляет теги как есть
+	must(t, "UpdateMetadata without tags", r.Memes.UpdateMetadata(ctx, got, nil, got.UpdatedAt))
+	got, err = r.Memes.GetByID(ctx, onlyCats.ID)
+	must(t, "GetByID after UpdateMetadata", err)
+	if len(got.Tags) != 1 {
+		t.Fatalf("UpdateMetadata with nil tags must keep tags, got %v", got.Tags)
+	}
+
+	got, err = r.Memes.GetByID(ctx, both.ID)
+	must(t, "GetByID", err)
+	must(t, "UpdateMetadata with no tags", r.Memes.UpdateMetadata(ctx, got, []models.Tag{}, got.UpdatedAt))
+	memes, err = r.Memes.List(ctx, repository.MemeFilter{Tags: repository.TagFilter{Slugs: []string{"koty"}}}, page)
+	must(t, "List after clearing tags", err)
+	expectSet(t, "List after clearing tags", memes)
+}
+
+func testMemeSearch(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+
+	programmer := createMeme(t, r, user.ID, func(m *models.Meme) { m.Prompt = "кот программист пишет код" })
+	titled := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Prompt = "утро понедельника"
+		m.Title = "программист"
+	})
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.Prompt = "собака гуляет в парке" })
+	deleted := createMeme(t, r, user.ID, func(m *models.Meme) { m.Prompt = "программист в отпуске" })
+	must(t, "Delete", r.Memes.Delete(ctx, deleted.ID))
+
+	page := repository.Pagination{Limit: 10}
+	memes, err := r.Memes.List(ctx, repository.MemeFilter{Search: "программист"}, page)
+	must(t, "search", err)
+	expectSet(t, "search by prompt and title", memes, programmer, titled)
+	for _, meme := range memes {
+		if meme.SearchRank <= 0 {
+			t.Fatalf("search must fill search rank, meme %s has %v", meme.ID, meme.SearchRank)
+		}
+		if meme.ID == programmer.ID && !strings.Contains(meme.SearchHighlight, "<mark>") {
+			t.Fatalf("search must highlight matches in the prompt, got %q", meme.SearchHighlight)
+		}
+	}
+
+	count, err := r.Memes.Count(ctx, repository.MemeFilter{Search: "программист"})
+	expectCount(t, "search count", count, err, 2)
+
+	memes, err = r.Memes.List(ctx, repository.MemeFilter{Search: "кот код"}, page)
+	must(t, "search with several words", err)
+	expectSet(t, "search with several words", memes, programmer)
+
+	memes, err = r.Memes.List(ctx, repository.MemeFilter{Search: "бегемот"}, page)
+	must(t, "search without matches", err)
+	expectSet(t, "search without matches", memes)
+
+	// Курсор по релевантности проходит всю выдачу без повторов
+	var collected []*models.Meme
+	var cursor *repository.Cursor
+	for {
+		memes, err := r.Memes.List(ctx, repository.MemeFilter{Search: "программист"}, repository.Pagination{Limit: 1, After: cursor})
+		must(t, "search with cursor", err)
+		if len(memes) == 0 {
+			break
+		}
+		if len(collected) > 2 {
+			t.Fatalf("search pagination does not terminate, got %v", memeIDs(collected))
+		}
+		collected = append(collected, memes...)
+		last := memes[0]
+		score := last.SearchRank
+		cursor = &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Score: &score}
+	}
+	expectSet(t, "search pagination", collected, programmer, titled)
+}
+
+func testMemeStuck(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	old := time.Now().Add(-2 * time.Hour).Truncate(time.Microsecond)
+
+	oldest := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Status = "pending"
+		m.StatusUpdatedAt = old.Add(-time.Hour)
+	})
+	failed := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Status = "failed"
+		m.StatusUpdatedAt = old
+	})
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.StatusUpdatedAt = old })
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.Status = "processing" })
+	// Давно не редактированный мем, задачу которого ещё опрашивают, не завис
+	createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Status = "processing"
+		m.UpdatedAt = old
+	})
+	polled := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Status = "processing"
+		m.StatusUpdatedAt = old
+	})
+	must(t, "UpdateGeneration", r.Memes.UpdateGeneration(ctx, polled))
+	deleted := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Status = "processing"
+		m.StatusUpdatedAt = old
+	})
+	must(t, "Delete", r.Memes.Delete(ctx, deleted.ID))
+
+	stuck, err := r.Memes.FindStuckMemes(ctx, time.Hour)
+	must(t, "FindStuckMemes", err)
+	expectIDs(t, "FindStuckMemes (oldest first)", stuck, oldest, failed)
+}
+
+func testMemeCountCreatedSince(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	other := createUser(t, r)
+	base := baseTime()
+
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.CreatedAt = base.Add(-time.Minute) })
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.CreatedAt = base })
+	deleted := createMeme(t, r, user.ID, func(m *models.Meme) { m.CreatedAt = base.Add(time.Hour) })
+	must(t, "Delete", r.Memes.Delete(ctx, deleted.ID))
+	createMeme(t, r, other.ID, func(m *models.Meme) { m.CreatedAt = base.Add(time.Hour) })
+
+	// Мемы в корзине тоже считаются: удаление не возвращает квоту
+	count, err := r.Memes.CountCreatedSince(ctx, user.ID, base)
+	expectCount(t, "CountCreatedSince", count, err, 2)
+	count, err = r.Memes.CountCreatedSince(ctx, user.ID, base.Add(2*time.Hour))
+	expectCount(t, "CountCreatedSince in the future", count, err, 0)
+}
+
+func testMemeGenerationStats(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	base := baseTime()
+
+	for _, ms := range []int{100, 200, 300, 400} {
+		wait := int64(ms / 10)
+		createMeme(t, r, user.ID, func(m *models.Meme) {
+			m.Style = "anime"
+			m.GenerationTimeMs = ms
+			m.Generation = &models.GenerationInfo{Style: "anime", QueueWaitMs: &wait}
+			m.CreatedAt = base
+		})
+	}
+	// Нулевое ожидание в очереди тоже входит в среднее
+	noWait := int64(0)
+	failed := createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Style = "anime"
+		m.Status = "failed"
+		m.Generation = &models.GenerationInfo{Style: "anime", QueueWaitMs: &noWait}
+		m.CreatedAt = base
+	})
+	must(t, "Delete", r.Memes.Delete(ctx, failed.ID))
+	createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Kind = models.MemeKindTemplate
+		m.CreatedAt = base.Add(time.Minute)
+	})
+	createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Style = "anime"
+		m.CreatedAt = base.Add(time.Hour)
+	})
+
+	stats, err := r.Memes.GetGenerationStats(ctx, base, base.Add(time.Hour))
+	must(t, "GetGenerationStats", err)
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(stats))
+	}
+
+	ai := stats[0]
+	if ai.Kind != models.MemeKindAI || ai.Style != "anime" || ai.Total != 5 || ai.Completed != 4 || ai.Failed != 1 {
+		t.Fatalf("unexpected ai stats: %+v", ai)
+	}
+	expectFloat(t, "p50", ai.P50Ms, 250)
+	expectFloat(t, "p95", ai.P95Ms, 385)
+	expectFloat(t, "avg queue wait", ai.AvgQueueWaitMs, 20)
+
+	template := stats[1]
+	if template.Kind != models.MemeKindTemplate || template.Total != 1 || template.Completed != 1 {
+		t.Fatalf("unexpected template stats: %+v", template)
+	}
+	if template.P50Ms != nil || template.AvgQueueWaitMs != nil {
+		t.Fatalf("template without durations must have no percentiles, got %+v", template)
+	}
+}
+
+func expectFloat(t *testing.T, what string, got *float64, want float64) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("%s: expected %v, got nil", what, want)
+	}
+	if diff := *got - want; diff > 0.001 || diff < -0.001 {
+		t.Fatalf("%s: expected %v, got %v", what, want, *got)
+	}
+}
+
+func containsAll(values []string, wanted ...string) bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	for _, w := range wanted {
+		if !set[w] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"memology-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func testMetrics(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	meme := createMeme(t, r, user.ID, nil)
+
+	if _, err := r.Metrics.GetByMemeID(ctx, meme.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByMemeID without metrics: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	if err := r.Metrics.IncrementClick(ctx, meme.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("IncrementClick without metrics: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+
+	metrics := &models.MemeMetrics{MemeID: meme.ID}
+	must(t, "Create", r.Metrics.Create(ctx, metrics))
+	if metrics.ID == uuid.Nil {
+		t.Fatal("Create must assign metrics id")
+	}
+	if err := r.Metrics.Create(ctx, &models.MemeMetrics{MemeID: meme.ID}); err == nil {
+		t.Fatal("second Create for the same meme must fail")
+	}
+	if err := r.Metrics.Create(ctx, &models.MemeMetrics{MemeID: uuid.New()}); err == nil {
+		t.Fatal("Create for unknown meme must fail")
+	}
+
+	must(t, "IncrementClick", r.Metrics.IncrementClick(ctx, meme.ID))
+	must(t, "IncrementClick", r.Metrics.IncrementClick(ctx, meme.ID))
+	must(t, "IncrementDownload", r.Metrics.IncrementDownload(ctx, meme.ID))
+	must(t, "UpdateRating", r.Metrics.UpdateRating(ctx, meme.ID, 5))
+	must(t, "UpdateRating", r.Metrics.UpdateRating(ctx, meme.ID, -2))
+
+	got, err := r.Metrics.GetByMemeID(ctx, meme.ID)
+	must(t, "GetByMemeID", err)
+	if got.ClickCount != 2 || got.DownloadCount != 1 || got.RatingScore != 3 {
+		t.Fatalf("unexpected counters: clicks %d, downloads %d, rating %d", got.ClickCount, got.DownloadCount, got.RatingScore)
+	}
+
+	got.OtherInteractions = 7
+	must(t, "Update", r.Metrics.Update(ctx, got))
+	got, err = r.Metrics.GetByMemeID(ctx, meme.ID)
+	must(t, "GetByMemeID after Update", err)
+	if got.OtherInteractions != 7 || got.ClickCount != 2 {
+		t.Fatalf("Update did not persist metrics, got %+v", got)
+	}
+
+	loaded, err := r.Memes.GetByID(ctx, meme.ID)
+	must(t, "GetByID", err)
+	if loaded.Metrics == nil || loaded.Metrics.ClickCount != 2 {
+		t.Fatalf("GetByID must preload metrics, got %+v", loaded.Metrics)
+	}
+
+	// Метрики удаляются вместе с мемом
+	must(t, "HardDelete", r.Memes.HardDelete(ctx, meme.ID))
+	if _, err := r.Metrics.GetByMemeID(ctx, meme.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByMemeID after HardDelete: expected gorm.ErrRecordNotFound, got %v", err)
+	}
+}
+
+func testTags(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+
+	if tags, err := r.Tags.GetOrCreate(ctx, nil); err != nil || len(tags) != 0 {
+		t.Fatalf("GetOrCreate with no tags: expected empty result, got %v, %v", tags, err)
+	}
+
+	first, err := r.Tags.GetOrCreate(ctx, []models.Tag{{Name: "Коты", Slug: "koty"}, {Name: "Кофе", Slug: "kofe"}})
+	must(t, "GetOrCreate", err)
+	if len(first) != 2 || first[0].Slug != "koty" || first[1].Slug != "kofe" || first[0].ID == uuid.Nil {
+		t.Fatalf("GetOrCreate must return created tags in input order, got %v", first)
+	}
+
+	second, err := r.Tags.GetOrCreate(ctx, []models.Tag{{Name: "Работа", Slug: "rabota"}, {Name: "КОТЫ", Slug: "koty"}})
+	must(t, "GetOrCreate", err)
+	if len(second) != 2 || second[0].Slug != "rabota" || second[1].ID != first[0].ID || second[1].Name != "Коты" {
+		t.Fatalf("GetOrCreate must reuse existing tags, got %v", second)
+	}
+	koty, kofe, rabota := first[0], first[1], second[0]
+
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.Tags = []models.Tag{koty, kofe} })
+	createMeme(t, r, user.ID, func(m *models.Meme) { m.Tags = []models.Tag{koty, rabota} })
+	createMeme(t, r, user.ID, func(m *models.Meme) {
+		m.Tags = []models.Tag{rabota}
+		m.IsPublic = false
+	})
+	deleted := createMeme(t, r, user.ID, func(m *models.Meme) { m.Tags = []models.Tag{rabota} })
+	must(t, "Delete", r.Memes.Delete(ctx, deleted.ID))
+
+	popular, err := r.Tags.GetPopular(ctx, 10)
+	must(t, "GetPopular", err)
+	want := []struct {
+		slug  string
+		count int64
+	}{{"koty", 2}, {"kofe", 1}, {"rabota", 1}}
+	if len(popular) != len(want) {
+		t.Fatalf("GetPopular: expected %d tags, got %d", len(want), len(popular))
+	}
+	for i, w := range want {
+		if popular[i].Slug != w.slug || popular[i].MemeCount != w.count {
+			t.Fatalf("GetPopular[%d]: expected %s=%d, got %s=%d", i, w.slug, w.count, popular[i].Slug, popular[i].MemeCount)
+		}
+	}
+
+	if popular, err := r.Tags.GetPopular(ctx, 1); err != nil || len(popular) != 1 {
+		t.Fatalf("GetPopular with limit: expected 1 tag, got %d, %v", len(popular), err)
+	}
+}
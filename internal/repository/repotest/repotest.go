@@ -0,0 +1,164 @@
+// Package repotest - контрактные тесты интерфейсов repository. Один и тот же набор
+// проверок запускается для GORM-реализаций на PostgreSQL и для in-memory реализаций,
+// чтобы тесты сервисов на memory-репозиториях проверяли то же поведение, что и в проде
+package repotest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Repositories - проверяемые реализации; все должны работать над одним хранилищем
+type Repositories struct {
+	Users    repository.UserRepository
+	Sessions repository.SessionRepository
+	Memes    repository.MemeRepository
+	Metrics  repository.MetricsRepository
+	Tags     repository.TagRepository
+}
+
+// Run запускает контрактные тесты. newRepos вызывается в каждом подтесте и должен
+// возвращать репозитории над пустым хранилищем
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, r Repositories)
+	}{
+		{"Users", testUsers},
+		{"Sessions", testSessions},
+		{"MemeCreateAndGet", testMemeCreateAndGet},
+		{"MemeUpdate", testMemeUpdate},
+		{"MemeSoftDelete", testMemeSoftDelete},
+		{"MemeContentHash", testMemeContentHash},
+		{"MemeVisibility", testMemeVisibility},
+		{"MemeOrderingAndPagination", testMemeOrderingAndPagination},
+		{"MemeFilters", testMemeFilters},
+		{"MemeTags", testMemeTags},
+		{"MemeSearch", testMemeSearch},
+		{"MemeStuck", testMemeStuck},
+		{"MemeCountCreatedSince", testMemeCountCreatedSince},
+		{"MemeGenerationStats", testMemeGenerationStats},
+		{"Metrics", testMetrics},
+		{"Tags", testTags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepos(t))
+		})
+	}
+}
+
+// baseTime - момент в прошлом, от которого тесты задают created_at, с точностью PostgreSQL
+func baseTime() time.Time {
+	return time.Now().Add(-24 * time.Hour).Truncate(time.Microsecond)
+}
+
+// sameTime сравнивает время с точностью PostgreSQL: GORM заполняет created_at
+// до наносекунд, а в базе хранятся микросекунды
+func sameTime(a, b time.Time) bool {
+	return a.Sub(b).Abs() <= time.Microsecond
+}
+
+func randomName(prefix string) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return prefix + "_" + hex.EncodeToString(suffix)
+}
+
+func createUser(t *testing.T, r Repositories) *models.User {
+	t.Helper()
+	name := randomName("user")
+	user := &models.User{
+		Username:     name,
+		Email:        name + "@example.com",
+		PasswordHash: "hash",
+		IsActive:     true,
+	}
+	if err := r.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// createMeme создаёт публичный завершённый мем; configure может изменить поля до записи
+func createMeme(t *testing.T, r Repositories, userID uuid.UUID, configure func(*models.Meme)) *models.Meme {
+	t.Helper()
+	meme := &models.Meme{
+		UserID:   userID,
+		Prompt:   "мем " + randomName("prompt"),
+		Status:   "completed",
+		IsPublic: true,
+	}
+	if configure != nil {
+		configure(meme)
+	}
+	if err := r.Memes.Create(context.Background(), meme); err != nil {
+		t.Fatalf("failed to create meme: %v", err)
+	}
+	return meme
+}
+
+func memeIDs(memes []*models.Meme) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(memes))
+	for _, meme := range memes {
+		ids = append(ids, meme.ID)
+	}
+	return ids
+}
+
+// expectIDs сравнивает выдачу с ожидаемыми мемами с учётом порядка
+func expectIDs(t *testing.T, what string, memes []*models.Meme, want ...*models.Meme) {
+	t.Helper()
+	got := memeIDs(memes)
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %d memes, got %d: %v", what, len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i].ID {
+			t.Fatalf("%s: expected %v, got %v", what, memeIDs(want), got)
+		}
+	}
+}
+
+// expectSet сравнивает выдачу с ожидаемыми мемами без учёта порядка
+func expectSet(t *testing.T, what string, memes []*models.Meme, want ...*models.Meme) {
+	t.Helper()
+	got := make(map[uuid.UUID]bool, len(memes))
+	for _, meme := range memes {
+		got[meme.ID] = true
+	}
+	if len(got) != len(want) || len(memes) != len(want) {
+		t.Fatalf("%s: expected %v, got %v", what, memeIDs(want), memeIDs(memes))
+	}
+	for _, meme := range want {
+		if !got[meme.ID] {
+			t.Fatalf("%s: expected %v, got %v", what, memeIDs(want), memeIDs(memes))
+		}
+	}
+}
+
+func expectCount(t *testing.T, what string, count int64, err error, want int64) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if count != want {
+		t.Fatalf("%s: expected %d, got %d", what, want, count)
+	}
+}
+
+func must(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
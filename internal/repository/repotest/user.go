@@ -0,0 +1,162 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"memology-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func testUsers(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+
+	if user.ID == uuid.Nil || user.CreatedAt.IsZero() {
+		t.Fatalf("Create must assign id and created_at, got %+v", user)
+	}
+
+	for what, get := range map[string]func() (*models.User, error){
+		"GetByID":       func() (*models.User, error) { return r.Users.GetByID(ctx, user.ID) },
+		"GetByUsername": func() (*models.User, error) { return r.Users.GetByUsername(ctx, user.Username) },
+		"GetByEmail":    func() (*models.User, error) { return r.Users.GetByEmail(ctx, user.Email) },
+	} {
+		got, err := get()
+		must(t, what, err)
+		if got == nil || got.ID != user.ID || got.Username != user.Username {
+			t.Fatalf("%s: expected user %s, got %+v", what, user.ID, got)
+		}
+	}
+
+	// Отсутствующий пользователь - nil без ошибки
+	if got, err := r.Users.GetByID(ctx, uuid.New()); err != nil || got != nil {
+		t.Fatalf("GetByID of unknown user: expected nil, nil, got %+v, %v", got, err)
+	}
+	if got, err := r.Users.GetByUsername(ctx, "nobody"); err != nil || got != nil {
+		t.Fatalf("GetByUsername of unknown user: expected nil, nil, got %+v, %v", got, err)
+	}
+
+	if err := r.Users.Create(ctx, &models.User{Username: user.Username, Email: "other-" + user.Email, PasswordHash: "hash"}); err == nil {
+		t.Fatal("Create with duplicate username must fail")
+	}
+	if err := r.Users.Create(ctx, &models.User{Username: randomName("user"), Email: user.Email, PasswordHash: "hash"}); err == nil {
+		t.Fatal("Create with duplicate email must fail")
+	}
+
+	user.AvatarURL = "http://example.com/avatar.png"
+	must(t, "Update", r.Users.Update(ctx, user))
+	got, err := r.Users.GetByID(ctx, user.ID)
+	must(t, "GetByID after Update", err)
+	if got.AvatarURL != user.AvatarURL {
+		t.Fatalf("Update did not persist avatar_url, got %q", got.AvatarURL)
+	}
+	if !sameTime(got.CreatedAt, user.CreatedAt) {
+		t.Fatalf("Update must keep created_at %v, got %v", user.CreatedAt, got.CreatedAt)
+	}
+
+	other := createUser(t, r)
+	third := createUser(t, r)
+
+	users, err := r.Users.List(ctx, 10, 0)
+	must(t, "List", err)
+	if len(users) != 3 {
+		t.Fatalf("List: expected 3 users, got %d", len(users))
+	}
+	if users, err := r.Users.List(ctx, 2, 2); err != nil || len(users) != 1 {
+		t.Fatalf("List with offset: expected 1 user, got %d, %v", len(users), err)
+	}
+
+	// Мягкое удаление: пользователь пропадает из выборок, но имя остаётся занятым
+	must(t, "Delete", r.Users.Delete(ctx, other.ID))
+	if got, err := r.Users.GetByID(ctx, other.ID); err != nil || got != nil {
+		t.Fatalf("GetByID of deleted user: expected nil, nil, got %+v, %v", got, err)
+	}
+	if got, err := r.Users.GetByUsername(ctx, other.Username); err != nil || got != nil {
+		t.Fatalf("GetByUsername of deleted user: expected nil, nil, got %+v, %v", got, err)
+	}
+	users, err = r.Users.List(ctx, 10, 0)
+	must(t, "List after Delete", err)
+	if len(users) != 2 {
+		t.Fatalf("List after Delete: expected 2 users, got %d", len(users))
+	}
+	if err := r.Users.Create(ctx, &models.User{Username: other.Username, Email: randomName("mail") + "@example.com", PasswordHash: "hash"}); err == nil {
+		t.Fatal("username of a deleted user must stay taken")
+	}
+
+	must(t, "Delete of unknown user", r.Users.Delete(ctx, uuid.New()))
+	if got, err := r.Users.GetByID(ctx, third.ID); err != nil || got == nil {
+		t.Fatalf("other users must not be affected, got %+v, %v", got, err)
+	}
+}
+
+func testSessions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := createUser(t, r)
+	other := createUser(t, r)
+
+	newSession := func(userID uuid.UUID, expiresAt time.Time) *models.UserSession {
+		t.Helper()
+		session := &models.UserSession{UserID: userID, TokenHash: randomName("hash"), ExpiresAt: expiresAt}
+		must(t, "Create session", r.Sessions.Create(ctx, session))
+		if session.ID == uuid.Nil {
+			t.Fatal("Create must assign session id")
+		}
+		return session
+	}
+
+	active := newSession(user.ID, time.Now().Add(time.Hour))
+	expired := newSession(user.ID, time.Now().Add(-time.Hour))
+	foreign := newSession(other.ID, time.Now().Add(time.Hour))
+
+	if err := r.Sessions.Create(ctx, &models.UserSession{UserID: uuid.New(), TokenHash: "x", ExpiresAt: time.Now()}); err == nil {
+		t.Fatal("Create session for unknown user must fail")
+	}
+
+	got, err := r.Sessions.GetByTokenHash(ctx, active.TokenHash)
+	must(t, "GetByTokenHash", err)
+	if got == nil || got.ID != active.ID || got.UserID != user.ID {
+		t.Fatalf("GetByTokenHash: expected session %s, got %+v", active.ID, got)
+	}
+	if got, err := r.Sessions.GetByTokenHash(ctx, "unknown"); err != nil || got != nil {
+		t.Fatalf("GetByTokenHash of unknown token: expected nil, nil, got %+v, %v", got, err)
+	}
+
+	sessions, err := r.Sessions.GetByUserID(ctx, user.ID)
+	must(t, "GetByUserID", err)
+	if len(sessions) != 2 {
+		t.Fatalf("GetByUserID: expected 2 sessions, got %d", len(sessions))
+	}
+
+	newExpiry := time.Now().Add(48 * time.Hour).Truncate(time.Microsecond)
+	active.ExpiresAt = newExpiry
+	must(t, "Update session", r.Sessions.Update(ctx, active))
+	got, err = r.Sessions.GetByTokenHash(ctx, active.TokenHash)
+	must(t, "GetByTokenHash after Update", err)
+	if !got.ExpiresAt.Equal(newExpiry) {
+		t.Fatalf("Update did not persist expires_at: expected %v, got %v", newExpiry, got.ExpiresAt)
+	}
+
+	must(t, "DeleteExpired", r.Sessions.DeleteExpired(ctx))
+	if got, _ := r.Sessions.GetByTokenHash(ctx, expired.TokenHash); got != nil {
+		t.Fatal("DeleteExpired must remove expired sessions")
+	}
+	if got, _ := r.Sessions.GetByTokenHash(ctx, active.TokenHash); got == nil {
+		t.Fatal("DeleteExpired must keep active sessions")
+	}
+
+	must(t, "Delete session", r.Sessions.Delete(ctx, active.ID))
+	if got, _ := r.Sessions.GetByTokenHash(ctx, active.TokenHash); got != nil {
+		t.Fatal("Delete must remove the session")
+	}
+
+	newSession(other.ID, time.Now().Add(time.Hour))
+	must(t, "DeleteByUserID", r.Sessions.DeleteByUserID(ctx, other.ID))
+	if sessions, err := r.Sessions.GetByUserID(ctx, other.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("DeleteByUserID: expected no sessions, got %d, %v", len(sessions), err)
+	}
+	if got, _ := r.Sessions.GetByTokenHash(ctx, foreign.TokenHash); got != nil {
+		t.Fatal("DeleteByUserID must remove all sessions of the user")
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"memology-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStyleOverrideNotFound - для стиля нет правки
+var ErrStyleOverrideNotFound = errors.New("style override not found")
+
+type styleOverrideRepository struct {
+	db *gorm.DB
+}
+
+func NewStyleOverrideRepository(db *gorm.DB) StyleOverrideRepository {
+	return &styleOverrideRepository{db: db}
+}
+
+func (r *styleOverrideRepository) List(ctx context.Context) ([]*models.StyleOverride, error) {
+	var overrides []*models.StyleOverride
+	err := r.db.WithContext(ctx).Order("name").Find(&overrides).Error
+	return overrides, err
+}
+
+func (r *styleOverrideRepository) Upsert(ctx context.Context, override *models.StyleOverride) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"hidden", "display_name", "description", "preview_url", "updated_at"}),
+		}).
+		Create(override).Error
+}
+
+func (r *styleOverrideRepository) Delete(ctx context.Context, name string) error {
+	result := r.db.WithContext(ctx).Where("name = ?", name).Delete(&models.StyleOverride{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStyleOverrideNotFound
+	}
+	return nil
+}
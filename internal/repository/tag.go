@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"memology-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// GetOrCreate создаёт недостающие теги и возвращает все теги в порядке входного списка
+func (r *tagRepository) GetOrCreate(ctx context.Context, tags []models.Tag) ([]models.Tag, error) {
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "slug"}}, DoNothing: true}).
+		Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		slugs = append(slugs, tag.Slug)
+	}
+
+	var existing []models.Tag
+	if err := r.db.WithContext(ctx).Where("slug IN ?", slugs).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	bySlug := make(map[string]models.Tag, len(existing))
+	for _, tag := range existing {
+		bySlug[tag.Slug] = tag
+	}
+
+	result := make([]models.Tag, 0, len(slugs))
+	for _, slug := range slugs {
+		if tag, ok := bySlug[slug]; ok {
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+func (r *tagRepository) GetPopular(ctx context.Context, limit int) ([]*TagUsage, error) {
+	var usages []*TagUsage
+	err := r.db.WithContext(ctx).
+		Table("tags").
+		Select("tags.name, tags.slug, COUNT(*) AS meme_count").
+		Joins("JOIN meme_tags ON meme_tags.tag_id = tags.id").
+		Joins("JOIN memes ON memes.id = meme_tags.meme_id").
+		Where("memes.is_public = ? AND memes.deleted_at IS NULL", true).
+		Group("tags.id, tags.name, tags.slug").
+		Order("meme_count DESC, tags.slug ASC").
+		Limit(limit).
+		Scan(&usages).Error
+	return usages, err
+}
@@ -161,8 +161,8 @@ func convertParameters(params []interface{}) []interface{} {
 
 	for _, param := range params {
 		if paramMap, ok := param.(map[string]interface{}); ok {
-			// Skip body parameters as they become requestBody in OpenAPI 3.x
-			if in, ok := paramMap["in"].(string); ok && in == "body" {
+			// Skip body and form parameters as they become requestBody in OpenAPI 3.x
+			if in, ok := paramMap["in"].(string); ok && (in == "body" || in == "formData") {
 				continue
 			}
 
@@ -210,6 +210,9 @@ func convertParameters(params []interface{}) []interface{} {
 			if enum, ok := paramMap["enum"]; ok {
 				schema["enum"] = enum
 			}
+			if items, ok := paramMap["items"]; ok {
+				schema["items"] = items
+			}
 
 			if len(schema) > 0 {
 				convertedParam["schema"] = schema
@@ -222,19 +225,19 @@ func convertParameters(params []interface{}) []interface{} {
 	return converted
 }
 
-// filterNonBodyParameters removes body parameters from the list
+// filterNonBodyParameters removes body and form parameters from the list
 func filterNonBodyParameters(params []interface{}) []interface{} {
 	filtered := []interface{}{}
 
 	for _, param := range params {
 		if paramMap, ok := param.(map[string]interface{}); ok {
-			if in, ok := paramMap["in"].(string); ok && in != "body" {
+			if in, ok := paramMap["in"].(string); ok && in != "body" && in != "formData" {
 				filtered = append(filtered, param)
 			}
 		}
 	}
 
-	return filtered
+	return convertParameters(filtered)
 }
 
 // extractRequestBody extracts body parameter and converts to requestBody
@@ -275,7 +278,64 @@ func extractRequestBody(params []interface{}, operation map[string]interface{})
 		}
 	}
 
-	return nil
+	return extractFormRequestBody(params, operation)
+}
+
+// extractFormRequestBody converts formData parameters to a multipart/form-data
+// (or urlencoded) requestBody schema; type file becomes string/binary
+func extractFormRequestBody(params []interface{}, operation map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []interface{}{}
+
+	for _, param := range params {
+		paramMap, ok := param.(map[string]interface{})
+		if !ok || paramMap["in"] != "formData" {
+			continue
+		}
+		name, _ := paramMap["name"].(string)
+
+		property := make(map[string]interface{})
+		for _, key := range []string{"type", "format", "description", "items", "enum", "default"} {
+			if value, ok := paramMap[key]; ok {
+				property[key] = value
+			}
+		}
+		if property["type"] == "file" {
+			property["type"] = "string"
+			property["format"] = "binary"
+		}
+		properties[name] = property
+
+		if isRequired, ok := paramMap["required"].(bool); ok && isRequired {
+			required = append(required, name)
+		}
+	}
+
+	if len(properties) == 0 {
+		return nil
+	}
+
+	contentType := "multipart/form-data"
+	if consumes, ok := operation["consumes"].([]interface{}); ok && len(consumes) > 0 {
+		if ct, ok := consumes[0].(string); ok {
+			contentType = ct
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return map[string]interface{}{
+		"required": len(required) > 0,
+		"content": map[string]interface{}{
+			contentType: map[string]interface{}{"schema": schema},
+		},
+	}
 }
 
 // convertResponses converts responses object
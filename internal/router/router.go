@@ -2,37 +2,36 @@ package router
 
 import (
 	"memology-backend/docs"
+	"memology-backend/internal/config"
 	"memology-backend/internal/handlers"
+	"memology-backend/internal/metrics"
 	"memology-backend/internal/middleware"
 	"memology-backend/internal/services"
+	"memology-backend/internal/tracing"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func SetupRouter(authService services.AuthService, userService services.UserService, memeService services.MemeService) *gin.Engine {
-	r := gin.Default()
+func SetupRouter(authService services.AuthService, userService services.UserService, memeService services.MemeService, styleCatalog *services.StyleCatalog, healthChecker *services.HealthChecker, corsCfg *config.CORSConfig, cookieCfg *config.CookieConfig) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), otelgin.Middleware(tracing.ServerName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !middleware.IsProbe(r.URL.Path)
+	})), middleware.RequestID(), middleware.AccessLog(), metrics.GinMiddleware())
 
-	r.Use(func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		if origin != "" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-		}
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	healthHandler := handlers.NewHealthHandler(healthChecker)
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	r.Use(middleware.CORS(corsCfg))
 
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(authService, cookieCfg)
+	userHandler := handlers.NewUserHandler(userService, cookieCfg)
 	memeHandler := handlers.NewMemeHandler(memeService)
+	styleHandler := handlers.NewStyleHandler(styleCatalog)
 
 	apiRoot := r.Group("/api")
 	{
@@ -55,7 +54,7 @@ func SetupRouter(authService services.AuthService, userService services.UserServ
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
-			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/refresh", middleware.CSRF(), authHandler.RefreshToken)
 			auth.POST("/logout", middleware.JWTAuth(authService), authHandler.Logout)
 			auth.POST("/logout-all", middleware.JWTAuth(authService), authHandler.LogoutAll)
 		}
@@ -77,17 +76,43 @@ func SetupRouter(authService services.AuthService, userService services.UserServ
 
 		memes := api.Group("/memes")
 		{
-			memes.GET("", memeHandler.GetAllMemes)
 			memes.GET("/public", memeHandler.GetPublicMemes)
 			memes.GET("/styles", memeHandler.GetAvailableStyles)
 			memes.GET("/:id", memeHandler.GetMeme)
 			memes.GET("/:id/status", memeHandler.CheckMemeStatus)
+			// Устаревший адрес списка всех мемов, оставлен для старых клиентов; то же, что /admin/memes
+			memes.GET("", middleware.JWTAuth(authService), middleware.RequireAdmin(userService), memeHandler.GetAllMemes)
 
 			memes.Use(middleware.JWTAuth(authService))
 			memes.POST("/generate", memeHandler.GenerateMeme)
 			memes.POST("/generate-template", memeHandler.GenerateTemplateMeme)
+			memes.POST("/upload", memeHandler.UploadMeme)
+			memes.POST("/import", memeHandler.ImportMeme)
 			memes.GET("/my", memeHandler.GetMyMemes)
+			memes.GET("/trash", memeHandler.GetTrash)
+			memes.PATCH("/:id", memeHandler.UpdateMeme)
 			memes.DELETE("/:id", memeHandler.DeleteMeme)
+			memes.POST("/:id/restore", memeHandler.RestoreMeme)
+		}
+
+		tags := api.Group("/tags")
+		{
+			tags.GET("/popular", memeHandler.GetPopularTags)
+		}
+
+		stats := api.Group("/stats")
+		stats.Use(middleware.JWTAuth(authService), middleware.RequireAdmin(userService))
+		{
+			stats.GET("/generation", memeHandler.GetGenerationStats)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.JWTAuth(authService), middleware.RequireAdmin(userService))
+		{
+			admin.GET("/memes", memeHandler.GetAllMemes)
+			admin.GET("/styles", styleHandler.ListStyles)
+			admin.PUT("/styles/:name", styleHandler.SetStyleOverride)
+			admin.DELETE("/styles/:name", styleHandler.DeleteStyleOverride)
 		}
 
 	}
@@ -4,35 +4,241 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"memology-backend/internal/config"
+	"memology-backend/internal/logger"
+	"memology-backend/internal/metrics"
+	"memology-backend/internal/tracing"
 )
 
+// AIService - клиент набора бэкендов генерации. Новые задачи распределяются между
+// бэкендами, а статус и результат запрашиваются у того, кто задачу принял
 type AIService interface {
-	GenerateMeme(ctx context.Context, userInput, style string) (string, error)
+	GenerateMeme(ctx context.Context, userInput, style string) (*GenerationTask, error)
 	GenerateTemplateMeme(ctx context.Context, req GenerateTemplateRequest) (*GenerateTemplateResponse, error)
-	GetTaskStatus(ctx context.Context, taskID string) (*TaskStatusResponse, error)
-	GetTaskResult(ctx context.Context, taskID string) ([]byte, error)
-	GetAvailableStyles(ctx context.Context) ([]string, error)
+	GetTaskStatus(ctx context.Context, backend, taskID string) (*TaskStatusResponse, error)
+	GetTaskResult(ctx context.Context, backend, taskID string) ([]byte, error)
+	GetAvailableStyles(ctx context.Context) ([]StyleObject, error)
+	Ping(ctx context.Context) error
+	Available(backend string) bool
+	Backends() []AIBackendStatus
+}
+
+// ErrAIUnavailable - AI-сервис не отвечает или circuit breaker разомкнут
+var ErrAIUnavailable = errors.New("AI service is unavailable")
+
+// GenerationTask - принятая задача генерации и бэкенд, который её выполняет
+type GenerationTask struct {
+	TaskID  string
+	Backend string
+}
+
+// aiEndpoint - настройки вызова: таймаут одной попытки и можно ли повторять запрос
+type aiEndpoint struct {
+	timeout    time.Duration
+	idempotent bool
 }
 
 type aiService struct {
-	config *config.AIConfig
-	client *http.Client
+	config    *config.AIConfig
+	client    *http.Client
+	endpoints map[string]aiEndpoint
+	backends  []*aiBackend
 }
 
 func NewAIService(cfg *config.AIConfig) AIService {
+	backendConfigs := cfg.Backends
+	if len(backendConfigs) == 0 {
+		backendConfigs = []config.AIBackendConfig{{Name: "default", BaseURL: cfg.BaseURL, Weight: 1}}
+	}
+	backends := make([]*aiBackend, 0, len(backendConfigs))
+	for _, backendCfg := range backendConfigs {
+		backends = append(backends, newAIBackend(backendCfg, cfg))
+	}
+
 	return &aiService{
-		config: cfg,
+		config:   cfg,
+		backends: backends,
 		client: &http.Client{
-			Timeout: cfg.Timeout,
+			Timeout:   cfg.Timeout,
+			Transport: tracing.Transport(http.DefaultTransport, "ai"),
+		},
+		endpoints: map[string]aiEndpoint{
+			"generate":          {timeout: cfg.GenerateTimeout},
+			"generate_template": {timeout: cfg.TemplateTimeout},
+			"task_status":       {timeout: cfg.StatusTimeout, idempotent: true},
+			"task_result":       {timeout: cfg.ResultTimeout, idempotent: true},
+			"styles":            {timeout: cfg.StylesTimeout, idempotent: true},
 		},
 	}
 }
 
+// Available - circuit breaker бэкенда не отклонит следующий вызов. Для неизвестного
+// бэкенда true: вызов сразу вернёт ErrUnknownAIBackend, и задача не повиснет в ожидании
+func (s *aiService) Available(backend string) bool {
+	b, err := s.backend(backend)
+	if err != nil {
+		return true
+	}
+	return b.breaker.Available()
+}
+
+func (s *aiService) Backends() []AIBackendStatus {
+	statuses := make([]AIBackendStatus, 0, len(s.backends))
+	for _, b := range s.backends {
+		statuses = append(statuses, b.status())
+	}
+	return statuses
+}
+
+// withFailover вызывает call на бэкенде, выбранном pickBackend, а если тот недоступен -
+// на следующем. Генерация не идемпотентна, поэтому при обрыве соединения задача
+// может остаться и на первом бэкенде; это дешевле, чем отказать пользователю
+func (s *aiService) withFailover(ctx context.Context, style string, call func(b *aiBackend) error) (*aiBackend, error) {
+	exclude := make(map[*aiBackend]bool)
+	var lastErr error
+
+	for {
+		b, err := s.pickBackend(style, exclude)
+		if err != nil {
+			if lastErr != nil && errors.Is(err, ErrAIUnavailable) {
+				return nil, lastErr
+			}
+			return nil, err
+		}
+
+		err = call(b)
+		if err == nil {
+			return b, nil
+		}
+		if !errors.Is(err, ErrAIUnavailable) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		slog.WarnContext(ctx, "AI backend unavailable, failing over", "backend", b.name, "error", err)
+		exclude[b] = true
+		lastErr = err
+	}
+}
+
+// do выполняет запрос к бэкенду с таймаутом endpoint, повторяет идемпотентные
+// запросы при ошибках сети, 5xx и 429 и учитывает результат в circuit breaker бэкенда.
+// Тело ответа нужно закрыть: вместе с ним освобождается контекст попытки
+func (s *aiService) do(b *aiBackend, req *http.Request, endpoint string) (*http.Response, error) {
+	ctx := req.Context()
+	ep := s.endpoints[endpoint]
+
+	if !b.breaker.Allow() {
+		metrics.ObserveAIRequest(b.name, endpoint, 0, true)
+		return nil, fmt.Errorf("%w: circuit breaker of backend %s is open", ErrAIUnavailable, b.name)
+	}
+
+	b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+
+	attempts := 1
+	if ep.idempotent && s.config.RetryAttempts > 1 {
+		attempts = s.config.RetryAttempts
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			backoff := s.config.RetryBackoff * time.Duration(1<<(attempt-2))
+			select {
+			case <-ctx.Done():
+				b.breaker.Abort()
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		resp, cancel, err := s.attempt(b, req, endpoint, ep.timeout)
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			b.breaker.Success()
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			resp.Body.Close()
+			lastErr = fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
+		}
+		cancel()
+
+		// Запрос отменил сам клиент - AI-сервис в этом не виноват
+		if ctx.Err() != nil {
+			b.breaker.Abort()
+			return nil, ctx.Err()
+		}
+
+		slog.WarnContext(ctx, "AI request attempt failed", "backend", b.name, "endpoint", endpoint,
+			"attempt", attempt, "max_attempts", attempts, "error", lastErr)
+	}
+
+	b.breaker.Failure()
+	return nil, fmt.Errorf("%w: backend %s: %v", ErrAIUnavailable, b.name, lastErr)
+}
+
+// attempt - одна попытка запроса, передаёт id исходного запроса в X-Request-ID
+// и записывает метрики вызова
+func (s *aiService) attempt(b *aiBackend, req *http.Request, endpoint string, timeout time.Duration) (*http.Response, context.CancelFunc, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+
+	attemptReq := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to copy request body: %w", err)
+		}
+		attemptReq.Body = body
+	}
+
+	if requestID := logger.RequestID(ctx); requestID != "" {
+		attemptReq.Header.Set("X-Request-ID", requestID)
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(attemptReq)
+	duration := time.Since(start)
+	metrics.ObserveAIRequest(b.name, endpoint, duration, err != nil || resp.StatusCode >= 300)
+
+	if err != nil {
+		slog.DebugContext(ctx, "AI request failed", "backend", b.name, "endpoint", endpoint, "duration", duration, "error", err)
+	} else {
+		slog.DebugContext(ctx, "AI request", "backend", b.name, "endpoint", endpoint, "status", resp.StatusCode, "duration", duration)
+	}
+	return resp, cancel, err
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
 type GenerateMemeRequest struct {
 	UserInput string `json:"user_input"`
 	Style     string `json:"style,omitempty"`
@@ -46,6 +252,7 @@ type TaskStatusResponse struct {
 	Status     string `json:"status"`
 	TaskID     string `json:"task_id"`
 	ResultPath string `json:"result_path,omitempty"`
+	Model      string `json:"model,omitempty"`
 }
 
 // GenerateTemplateRequest - запрос на генерацию шаблонного мема через memegen.link
@@ -77,7 +284,7 @@ func (r *GenerateTemplateResponse) GetTextStrings() []string {
 	return nil
 }
 
-func (s *aiService) GenerateMeme(ctx context.Context, userInput, style string) (string, error) {
+func (s *aiService) GenerateMeme(ctx context.Context, userInput, style string) (*GenerationTask, error) {
 	reqBody := GenerateMemeRequest{
 		UserInput: userInput,
 		Style:     style,
@@ -85,42 +292,53 @@ func (s *aiService) GenerateMeme(ctx context.Context, userInput, style string) (
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.config.BaseURL+"/api/memes/generate", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
+	var result GenerateMemeResponse
+	backend, err := s.withFailover(ctx, style, func(b *aiBackend) error {
+		req, err := http.NewRequestWithContext(ctx, "POST", b.baseURL+"/api/memes/generate", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
 
-	req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+		resp, err := s.do(b, req, "generate")
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
-	}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
+		}
 
-	var result GenerateMemeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return result.TaskID, nil
+	return &GenerationTask{TaskID: result.TaskID, Backend: backend.name}, nil
 }
 
-func (s *aiService) GetTaskStatus(ctx context.Context, taskID string) (*TaskStatusResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/memes/task/%s", s.config.BaseURL, taskID), nil)
+func (s *aiService) GetTaskStatus(ctx context.Context, backend, taskID string) (*TaskStatusResponse, error) {
+	b, err := s.backend(backend)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, backend)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/memes/task/%s", b.baseURL, taskID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(b, req, "task_status")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -139,13 +357,18 @@ func (s *aiService) GetTaskStatus(ctx context.Context, taskID string) (*TaskStat
 	return &result, nil
 }
 
-func (s *aiService) GetTaskResult(ctx context.Context, taskID string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/memes/task/%s/result", s.config.BaseURL, taskID), nil)
+func (s *aiService) GetTaskResult(ctx context.Context, backend, taskID string) ([]byte, error) {
+	b, err := s.backend(backend)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, backend)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/memes/task/%s/result", b.baseURL, taskID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(b, req, "task_result")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -159,18 +382,111 @@ func (s *aiService) GetTaskResult(ctx context.Context, taskID string) ([]byte, e
 	return io.ReadAll(resp.Body)
 }
 
+// Ping проверяет все бэкенды лёгким запросом списка стилей. Ошибка, если не отвечает
+// хотя бы один: readiness покажет degraded с именами упавших бэкендов
+func (s *aiService) Ping(ctx context.Context) error {
+	errs := make([]error, len(s.backends))
+	var wg sync.WaitGroup
+	for i, b := range s.backends {
+		wg.Add(1)
+		go func(i int, b *aiBackend) {
+			defer wg.Done()
+			if err := s.pingBackend(ctx, b); err != nil {
+				errs[i] = fmt.Errorf("backend %s: %w", b.name, err)
+			}
+		}(i, b)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (s *aiService) pingBackend(ctx context.Context, b *aiBackend) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", b.baseURL+"/api/memes/styles", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Мимо circuit breaker: readiness должна видеть реальное состояние сервиса
+	resp, cancel, err := s.attempt(b, req, "ping", 0)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer cancel()
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("AI service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// StyleObject - стиль в ответе AI-сервиса; старые версии отдают только имя
 type StyleObject struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	PreviewURL  string `json:"preview_url"`
+}
+
+// UnmarshalJSON принимает как объект, так и строку с именем стиля
+func (o *StyleObject) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*o = StyleObject{Name: name}
+		return nil
+	}
+
+	type plain StyleObject
+	return json.Unmarshal(data, (*plain)(o))
 }
 
-func (s *aiService) GetAvailableStyles(ctx context.Context) ([]string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", s.config.BaseURL+"/api/memes/styles", nil)
+// GetAvailableStyles объединяет стили доступных бэкендов. Если у бэкенда в конфигурации
+// задан список стилей, от него берутся только они
+func (s *aiService) GetAvailableStyles(ctx context.Context) ([]StyleObject, error) {
+	result := []StyleObject{}
+	seen := make(map[string]bool)
+	answered := false
+	var lastErr error
+
+	for _, b := range s.backends {
+		if !b.breaker.Available() {
+			lastErr = fmt.Errorf("%w: circuit breaker of backend %s is open", ErrAIUnavailable, b.name)
+			continue
+		}
+
+		styles, err := s.getBackendStyles(ctx, b)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "failed to get styles from AI backend", "backend", b.name, "error", err)
+			lastErr = err
+			continue
+		}
+
+		for _, style := range styles {
+			if style.Name != "" && !seen[style.Name] && b.supports(style.Name) {
+				seen[style.Name] = true
+				result = append(result, style)
+			}
+		}
+		answered = true
+	}
+
+	if !answered {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+func (s *aiService) getBackendStyles(ctx context.Context, b *aiBackend) ([]StyleObject, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", b.baseURL+"/api/memes/styles", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(b, req, "styles")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -186,31 +502,17 @@ func (s *aiService) GetAvailableStyles(ctx context.Context) ([]string, error) {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	var stylesArray []string
-	if err := json.Unmarshal(body, &stylesArray); err == nil {
-		return stylesArray, nil
+	// Список стилей приходит массивом строк или объектов, либо внутри {"styles": [...]}
+	var styles []StyleObject
+	if err := json.Unmarshal(body, &styles); err == nil {
+		return styles, nil
 	}
 
-	var stylesObjectArray []StyleObject
-	if err := json.Unmarshal(body, &stylesObjectArray); err == nil {
-		result := make([]string, 0, len(stylesObjectArray))
-		for _, style := range stylesObjectArray {
-			result = append(result, style.Name)
-		}
-		return result, nil
+	var wrapped struct {
+		Styles []StyleObject `json:"styles"`
 	}
-
-	var stylesObject map[string]interface{}
-	if err := json.Unmarshal(body, &stylesObject); err == nil {
-		if styles, ok := stylesObject["styles"].([]interface{}); ok {
-			result := make([]string, 0, len(styles))
-			for _, style := range styles {
-				if s, ok := style.(string); ok {
-					result = append(result, s)
-				}
-			}
-			return result, nil
-		}
+	if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.Styles != nil {
+		return wrapped.Styles, nil
 	}
 
 	return nil, fmt.Errorf("failed to parse styles response: %s", string(body))
@@ -231,27 +533,33 @@ func (s *aiService) GenerateTemplateMeme(ctx context.Context, req GenerateTempla
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.config.BaseURL+"/api/memes/generate-template", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	var result GenerateTemplateResponse
+	_, err = s.withFailover(ctx, "", func(b *aiBackend) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", b.baseURL+"/api/memes/generate-template", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+		resp, err := s.do(b, httpReq, "generate_template")
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
+		}
 
-	var result GenerateTemplateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &result, nil
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/metrics"
+)
+
+var (
+	// ErrStyleNotSupported - ни один из настроенных бэкендов не умеет генерировать этот стиль
+	ErrStyleNotSupported = errors.New("style is not supported")
+	// ErrUnknownAIBackend - задача мема принадлежит бэкенду, которого больше нет в конфигурации
+	ErrUnknownAIBackend = errors.New("unknown AI backend")
+)
+
+// AIBackendStatus - состояние бэкенда для диагностики
+type AIBackendStatus struct {
+	Name      string   `json:"name"`
+	Weight    int      `json:"weight"`
+	Styles    []string `json:"styles,omitempty"`
+	Available bool     `json:"available"`
+	InFlight  int64    `json:"in_flight"`
+}
+
+// aiBackend - один пул генерации со своим circuit breaker. inFlight - запросы,
+// выполняющиеся прямо сейчас, включая опрос статусов его задач
+type aiBackend struct {
+	name     string
+	baseURL  string
+	weight   int
+	styles   map[string]bool
+	breaker  *circuitBreaker
+	inFlight atomic.Int64
+}
+
+func newAIBackend(cfg config.AIBackendConfig, aiCfg *config.AIConfig) *aiBackend {
+	b := &aiBackend{
+		name:    cfg.Name,
+		baseURL: cfg.BaseURL,
+		weight:  cfg.Weight,
+	}
+	if b.weight <= 0 {
+		b.weight = 1
+	}
+	if len(cfg.Styles) > 0 {
+		b.styles = make(map[string]bool, len(cfg.Styles))
+		for _, style := range cfg.Styles {
+			b.styles[style] = true
+		}
+	}
+
+	b.breaker = newCircuitBreaker(aiCfg.BreakerFailures, aiCfg.BreakerCooldown, func(state int) {
+		metrics.SetAICircuitState(b.name, state)
+		switch state {
+		case circuitOpen:
+			slog.Warn("AI circuit breaker opened", "backend", b.name, "cooldown", aiCfg.BreakerCooldown)
+		case circuitClosed:
+			slog.Info("AI circuit breaker closed", "backend", b.name)
+		}
+	})
+	return b
+}
+
+// supports - пустой стиль принимает любой бэкенд
+func (b *aiBackend) supports(style string) bool {
+	return style == "" || b.styles == nil || b.styles[style]
+}
+
+func (b *aiBackend) status() AIBackendStatus {
+	status := AIBackendStatus{
+		Name:      b.name,
+		Weight:    b.weight,
+		Available: b.breaker.Available(),
+		InFlight:  b.inFlight.Load(),
+	}
+	for style := range b.styles {
+		status.Styles = append(status.Styles, style)
+	}
+	return status
+}
+
+// pickBackend выбирает бэкенд для новой задачи среди поддерживающих style.
+// Вероятность выбора пропорциональна weight / (inFlight + 1): тяжёлые пулы получают
+// больше задач, но загруженный бэкенд уступает свободным. Бэкенды из exclude уже
+// не ответили на этот запрос. Возвращает ErrStyleNotSupported, если стиль не умеет
+// никто, и ErrAIUnavailable, если все подходящие бэкенды недоступны
+func (s *aiService) pickBackend(style string, exclude map[*aiBackend]bool) (*aiBackend, error) {
+	var candidates []*aiBackend
+	var scores []float64
+	var total float64
+	supported := false
+
+	for _, b := range s.backends {
+		if !b.supports(style) {
+			continue
+		}
+		supported = true
+		if exclude[b] || !b.breaker.Available() {
+			continue
+		}
+		score := float64(b.weight) / float64(b.inFlight.Load()+1)
+		candidates = append(candidates, b)
+		scores = append(scores, score)
+		total += score
+	}
+
+	if !supported {
+		return nil, ErrStyleNotSupported
+	}
+	if len(candidates) == 0 {
+		return nil, ErrAIUnavailable
+	}
+
+	r := rand.Float64() * total
+	for i, score := range scores {
+		if r < score {
+			return candidates[i], nil
+		}
+		r -= score
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+// backend - бэкенд, которому принадлежит задача. Пустое имя - мемы, созданные
+// до появления нескольких бэкендов: их задачи живут на первом из списка
+func (s *aiService) backend(name string) (*aiBackend, error) {
+	if name == "" {
+		return s.backends[0], nil
+	}
+	for _, b := range s.backends {
+		if b.name == name {
+			return b, nil
+		}
+	}
+	return nil, ErrUnknownAIBackend
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	circuitClosed = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+// circuitBreaker размыкается после failureThreshold неудачных вызовов подряд и
+// cooldown отклоняет запросы сразу. Затем пропускает один пробный вызов (half-open):
+// успех замыкает цепь, ошибка снова размыкает её
+type circuitBreaker struct {
+	failureThreshold int
+	cooldown         time.Duration
+	onStateChange    func(state int)
+
+	mu            sync.Mutex
+	state         int
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+func newCircuitBreaker(failureThreshold int, cooldown time.Duration, onStateChange func(state int)) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		onStateChange:    onStateChange,
+	}
+}
+
+// Allow сообщает, можно ли выполнить вызов сейчас
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		b.probeInFlight = true
+		return true
+	case circuitHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	}
+	return true
+}
+
+// Available - без изменения состояния: вызов не будет отклонён сразу
+func (b *circuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		return time.Since(b.openedAt) >= b.cooldown
+	case circuitHalfOpen:
+		return !b.probeInFlight
+	}
+	return true
+}
+
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probeInFlight = false
+	b.setState(circuitClosed)
+}
+
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probeInFlight = false
+	if b.state == circuitHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = time.Now()
+		b.setState(circuitOpen)
+	}
+}
+
+// Abort освобождает пробный вызов, прерванный не по вине AI-сервиса (например, клиент отменил запрос)
+func (b *circuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+}
+
+func (b *circuitBreaker) setState(state int) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onStateChange != nil {
+		b.onStateChange(state)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"memology-backend/internal/config"
+)
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+)
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// DBPinger - соединение с базой, *sql.DB подходит как есть
+type DBPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DependencyStatus - результат проверки одной зависимости. Critical - без неё сервис не готов
+type DependencyStatus struct {
+	Status    string `json:"status" example:"ok"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Cached    bool   `json:"cached,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Backends - состояние бэкендов AI-сервиса
+	Backends []AIBackendStatus `json:"backends,omitempty"`
+}
+
+// ReadinessReport - ответ /readyz: общий статус и разбивка по зависимостям
+type ReadinessReport struct {
+	Status string                       `json:"status" example:"ok"`
+	Checks map[string]*DependencyStatus `json:"checks"`
+}
+
+// Ready - можно ли направлять на инстанс трафик (упала хотя бы одна критичная зависимость - нет)
+func (r *ReadinessReport) Ready() bool {
+	return r.Status != HealthStatusUnavailable
+}
+
+// HealthChecker проверяет зависимости для /readyz. AI-сервис не критичен: без него
+// работают чтение и редактирование мемов, поэтому его падение даёт статус degraded.
+// Результат проверки AI кэшируется, чтобы частые пробы не нагружали сервис
+type HealthChecker struct {
+	db            DBPinger
+	minioSvc      MinIOService
+	aiSvc         AIService
+	taskProcessor *TaskProcessor
+	timeout       time.Duration
+	aiCacheTTL    time.Duration
+	shuttingDown  atomic.Bool
+
+	mu          sync.Mutex
+	aiCached    *DependencyStatus
+	aiCheckedAt time.Time
+}
+
+func NewHealthChecker(cfg *config.HealthConfig, db DBPinger, minioSvc MinIOService, aiSvc AIService, taskProcessor *TaskProcessor) *HealthChecker {
+	return &HealthChecker{
+		db:            db,
+		minioSvc:      minioSvc,
+		aiSvc:         aiSvc,
+		taskProcessor: taskProcessor,
+		timeout:       cfg.CheckTimeout,
+		aiCacheTTL:    cfg.AICacheTTL,
+	}
+}
+
+// SetShuttingDown переводит readiness в unavailable на время graceful shutdown
+func (h *HealthChecker) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthChecker) Readiness(ctx context.Context) *ReadinessReport {
+	report := &ReadinessReport{Checks: make(map[string]*DependencyStatus)}
+
+	if h.shuttingDown.Load() {
+		report.Status = HealthStatusUnavailable
+		report.Checks["server"] = &DependencyStatus{
+			Status:   HealthStatusUnavailable,
+			Critical: true,
+			Error:    errShuttingDown.Error(),
+		}
+		return report
+	}
+
+	checks := map[string]func(context.Context) *DependencyStatus{
+		"database": func(ctx context.Context) *DependencyStatus {
+			return h.check(ctx, true, h.db.PingContext)
+		},
+		"storage": func(ctx context.Context) *DependencyStatus {
+			return h.check(ctx, true, h.minioSvc.Ping)
+		},
+		"ai_service":     h.checkAI,
+		"task_processor": h.checkTaskProcessor,
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) *DependencyStatus) {
+			defer wg.Done()
+			status := check(ctx)
+			mu.Lock()
+			report.Checks[name] = status
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	report.Status = HealthStatusOK
+	for _, status := range report.Checks {
+		if status.Status == HealthStatusOK {
+			continue
+		}
+		if status.Critical {
+			report.Status = HealthStatusUnavailable
+			break
+		}
+		report.Status = HealthStatusDegraded
+	}
+
+	return report
+}
+
+func (h *HealthChecker) check(ctx context.Context, critical bool, ping func(context.Context) error) *DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+
+	status := &DependencyStatus{
+		Status:    HealthStatusOK,
+		Critical:  critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = HealthStatusUnavailable
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (h *HealthChecker) checkAI(ctx context.Context) *DependencyStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.aiCached != nil && time.Since(h.aiCheckedAt) < h.aiCacheTTL {
+		cached := *h.aiCached
+		cached.Cached = true
+		cached.Backends = h.aiSvc.Backends()
+		return &cached
+	}
+
+	status := h.check(ctx, false, h.aiSvc.Ping)
+	status.Backends = h.aiSvc.Backends()
+	h.aiCached = status
+	h.aiCheckedAt = time.Now()
+	return status
+}
+
+func (h *HealthChecker) checkTaskProcessor(_ context.Context) *DependencyStatus {
+	status := &DependencyStatus{Status: HealthStatusOK, Critical: true}
+	if h.taskProcessor == nil || !h.taskProcessor.Running() {
+		status.Status = HealthStatusUnavailable
+		status.Error = "task processor is not running"
+	}
+	return status
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrFetchRejected - общая причина отказа скачивать URL; конкретные ошибки её оборачивают
+var ErrFetchRejected = errors.New("remote image rejected")
+
+var (
+	ErrFetchHostNotAllowed   = fmt.Errorf("%w: host is not allowed", ErrFetchRejected)
+	ErrFetchPrivateAddress   = fmt.Errorf("%w: address is in a private network", ErrFetchRejected)
+	ErrFetchTooLarge         = fmt.Errorf("%w: image is too large", ErrFetchRejected)
+	ErrFetchNotImage         = fmt.Errorf("%w: response is not a supported image", ErrFetchRejected)
+	ErrFetchTooManyRedirects = fmt.Errorf("%w: too many redirects", ErrFetchRejected)
+)
+
+// fetchImageTypes - типы, которые принимаются и по заголовку Content-Type, и по содержимому,
+// и расширения файлов в хранилище. Набор тот же, что у загрузки (uploadFormats)
+var fetchImageTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+}
+
+// blockedPrefixes - сети, которых нет среди IsPrivate/IsLoopback/IsLinkLocal*, но
+// которые тоже не должны быть доступны снаружи: CGNAT, "this network", benchmark, reserved
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// FetchedImage - скачанная картинка, её тип, определённый по содержимому, и расширение для хранилища
+type FetchedImage struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// ImageFetcher скачивает картинки по URL, которые пришли извне (от AI-сервиса или
+// пользователя). Хост сверяется со списком разрешённых на каждом редиректе, а IP -
+// уже после DNS-резолва при подключении, поэтому подмена DNS не пускает во внутреннюю сеть
+type ImageFetcher struct {
+	cfg    config.FetchConfig
+	client *http.Client
+}
+
+func NewImageFetcher(cfg *config.FetchConfig) *ImageFetcher {
+	f := &ImageFetcher{cfg: *cfg}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: f.checkAddress,
+	}
+	transport := &http.Transport{
+		// Прокси отключён: иначе проверялся бы адрес прокси, а не конечного хоста
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	f.client = &http.Client{
+		Timeout:       cfg.Timeout,
+		Transport:     transport,
+		CheckRedirect: f.checkRedirect,
+	}
+	return f
+}
+
+// Fetch скачивает картинку не больше MaxBytes и проверяет, что это действительно изображение
+func (f *ImageFetcher) Fetch(ctx context.Context, rawURL string) (*FetchedImage, error) {
+	// Заголовок traceparent сторонним сервисам не передаётся, поэтому спан открывается вручную
+	ctx, span := tracing.Start(ctx, "image fetch", attribute.String("url.full", rawURL))
+	defer span.End()
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image URL: %w", err)
+	}
+	if err := f.checkURL(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "image/png, image/jpeg, image/gif")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	}
+
+	maxBytes := int64(f.cfg.MaxBytes)
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrFetchTooLarge, resp.ContentLength, maxBytes)
+	}
+
+	declared := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	if _, ok := fetchImageTypes[strings.ToLower(declared)]; !ok {
+		return nil, fmt.Errorf("%w: content type %q", ErrFetchNotImage, declared)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image data: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: limit %d bytes", ErrFetchTooLarge, maxBytes)
+	}
+
+	// Заголовку не верим: тип определяется по первым байтам
+	detected := http.DetectContentType(data)
+	ext, ok := fetchImageTypes[detected]
+	if !ok {
+		return nil, fmt.Errorf("%w: content looks like %q", ErrFetchNotImage, detected)
+	}
+
+	return &FetchedImage{Data: data, ContentType: detected, Ext: ext}, nil
+}
+
+func (f *ImageFetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.cfg.MaxRedirects {
+		return fmt.Errorf("%w: limit %d", ErrFetchTooManyRedirects, f.cfg.MaxRedirects)
+	}
+	return f.checkURL(req.URL)
+}
+
+// checkURL пропускает только http(s) на разрешённые хосты
+func (f *ImageFetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrFetchHostNotAllowed, u.Scheme)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in URL", ErrFetchHostNotAllowed)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrFetchHostNotAllowed)
+	}
+	if len(f.cfg.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range f.cfg.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return nil
+			}
+		} else if host == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrFetchHostNotAllowed, host)
+}
+
+// checkAddress вызывается при каждом подключении с уже разрезолвленным IP
+func (f *ImageFetcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	if f.cfg.AllowPrivateNetworks {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrFetchPrivateAddress, address)
+	}
+	if !publicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrFetchPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"memology-backend/internal/config"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageFetcher(t *testing.T) {
+	pngData := testPNG(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/fake.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("<html><body>not an image</body></html>"))
+	})
+	mux.HandleFunc("/image.webp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/webp")
+		w.Write([]byte("RIFF\x24\x00\x00\x00WEBPVP8 \x18\x00\x00\x00"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	base := config.FetchConfig{
+		AllowedHosts:         []string{"127.0.0.1"},
+		AllowPrivateNetworks: true,
+		Timeout:              5 * time.Second,
+		MaxBytes:             1 << 20,
+		MaxRedirects:         3,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *config.FetchConfig)
+		url    string
+		want   error
+	}{
+		{name: "allowed", url: srv.URL + "/image.png"},
+		{name: "private address", modify: func(cfg *config.FetchConfig) { cfg.AllowPrivateNetworks = false }, url: srv.URL + "/image.png", want: ErrFetchPrivateAddress},
+		{name: "host not in allowlist", modify: func(cfg *config.FetchConfig) { cfg.AllowedHosts = []string{"*.memegen.link"} }, url: srv.URL + "/image.png", want: ErrFetchHostNotAllowed},
+		{name: "redirect to foreign host", url: srv.URL + "/redirect?to=http://localhost/image.png", want: ErrFetchHostNotAllowed},
+		{name: "redirect loop", url: srv.URL + "/loop", want: ErrFetchTooManyRedirects},
+		{name: "unsupported scheme", url: "file:///etc/passwd", want: ErrFetchHostNotAllowed},
+		{name: "too large", modify: func(cfg *config.FetchConfig) { cfg.MaxBytes = 16 }, url: srv.URL + "/image.png", want: ErrFetchTooLarge},
+		{name: "declared type is not an image", url: srv.URL + "/page", want: ErrFetchNotImage},
+		{name: "content is not an image", url: srv.URL + "/fake.png", want: ErrFetchNotImage},
+		// WebP не декодируется стандартной библиотекой, и загрузка его тоже не принимает
+		{name: "webp is not supported", url: srv.URL + "/image.webp", want: ErrFetchNotImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+
+			image, err := NewImageFetcher(&cfg).Fetch(context.Background(), tt.url)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if image.ContentType != "image/png" || image.Ext != "png" || !bytes.Equal(image.Data, pngData) {
+					t.Fatalf("unexpected image: %s, %d bytes", image.ContentType, len(image.Data))
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) || !errors.Is(err, ErrFetchRejected) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if got := publicAddress(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("publicAddress(%s) = %v, want %v", addr, got, public)
+		}
+	}
+}
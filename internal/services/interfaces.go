@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"mime/multipart"
+	"time"
 
 	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
 
 	"github.com/google/uuid"
 )
@@ -25,6 +27,8 @@ type UserService interface {
 	UploadAvatar(ctx context.Context, userID uuid.UUID, fileData []byte, filename string) (*models.User, error)
 	DeleteAccount(ctx context.Context, userID uuid.UUID) error
 	GetUsers(ctx context.Context, limit, offset int) ([]*models.User, error)
+	SetActive(ctx context.Context, userID uuid.UUID, active bool) (*models.User, error)
+	ResetPassword(ctx context.Context, userID uuid.UUID, newPassword string) error
 }
 
 type RegisterRequest struct {
@@ -64,27 +68,89 @@ type ChangePasswordRequest struct {
 type MemeService interface {
 	CreateMeme(ctx context.Context, userID uuid.UUID, req CreateMemeRequest) (*models.Meme, error)
 	CreateTemplateMeme(ctx context.Context, userID uuid.UUID, req CreateTemplateMemeRequest) (*models.Meme, error)
-	UploadMemeImage(ctx context.Context, memeID uuid.UUID, file *multipart.FileHeader) error
+	UploadMeme(ctx context.Context, userID uuid.UUID, req UploadMemeRequest, file *multipart.FileHeader) (*models.Meme, bool, error)
+	ImportMeme(ctx context.Context, userID uuid.UUID, req ImportMemeRequest) (*models.Meme, bool, error)
 	GetMeme(ctx context.Context, memeID uuid.UUID) (*models.Meme, error)
-	GetUserMemes(ctx context.Context, userID uuid.UUID, limit, offset int, search string) ([]*models.Meme, int64, error)
-	GetPublicMemes(ctx context.Context, limit, offset int, search string) ([]*models.Meme, int64, error)
-	GetAllMemes(ctx context.Context, limit, offset int) ([]*models.Meme, int64, error)
+	GetUserMemes(ctx context.Context, userID uuid.UUID, filter repository.MemeFilter, page PageRequest) (*MemeList, error)
+	GetPublicMemes(ctx context.Context, filter repository.MemeFilter, page PageRequest) (*MemeList, error)
+	GetAllMemes(ctx context.Context, filter repository.MemeFilter, page PageRequest) (*MemeList, error)
+	UpdateMeme(ctx context.Context, userID, memeID uuid.UUID, req UpdateMemeRequest) (*models.Meme, error)
 	DeleteMeme(ctx context.Context, userID, memeID uuid.UUID) error
+	GetTrash(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*TrashedMeme, int64, error)
+	RestoreMeme(ctx context.Context, userID, memeID uuid.UUID) (*models.Meme, error)
 	CheckTaskStatus(ctx context.Context, memeID uuid.UUID) (*models.Meme, error)
 	ProcessCompletedTask(ctx context.Context, memeID uuid.UUID) error
-	GetAvailableStyles(ctx context.Context) ([]string, error)
+	GetAvailableStyles(ctx context.Context) ([]Style, error)
+	GetPopularTags(ctx context.Context, limit int) ([]*repository.TagUsage, error)
+	GetGenerationStats(ctx context.Context, from, to time.Time) (*GenerationStatsReport, error)
+	RegenerateMeme(ctx context.Context, memeID uuid.UUID) (*models.Meme, error)
 }
 
 type CreateMemeRequest struct {
-	Prompt   string `json:"prompt" validate:"required" example:"я купил компьютер за 1000000"`
-	Style    string `json:"style,omitempty" example:"anime"`
-	IsPublic *bool  `json:"is_public,omitempty" example:"true"`
+	Prompt   string   `json:"prompt" validate:"required" example:"я купил компьютер за 1000000"`
+	Style    string   `json:"style,omitempty" example:"anime"`
+	IsPublic *bool    `json:"is_public,omitempty" example:"true"`
+	Tags     []string `json:"tags,omitempty" validate:"omitempty,dive,min=1,max=50" example:"деньги,компьютеры"`
 }
 
 // CreateTemplateMemeRequest - запрос на создание шаблонного мема через memegen.link
 type CreateTemplateMemeRequest struct {
-	Context  string `json:"context" validate:"required" example:"Кот пьет кофе"`
-	Width    int    `json:"width,omitempty" example:"512"`
-	Height   int    `json:"height,omitempty" example:"512"`
-	IsPublic *bool  `json:"is_public,omitempty" example:"true"`
+	Context  string   `json:"context" validate:"required" example:"Кот пьет кофе"`
+	Width    int      `json:"width,omitempty" example:"512"`
+	Height   int      `json:"height,omitempty" example:"512"`
+	IsPublic *bool    `json:"is_public,omitempty" example:"true"`
+	Tags     []string `json:"tags,omitempty" validate:"omitempty,dive,min=1,max=50" example:"коты,кофе"`
+}
+
+// UploadMemeRequest - подпись, заголовок, видимость и теги загружаемого мема.
+// Подпись хранится в prompt и участвует в поиске и автотегах
+type UploadMemeRequest struct {
+	Caption  string   `json:"caption,omitempty" form:"caption" validate:"max=1000" example:"когда пришёл на работу в понедельник"`
+	Title    string   `json:"title,omitempty" form:"title" validate:"max=200" example:"Понедельник"`
+	IsPublic *bool    `json:"is_public,omitempty" form:"is_public" example:"true"`
+	Tags     []string `json:"tags,omitempty" form:"tags" validate:"omitempty,dive,min=1,max=50" example:"понедельник,работа"`
+}
+
+// ImportMemeRequest - импорт мема по URL картинки
+type ImportMemeRequest struct {
+	URL string `json:"url" validate:"required,url" example:"https://example.com/meme.png"`
+	UploadMemeRequest
+}
+
+// UpdateMemeRequest - частичное обновление мема, изменяются только переданные поля
+// (tags заменяет весь список тегов).
+// UpdatedAt - значение из последнего чтения: если мем с тех пор изменился, обновление отклоняется
+type UpdateMemeRequest struct {
+	Title       *string    `json:"title,omitempty" validate:"omitempty,max=200" example:"Понедельник"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=2000" example:"Когда пришёл на работу"`
+	Prompt      *string    `json:"prompt,omitempty" validate:"omitempty,min=1,max=1000" example:"я купил компьютер за 1000000"`
+	IsPublic    *bool      `json:"is_public,omitempty" example:"false"`
+	Tags        *[]string  `json:"tags,omitempty" validate:"omitempty,dive,min=1,max=50" example:"понедельник,работа"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" example:"2025-01-01T12:00:00.123456Z"`
+}
+
+// TrashedMeme - мем в корзине с датой удаления и датой окончательной очистки
+type TrashedMeme struct {
+	*models.Meme
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// GenerationStyleStats - статистика генерации по одному виду и стилю мемов
+type GenerationStyleStats struct {
+	*repository.GenerationStats
+	SuccessRate float64 `json:"success_rate" example:"0.95"`
+	FailureRate float64 `json:"failure_rate" example:"0.05"`
+}
+
+// GenerationStatsReport - статистика генерации за период [From, To)
+type GenerationStatsReport struct {
+	From        time.Time               `json:"from"`
+	To          time.Time               `json:"to"`
+	Total       int64                   `json:"total"`
+	Completed   int64                   `json:"completed"`
+	Failed      int64                   `json:"failed"`
+	SuccessRate float64                 `json:"success_rate"`
+	FailureRate float64                 `json:"failure_rate"`
+	Styles      []*GenerationStyleStats `json:"styles"`
 }
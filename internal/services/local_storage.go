@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/metrics"
+
+	"github.com/google/uuid"
+)
+
+// LocalStoragePath - путь, по которому сервер раздаёт файлы локального хранилища
+const LocalStoragePath = "/storage"
+
+// localStorage хранит объекты файлами в каталоге; имя объекта - путь относительно него
+type localStorage struct {
+	dir       string
+	publicURL string
+}
+
+// NewStorageService создаёт хранилище по STORAGE_BACKEND
+func NewStorageService(cfg *config.Config) (MinIOService, error) {
+	switch cfg.Storage.Backend {
+	case "", "minio":
+		return NewMinIOService(&cfg.MinIO)
+	case "local":
+		return NewLocalStorageService(&cfg.Storage)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
+
+func NewLocalStorageService(cfg *config.StorageConfig) (MinIOService, error) {
+	dir, err := filepath.Abs(cfg.LocalDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage dir: %w", err)
+	}
+
+	return &localStorage{
+		dir:       dir,
+		publicURL: strings.TrimSuffix(cfg.LocalPublicURL, "/"),
+	}, nil
+}
+
+// path переводит имя объекта в путь на диске, не выпуская его за пределы каталога
+func (s *localStorage) path(objectName string) (string, error) {
+	if !filepath.IsLocal(objectName) {
+		return "", fmt.Errorf("invalid object name %q", objectName)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(objectName)), nil
+}
+
+func (s *localStorage) write(objectName string, src io.Reader) (int64, error) {
+	path, err := s.path(objectName)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы читатели не видели файл наполовину
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *localStorage) UploadMeme(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	objectName := fmt.Sprintf("memes/%s%s", uuid.New().String(), filepath.Ext(file.Filename))
+
+	size, err := s.write(objectName, src)
+	metrics.ObserveStorageUpload(size, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return objectName, nil
+}
+
+func (s *localStorage) UploadBytes(ctx context.Context, objectName string, data []byte) error {
+	_, err := s.write(objectName, bytes.NewReader(data))
+	metrics.ObserveStorageUpload(int64(len(data)), err)
+	if err != nil {
+		return fmt.Errorf("failed to upload bytes: %w", err)
+	}
+	return nil
+}
+
+func (s *localStorage) DeleteMeme(ctx context.Context, objectName string) error {
+	path, err := s.path(objectName)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *localStorage) GetMemeURL(objectName string) string {
+	return s.publicURL + "/" + objectName
+}
+
+func (s *localStorage) ObjectNameFromURL(url string) (string, bool) {
+	objectName, ok := strings.CutPrefix(url, s.publicURL+"/")
+	if !ok || objectName == "" {
+		return "", false
+	}
+	return objectName, true
+}
+
+func (s *localStorage) ListObjects(ctx context.Context, prefix string) ([]StoredObject, error) {
+	var objects []StoredObject
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, StoredObject{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+	return objects, nil
+}
+
+// Ping проверяет, что каталог хранилища существует
+func (s *localStorage) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to check storage dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", s.dir)
+	}
+	return nil
+}
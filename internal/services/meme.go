@@ -4,46 +4,79 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"mime/multipart"
-	"net/http"
+	"log/slog"
+	"time"
 
+	"memology-backend/internal/config"
+	"memology-backend/internal/logger"
 	"memology-backend/internal/models"
 	"memology-backend/internal/repository"
+	"memology-backend/internal/tracing"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrMemeNotFound = errors.New("meme not found")
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrInvalidFile  = errors.New("invalid file")
-	ErrTaskPending  = errors.New("task is still pending")
+	ErrMemeNotFound  = errors.New("meme not found")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrInvalidFile   = errors.New("invalid file")
+	ErrTaskPending   = errors.New("task is still pending")
+	ErrMemeModified  = errors.New("meme was modified by another request")
+	ErrTooManyTags   = errors.New("too many tags")
+	ErrQuotaExceeded = errors.New("meme quota exceeded")
+
+	ErrRegenerateUnsupported = errors.New("only AI memes can be regenerated")
 )
 
 type memeService struct {
-	memeRepo      repository.MemeRepository
-	minioSvc      MinIOService
-	aiSvc         AIService
-	taskProcessor *TaskProcessor
+	memeRepo       repository.MemeRepository
+	tagRepo        repository.TagRepository
+	minioSvc       MinIOService
+	aiSvc          AIService
+	taskProcessor  *TaskProcessor
+	styleCatalog   *StyleCatalog
+	imageFetcher   *ImageFetcher
+	importFetcher  *ImageFetcher
+	thumbnailer    *Thumbnailer
+	uploadCfg      config.UploadConfig
+	quotaCfg       config.QuotaConfig
+	trashRetention time.Duration
+	tagsCfg        config.TagsConfig
 }
 
-func NewMemeService(memeRepo repository.MemeRepository, minioSvc MinIOService, aiSvc AIService) MemeService {
+func NewMemeService(cfg *config.Config, memeRepo repository.MemeRepository, tagRepo repository.TagRepository, minioSvc MinIOService, aiSvc AIService, styleCatalog *StyleCatalog) MemeService {
 	return &memeService{
-		memeRepo:      memeRepo,
-		minioSvc:      minioSvc,
-		aiSvc:         aiSvc,
-		taskProcessor: nil,
+		memeRepo:       memeRepo,
+		tagRepo:        tagRepo,
+		minioSvc:       minioSvc,
+		aiSvc:          aiSvc,
+		taskProcessor:  nil,
+		styleCatalog:   styleCatalog,
+		imageFetcher:   NewImageFetcher(&cfg.Fetch),
+		importFetcher:  newImportFetcher(cfg),
+		thumbnailer:    NewThumbnailer(cfg, minioSvc),
+		uploadCfg:      cfg.Upload,
+		quotaCfg:       cfg.Quota,
+		trashRetention: cfg.Trash.Retention,
+		tagsCfg:        cfg.Tags,
 	}
 }
 
-func NewMemeServiceWithProcessor(memeRepo repository.MemeRepository, minioSvc MinIOService, aiSvc AIService, taskProcessor *TaskProcessor) MemeService {
+func NewMemeServiceWithProcessor(cfg *config.Config, memeRepo repository.MemeRepository, tagRepo repository.TagRepository, minioSvc MinIOService, aiSvc AIService, styleCatalog *StyleCatalog, taskProcessor *TaskProcessor) MemeService {
 	return &memeService{
-		memeRepo:      memeRepo,
-		minioSvc:      minioSvc,
-		aiSvc:         aiSvc,
-		taskProcessor: taskProcessor,
+		memeRepo:       memeRepo,
+		tagRepo:        tagRepo,
+		minioSvc:       minioSvc,
+		aiSvc:          aiSvc,
+		taskProcessor:  taskProcessor,
+		styleCatalog:   styleCatalog,
+		imageFetcher:   NewImageFetcher(&cfg.Fetch),
+		importFetcher:  newImportFetcher(cfg),
+		thumbnailer:    NewThumbnailer(cfg, minioSvc),
+		uploadCfg:      cfg.Upload,
+		quotaCfg:       cfg.Quota,
+		trashRetention: cfg.Trash.Retention,
+		tagsCfg:        cfg.Tags,
 	}
 }
 
@@ -53,23 +86,50 @@ func (s *memeService) CreateMeme(ctx context.Context, userID uuid.UUID, req Crea
 		isPublic = *req.IsPublic
 	}
 
+	if err := s.styleCatalog.Validate(ctx, req.Style); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.resolveTags(ctx, req.Tags, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
 	meme := &models.Meme{
 		UserID:      userID,
 		Prompt:      req.Prompt,
 		Style:       req.Style,
+		Kind:        models.MemeKindAI,
 		Status:      "pending",
 		IsPublic:    isPublic,
 		Width:       500,
 		Height:      500,
 		AspectRatio: "1:1",
+		Tags:        tags,
 	}
 
-	taskID, err := s.aiSvc.GenerateMeme(ctx, req.Prompt, req.Style)
+	task, err := s.aiSvc.GenerateMeme(ctx, req.Prompt, req.Style)
 	if err != nil {
+		if errors.Is(err, ErrStyleNotSupported) {
+			return nil, ErrStyleNotSupported
+		}
 		return nil, fmt.Errorf("failed to create AI task: %w", err)
 	}
 
-	meme.TaskID = taskID
+	meme.TaskID = task.TaskID
+	meme.AIBackend = task.Backend
+	meme.Generation = &models.GenerationInfo{
+		TaskID:      task.TaskID,
+		RequestID:   logger.RequestID(ctx),
+		TraceParent: tracing.TraceParent(ctx),
+		Style:       req.Style,
+		StartedAt:   &startedAt,
+	}
 
 	if err := s.memeRepo.Create(ctx, meme); err != nil {
 		return nil, fmt.Errorf("failed to create meme: %w", err)
@@ -77,13 +137,37 @@ func (s *memeService) CreateMeme(ctx context.Context, userID uuid.UUID, req Crea
 
 	if s.taskProcessor != nil {
 		if err := s.taskProcessor.AddTask(meme.ID); err != nil {
-			log.Printf("Warning: failed to add task to processor: %v", err)
+			slog.WarnContext(ctx, "failed to add task to processor", "meme_id", meme.ID, "error", err)
 		}
 	}
 
 	return meme, nil
 }
 
+// checkQuota проверяет, может ли пользователь создать ещё один мем. Параллельные
+// запросы могут превысить квоту на несколько мемов - точнее она и не нужна
+func (s *memeService) checkQuota(ctx context.Context, userID uuid.UUID) error {
+	if s.quotaCfg.Memes <= 0 {
+		return nil
+	}
+	count, err := s.memeRepo.CountCreatedSince(ctx, userID, time.Now().Add(-s.quotaCfg.Window))
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %w", err)
+	}
+	if count >= int64(s.quotaCfg.Memes) {
+		return fmt.Errorf("%w: %d memes per %s", ErrQuotaExceeded, s.quotaCfg.Memes, s.quotaCfg.Window)
+	}
+	return nil
+}
+
+// newImportFetcher - ImageFetcher для импорта пользователем: свои хосты и лимит размера загрузки
+func newImportFetcher(cfg *config.Config) *ImageFetcher {
+	fetchCfg := cfg.Fetch
+	fetchCfg.AllowedHosts = cfg.Upload.ImportAllowedHosts
+	fetchCfg.MaxBytes = cfg.Upload.MaxBytes
+	return NewImageFetcher(&fetchCfg)
+}
+
 // CreateTemplateMeme создает шаблонный мем через memegen.link API (синхронно)
 func (s *memeService) CreateTemplateMeme(ctx context.Context, userID uuid.UUID, req CreateTemplateMemeRequest) (*models.Meme, error) {
 	isPublic := true
@@ -100,6 +184,17 @@ func (s *memeService) CreateTemplateMeme(ctx context.Context, userID uuid.UUID,
 		height = 512
 	}
 
+	tags, err := s.resolveTags(ctx, req.Tags, req.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+
 	// Вызываем AI-сервис для генерации шаблонного мема
 	templateReq := GenerateTemplateRequest{
 		Context: req.Context,
@@ -113,139 +208,180 @@ func (s *memeService) CreateTemplateMeme(ctx context.Context, userID uuid.UUID,
 	}
 
 	// Скачиваем изображение с memegen.link
-	imageData, err := s.downloadImage(ctx, templateResp.URL)
+	image, err := s.imageFetcher.Fetch(ctx, templateResp.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download image from memegen: %w", err)
 	}
 
 	// Загружаем в MinIO
-	objectName := fmt.Sprintf("memes/%s.png", uuid.New().String())
-	if err := s.minioSvc.UploadBytes(ctx, objectName, imageData); err != nil {
+	objectName := fmt.Sprintf("memes/%s.%s", uuid.New().String(), image.Ext)
+	if err := s.minioSvc.UploadBytes(ctx, objectName, image.Data); err != nil {
 		return nil, fmt.Errorf("failed to upload image to MinIO: %w", err)
 	}
 
 	// Получаем публичный URL нашего MinIO
 	imageURL := s.minioSvc.GetMemeURL(objectName)
+	thumbnailURL := s.thumbnailer.Store(ctx, objectName, image.Data)
 
 	// Создаем запись мема со статусом completed (синхронная генерация)
 	meme := &models.Meme{
-		UserID:      userID,
-		Prompt:      req.Context,
-		Style:       templateResp.Template,
-		ImageURL:    imageURL,
-		Status:      "completed",
-		IsPublic:    isPublic,
-		Width:       width,
-		Height:      height,
-		AspectRatio: fmt.Sprintf("%d:%d", width, height),
-	}
+		UserID:       userID,
+		Prompt:       req.Context,
+		Kind:         models.MemeKindTemplate,
+		ImageURL:     imageURL,
+		ThumbnailURL: thumbnailURL,
+		Status:       "completed",
+		IsPublic:     isPublic,
+		Width:        width,
+		Height:       height,
+		AspectRatio:  fmt.Sprintf("%d:%d", width, height),
+		Tags:         tags,
+		Generation: &models.GenerationInfo{
+			TemplateID: templateResp.Template,
+			Captions:   templateResp.GetTextStrings(),
+			RequestID:  logger.RequestID(ctx),
+			StartedAt:  &startedAt,
+		},
+	}
+	meme.MarkGenerationCompleted(time.Now())
 
 	if err := s.memeRepo.Create(ctx, meme); err != nil {
-		// Удаляем файл из MinIO если не удалось создать запись
+		// Удаляем файлы из MinIO если не удалось создать запись
 		s.minioSvc.DeleteMeme(ctx, objectName)
+		s.thumbnailer.Delete(ctx, thumbnailURL, imageURL)
 		return nil, fmt.Errorf("failed to create meme: %w", err)
 	}
 
 	return meme, nil
 }
 
-// downloadImage скачивает изображение по URL и возвращает байты
-func (s *memeService) downloadImage(ctx context.Context, imageURL string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+func (s *memeService) GetMeme(ctx context.Context, memeID uuid.UUID) (*models.Meme, error) {
+	meme, err := s.memeRepo.GetByID(ctx, memeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, ErrMemeNotFound
 	}
+	return meme, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func (s *memeService) GetUserMemes(ctx context.Context, userID uuid.UUID, filter repository.MemeFilter, page PageRequest) (*MemeList, error) {
+	pagination, err := page.toPagination(filter.Search)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download image: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	memes, err := s.memeRepo.GetByUserID(ctx, userID, filter, pagination)
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read image data: %w", err)
+	list := newMemeList(memes, page.Limit, filter.Search)
+	if !page.SkipTotal {
+		total, err := s.memeRepo.CountByUserID(ctx, userID, filter)
+		if err != nil {
+			return nil, err
+		}
+		list.Total = &total
 	}
 
-	return data, nil
+	return list, nil
 }
 
-func (s *memeService) UploadMemeImage(ctx context.Context, memeID uuid.UUID, file *multipart.FileHeader) error {
-	objectName, err := s.minioSvc.UploadMeme(ctx, file)
+func (s *memeService) GetPublicMemes(ctx context.Context, filter repository.MemeFilter, page PageRequest) (*MemeList, error) {
+	pagination, err := page.toPagination(filter.Search)
 	if err != nil {
-		return fmt.Errorf("failed to upload image: %w", err)
+		return nil, err
 	}
 
-	meme, err := s.memeRepo.GetByID(ctx, memeID)
+	memes, err := s.memeRepo.GetPublicMemes(ctx, filter, pagination)
 	if err != nil {
-		s.minioSvc.DeleteMeme(ctx, objectName)
-		return fmt.Errorf("failed to get meme: %w", err)
+		return nil, err
 	}
 
-	meme.ImageURL = s.minioSvc.GetMemeURL(objectName)
-	meme.Status = "completed"
-
-	if err := s.memeRepo.Update(ctx, meme); err != nil {
-		s.minioSvc.DeleteMeme(ctx, objectName)
-		return fmt.Errorf("failed to update meme: %w", err)
+	list := newMemeList(memes, page.Limit, filter.Search)
+	if !page.SkipTotal {
+		total, err := s.memeRepo.CountPublicMemes(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		list.Total = &total
 	}
 
-	return nil
+	return list, nil
 }
 
-func (s *memeService) GetMeme(ctx context.Context, memeID uuid.UUID) (*models.Meme, error) {
-	meme, err := s.memeRepo.GetByID(ctx, memeID)
+func (s *memeService) GetAllMemes(ctx context.Context, filter repository.MemeFilter, page PageRequest) (*MemeList, error) {
+	pagination, err := page.toPagination(filter.Search)
 	if err != nil {
-		return nil, ErrMemeNotFound
+		return nil, err
 	}
-	return meme, nil
-}
 
-func (s *memeService) GetUserMemes(ctx context.Context, userID uuid.UUID, limit, offset int, search string) ([]*models.Meme, int64, error) {
-	memes, err := s.memeRepo.GetByUserID(ctx, userID, limit, offset, search)
+	memes, err := s.memeRepo.List(ctx, filter, pagination)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	total, err := s.memeRepo.CountByUserID(ctx, userID, search)
-	if err != nil {
-		return nil, 0, err
+	list := newMemeList(memes, page.Limit, filter.Search)
+	if !page.SkipTotal {
+		total, err := s.memeRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		list.Total = &total
 	}
 
-	return memes, total, nil
+	return list, nil
 }
 
-func (s *memeService) GetPublicMemes(ctx context.Context, limit, offset int, search string) ([]*models.Meme, int64, error) {
-	memes, err := s.memeRepo.GetPublicMemes(ctx, limit, offset, search)
+func (s *memeService) UpdateMeme(ctx context.Context, userID, memeID uuid.UUID, req UpdateMemeRequest) (*models.Meme, error) {
+	meme, err := s.memeRepo.GetByID(ctx, memeID)
 	if err != nil {
-		return nil, 0, err
+		return nil, ErrMemeNotFound
 	}
 
-	total, err := s.memeRepo.CountPublicMemes(ctx, search)
-	if err != nil {
-		return nil, 0, err
+	if meme.UserID != userID {
+		return nil, ErrUnauthorized
 	}
 
-	return memes, total, nil
-}
+	unmodifiedSince := meme.UpdatedAt
+	if req.UpdatedAt != nil {
+		if !req.UpdatedAt.Truncate(time.Microsecond).Equal(meme.UpdatedAt.Truncate(time.Microsecond)) {
+			return nil, ErrMemeModified
+		}
+		unmodifiedSince = *req.UpdatedAt
+	}
 
-func (s *memeService) GetAllMemes(ctx context.Context, limit, offset int) ([]*models.Meme, int64, error) {
-	memes, err := s.memeRepo.List(ctx, limit, offset)
-	if err != nil {
-		return nil, 0, err
+	if req.Title != nil {
+		meme.Title = *req.Title
+	}
+	if req.Description != nil {
+		meme.Description = *req.Description
+	}
+	if req.Prompt != nil {
+		meme.Prompt = *req.Prompt
+	}
+	if req.IsPublic != nil {
+		meme.IsPublic = *req.IsPublic
 	}
 
-	total, err := s.memeRepo.Count(ctx)
-	if err != nil {
-		return nil, 0, err
+	var tags []models.Tag
+	if req.Tags != nil {
+		if tags, err = s.resolveTags(ctx, *req.Tags, ""); err != nil {
+			return nil, err
+		}
+		// Пустой, но не nil список снимает все теги
+		if tags == nil {
+			tags = []models.Tag{}
+		}
+	}
+
+	if err := s.memeRepo.UpdateMetadata(ctx, meme, tags, unmodifiedSince.Truncate(time.Microsecond)); err != nil {
+		if errors.Is(err, repository.ErrMemeModified) {
+			return nil, ErrMemeModified
+		}
+		return nil, fmt.Errorf("failed to update meme: %w", err)
 	}
 
-	return memes, total, nil
+	return meme, nil
 }
 
 func (s *memeService) DeleteMeme(ctx context.Context, userID, memeID uuid.UUID) error {
@@ -265,6 +401,46 @@ func (s *memeService) DeleteMeme(ctx context.Context, userID, memeID uuid.UUID)
 	return nil
 }
 
+func (s *memeService) GetTrash(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*TrashedMeme, int64, error) {
+	memes, err := s.memeRepo.GetDeletedByUserID(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.memeRepo.CountDeletedByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	trashed := make([]*TrashedMeme, 0, len(memes))
+	for _, meme := range memes {
+		trashed = append(trashed, &TrashedMeme{
+			Meme:      meme,
+			DeletedAt: meme.DeletedAt.Time,
+			PurgeAt:   meme.DeletedAt.Time.Add(s.trashRetention),
+		})
+	}
+
+	return trashed, total, nil
+}
+
+func (s *memeService) RestoreMeme(ctx context.Context, userID, memeID uuid.UUID) (*models.Meme, error) {
+	meme, err := s.memeRepo.GetDeletedByID(ctx, memeID)
+	if err != nil {
+		return nil, ErrMemeNotFound
+	}
+
+	if meme.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	if err := s.memeRepo.Restore(ctx, memeID); err != nil {
+		return nil, fmt.Errorf("failed to restore meme: %w", err)
+	}
+
+	return s.memeRepo.GetByID(ctx, memeID)
+}
+
 func (s *memeService) CheckTaskStatus(ctx context.Context, memeID uuid.UUID) (*models.Meme, error) {
 	meme, err := s.memeRepo.GetByID(ctx, memeID)
 	if err != nil {
@@ -279,11 +455,15 @@ func (s *memeService) CheckTaskStatus(ctx context.Context, memeID uuid.UUID) (*m
 		return nil, fmt.Errorf("meme has no task ID")
 	}
 
-	taskStatus, err := s.aiSvc.GetTaskStatus(ctx, meme.TaskID)
+	taskStatus, err := s.aiSvc.GetTaskStatus(ctx, meme.AIBackend, meme.TaskID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check task status: %w", err)
 	}
 
+	if taskStatus.Model != "" {
+		meme.SetGenerationModel(taskStatus.Model)
+	}
+
 	if taskStatus.Status == "completed" || taskStatus.Status == "SUCCESS" {
 		if err := s.ProcessCompletedTask(ctx, memeID); err != nil {
 			return nil, fmt.Errorf("failed to process completed task: %w", err)
@@ -292,7 +472,7 @@ func (s *memeService) CheckTaskStatus(ctx context.Context, memeID uuid.UUID) (*m
 	}
 
 	meme.Status = taskStatus.Status
-	if err := s.memeRepo.Update(ctx, meme); err != nil {
+	if err := s.memeRepo.UpdateGeneration(ctx, meme); err != nil {
 		return nil, fmt.Errorf("failed to update meme status: %w", err)
 	}
 
@@ -309,7 +489,7 @@ func (s *memeService) ProcessCompletedTask(ctx context.Context, memeID uuid.UUID
 		return fmt.Errorf("meme has no task ID")
 	}
 
-	imageData, err := s.aiSvc.GetTaskResult(ctx, meme.TaskID)
+	imageData, err := s.aiSvc.GetTaskResult(ctx, meme.AIBackend, meme.TaskID)
 	if err != nil {
 		return fmt.Errorf("failed to get task result: %w", err)
 	}
@@ -321,16 +501,137 @@ func (s *memeService) ProcessCompletedTask(ctx context.Context, memeID uuid.UUID
 	}
 
 	meme.ImageURL = s.minioSvc.GetMemeURL(objectName)
+	meme.ThumbnailURL = s.thumbnailer.Store(ctx, objectName, imageData)
 	meme.Status = "completed"
+	meme.MarkGenerationCompleted(time.Now())
 
-	if err := s.memeRepo.Update(ctx, meme); err != nil {
+	if err := s.memeRepo.UpdateGeneration(ctx, meme); err != nil {
 		s.minioSvc.DeleteMeme(ctx, objectName)
+		s.thumbnailer.Delete(ctx, meme.ThumbnailURL, meme.ImageURL)
 		return fmt.Errorf("failed to update meme: %w", err)
 	}
 
 	return nil
 }
 
-func (s *memeService) GetAvailableStyles(ctx context.Context) ([]string, error) {
-	return s.aiSvc.GetAvailableStyles(ctx)
+// RegenerateMeme заново отправляет промпт и стиль мема в AI-сервис. Мем возвращается
+// в pending с новой задачей; старое изображение остаётся, пока новое не будет готово
+func (s *memeService) RegenerateMeme(ctx context.Context, memeID uuid.UUID) (*models.Meme, error) {
+	meme, err := s.memeRepo.GetByID(ctx, memeID)
+	if err != nil {
+		return nil, ErrMemeNotFound
+	}
+	if meme.Kind != models.MemeKindAI {
+		return nil, ErrRegenerateUnsupported
+	}
+
+	startedAt := time.Now()
+	task, err := s.aiSvc.GenerateMeme(ctx, meme.Prompt, meme.Style)
+	if err != nil {
+		if errors.Is(err, ErrStyleNotSupported) {
+			return nil, ErrStyleNotSupported
+		}
+		return nil, fmt.Errorf("failed to create AI task: %w", err)
+	}
+
+	meme.TaskID = task.TaskID
+	meme.AIBackend = task.Backend
+	meme.Status = "pending"
+	meme.GenerationTimeMs = 0
+	meme.Generation = &models.GenerationInfo{
+		TaskID:      task.TaskID,
+		RequestID:   logger.RequestID(ctx),
+		TraceParent: tracing.TraceParent(ctx),
+		Style:       meme.Style,
+		StartedAt:   &startedAt,
+	}
+
+	if err := s.memeRepo.UpdateGeneration(ctx, meme); err != nil {
+		return nil, fmt.Errorf("failed to update meme: %w", err)
+	}
+
+	if s.taskProcessor != nil {
+		if err := s.taskProcessor.AddTask(meme.ID); err != nil {
+			slog.WarnContext(ctx, "failed to add task to processor", "meme_id", meme.ID, "error", err)
+		}
+	}
+
+	return meme, nil
+}
+
+func (s *memeService) GetAvailableStyles(ctx context.Context) ([]Style, error) {
+	return s.styleCatalog.Styles(ctx)
+}
+
+func (s *memeService) GetPopularTags(ctx context.Context, limit int) ([]*repository.TagUsage, error) {
+	return s.tagRepo.GetPopular(ctx, limit)
+}
+
+// resolveTags нормализует теги пользователя, при включённых автотегах дополняет их
+// словами из prompt и возвращает сохранённые в БД теги
+func (s *memeService) resolveTags(ctx context.Context, names []string, prompt string) ([]models.Tag, error) {
+	tags := buildTags(names)
+	if len(tags) > s.tagsCfg.MaxPerMeme {
+		return nil, ErrTooManyTags
+	}
+
+	if s.tagsCfg.AutoFromPrompt && prompt != "" {
+		autoLimit := s.tagsCfg.AutoLimit
+		if free := s.tagsCfg.MaxPerMeme - len(tags); free < autoLimit {
+			autoLimit = free
+		}
+		if autoLimit > 0 {
+			withAuto := append(append([]string{}, names...), ExtractTags(prompt, autoLimit)...)
+			tags = buildTags(withAuto)
+			if len(tags) > s.tagsCfg.MaxPerMeme {
+				tags = tags[:s.tagsCfg.MaxPerMeme]
+			}
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	saved, err := s.tagRepo.GetOrCreate(ctx, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *memeService) GetGenerationStats(ctx context.Context, from, to time.Time) (*GenerationStatsReport, error) {
+	stats, err := s.memeRepo.GetGenerationStats(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generation stats: %w", err)
+	}
+
+	report := &GenerationStatsReport{
+		From:   from,
+		To:     to,
+		Styles: make([]*GenerationStyleStats, 0, len(stats)),
+	}
+
+	for _, st := range stats {
+		report.Total += st.Total
+		report.Completed += st.Completed
+		report.Failed += st.Failed
+		report.Styles = append(report.Styles, &GenerationStyleStats{
+			GenerationStats: st,
+			SuccessRate:     rate(st.Completed, st.Total),
+			FailureRate:     rate(st.Failed, st.Total),
+		})
+	}
+
+	report.SuccessRate = rate(report.Completed, report.Total)
+	report.FailureRate = rate(report.Failed, report.Total)
+
+	return report, nil
+}
+
+func rate(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
 }
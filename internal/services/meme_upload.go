@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"memology-backend/internal/logger"
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFileTooLarge = errors.New("file is too large")
+	ErrImportFailed = errors.New("failed to import image")
+)
+
+// uploadFormats - форматы, которые декодирует стандартная библиотека, и расширения файлов в хранилище.
+// Скачивание по URL принимает тот же набор (fetchImageTypes)
+var uploadFormats = map[string]string{
+	"png":  "png",
+	"jpeg": "jpg",
+	"gif":  "gif",
+}
+
+// uploadedImage - картинка, прошедшая проверку
+type uploadedImage struct {
+	data   []byte
+	ext    string
+	hash   string
+	width  int
+	height int
+}
+
+// UploadMeme создаёт завершённый мем из картинки пользователя. Если у пользователя
+// уже есть мем с такой же картинкой, возвращается он, а created = false
+func (s *memeService) UploadMeme(ctx context.Context, userID uuid.UUID, req UploadMemeRequest, file *multipart.FileHeader) (*models.Meme, bool, error) {
+	if file.Size > int64(s.uploadCfg.MaxBytes) {
+		return nil, false, fmt.Errorf("%w: %d bytes, limit %d", ErrFileTooLarge, file.Size, s.uploadCfg.MaxBytes)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, int64(s.uploadCfg.MaxBytes)+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return s.createUploadedMeme(ctx, userID, req, data, "")
+}
+
+// ImportMeme скачивает картинку по URL через ImageFetcher и создаёт из неё мем так же, как UploadMeme
+func (s *memeService) ImportMeme(ctx context.Context, userID uuid.UUID, req ImportMemeRequest) (*models.Meme, bool, error) {
+	fetched, err := s.importFetcher.Fetch(ctx, req.URL)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrImportFailed, err)
+	}
+	return s.createUploadedMeme(ctx, userID, req.UploadMemeRequest, fetched.Data, req.URL)
+}
+
+func (s *memeService) createUploadedMeme(ctx context.Context, userID uuid.UUID, req UploadMemeRequest, data []byte, sourceURL string) (*models.Meme, bool, error) {
+	img, err := s.validateImage(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.memeRepo.GetByContentHash(ctx, userID, img.hash)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, fmt.Errorf("failed to check duplicates: %w", err)
+	}
+
+	isPublic := true
+	if req.IsPublic != nil {
+		isPublic = *req.IsPublic
+	}
+
+	tags, err := s.resolveTags(ctx, req.Tags, req.Caption)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Повторная загрузка уже сохранённой картинки выше квоту не расходует
+	if err := s.checkQuota(ctx, userID); err != nil {
+		return nil, false, err
+	}
+
+	objectName := fmt.Sprintf("memes/%s.%s", uuid.New().String(), img.ext)
+	if err := s.minioSvc.UploadBytes(ctx, objectName, img.data); err != nil {
+		return nil, false, fmt.Errorf("failed to upload image to MinIO: %w", err)
+	}
+
+	now := time.Now()
+	meme := &models.Meme{
+		UserID:       userID,
+		Prompt:       req.Caption,
+		Title:        req.Title,
+		Kind:         models.MemeKindUpload,
+		ImageURL:     s.minioSvc.GetMemeURL(objectName),
+		ThumbnailURL: s.thumbnailer.Store(ctx, objectName, img.data),
+		ContentHash:  img.hash,
+		Status:       "completed",
+		IsPublic:     isPublic,
+		Width:        img.width,
+		Height:       img.height,
+		AspectRatio:  fmt.Sprintf("%d:%d", img.width, img.height),
+		Tags:         tags,
+		Generation: &models.GenerationInfo{
+			SourceURL:   sourceURL,
+			RequestID:   logger.RequestID(ctx),
+			CompletedAt: &now,
+		},
+	}
+
+	if err := s.memeRepo.Create(ctx, meme); err != nil {
+		s.minioSvc.DeleteMeme(ctx, objectName)
+		s.thumbnailer.Delete(ctx, meme.ThumbnailURL, meme.ImageURL)
+		// Ту же картинку параллельно загрузил другой запрос - возвращается его мем
+		if errors.Is(err, repository.ErrDuplicateContent) {
+			existing, getErr := s.memeRepo.GetByContentHash(ctx, userID, img.hash)
+			if getErr != nil {
+				return nil, false, fmt.Errorf("failed to get duplicate meme: %w", getErr)
+			}
+			return existing, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to create meme: %w", err)
+	}
+
+	return meme, true, nil
+}
+
+// validateImage проверяет размер файла, формат по содержимому и размеры в пикселях.
+// Картинка целиком не декодируется: заголовка достаточно, чтобы отсечь битые файлы и гигантские размеры
+func (s *memeService) validateImage(data []byte) (*uploadedImage, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidFile)
+	}
+	if len(data) > s.uploadCfg.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrFileTooLarge, len(data), s.uploadCfg.MaxBytes)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: expected PNG, JPEG or GIF image", ErrInvalidFile)
+	}
+	ext, ok := uploadFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported image format %q", ErrInvalidFile, format)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > s.uploadCfg.MaxDimension || cfg.Height > s.uploadCfg.MaxDimension {
+		return nil, fmt.Errorf("%w: image is %dx%d, limit %d pixels per side", ErrInvalidFile, cfg.Width, cfg.Height, s.uploadCfg.MaxDimension)
+	}
+
+	sum := sha256.Sum256(data)
+	return &uploadedImage{
+		data:   data,
+		ext:    ext,
+		hash:   hex.EncodeToString(sum[:]),
+		width:  cfg.Width,
+		height: cfg.Height,
+	}, nil
+}
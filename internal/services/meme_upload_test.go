@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/repository/memory"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// racingMemes не находит мем по хэшу первые hidden раз: так выглядит загрузка, которая
+// проверила дубликаты до того, как параллельный запрос вставил ту же картинку
+type racingMemes struct {
+	repository.MemeRepository
+	hidden int
+}
+
+func (r *racingMemes) GetByContentHash(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Meme, error) {
+	if r.hidden > 0 {
+		r.hidden--
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.MemeRepository.GetByContentHash(ctx, userID, contentHash)
+}
+
+func TestUploadReturnsConcurrentlyCreatedMeme(t *testing.T) {
+	ctx := context.Background()
+	storageDir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			Backend:        "local",
+			LocalDir:       storageDir,
+			LocalPublicURL: "http://storage.test/files",
+		},
+		Upload: config.UploadConfig{MaxBytes: 1 << 20, MaxDimension: 2048},
+		Tags:   config.TagsConfig{MaxPerMeme: 10},
+	}
+	storage, err := NewStorageService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := memory.NewDB()
+	user := &models.User{Username: "author", Email: "author@example.com", PasswordHash: "hash"}
+	if err := memory.NewUserRepository(db).Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	memes := &racingMemes{MemeRepository: memory.NewMemeRepository(db), hidden: 2}
+	svc := NewMemeService(cfg, memes, memory.NewTagRepository(db), storage, NewAIService(&cfg.AI), nil).(*memeService)
+
+	data := testPNG(t)
+	first, created, err := svc.createUploadedMeme(ctx, user.ID, UploadMemeRequest{Title: "Первая"}, data, "")
+	if err != nil || !created {
+		t.Fatalf("first upload: created %v, %v", created, err)
+	}
+
+	second, created, err := svc.createUploadedMeme(ctx, user.ID, UploadMemeRequest{Title: "Вторая"}, data, "")
+	if err != nil {
+		t.Fatalf("concurrent duplicate upload: %v", err)
+	}
+	if created || second.ID != first.ID {
+		t.Fatalf("expected existing meme %s, got %s (created %v)", first.ID, second.ID, created)
+	}
+
+	// Картинка проигравшей загрузки удаляется из хранилища
+	objects, err := filepath.Glob(filepath.Join(storageDir, "memes", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 stored image, got %v", objects)
+	}
+}
+
+func TestUploadQuota(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			Backend:        "local",
+			LocalDir:       t.TempDir(),
+			LocalPublicURL: "http://storage.test/files",
+		},
+		Upload: config.UploadConfig{MaxBytes: 1 << 20, MaxDimension: 2048},
+		Tags:   config.TagsConfig{MaxPerMeme: 10},
+		Quota:  config.QuotaConfig{Memes: 1, Window: time.Hour},
+	}
+	storage, err := NewStorageService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := memory.NewDB()
+	user := &models.User{Username: "author", Email: "author@example.com", PasswordHash: "hash"}
+	if err := memory.NewUserRepository(db).Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	memes := memory.NewMemeRepository(db)
+	svc := NewMemeService(cfg, memes, memory.NewTagRepository(db), storage, NewAIService(&cfg.AI), nil).(*memeService)
+
+	first, created, err := svc.createUploadedMeme(ctx, user.ID, UploadMemeRequest{}, testPNG(t), "")
+	if err != nil || !created {
+		t.Fatalf("first upload: created %v, %v", created, err)
+	}
+
+	// Та же картинка возвращает существующий мем и квоту не расходует
+	if again, _, err := svc.createUploadedMeme(ctx, user.ID, UploadMemeRequest{}, testPNG(t), ""); err != nil || again.ID != first.ID {
+		t.Fatalf("duplicate upload over quota must return existing meme, got %v", err)
+	}
+
+	if _, _, err := svc.createUploadedMeme(ctx, user.ID, UploadMemeRequest{}, encodeTestImage(t, "png", 8, 8), ""); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Удаление в корзину квоту не возвращает
+	if err := memes.Delete(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.createUploadedMeme(ctx, user.ID, UploadMemeRequest{}, encodeTestImage(t, "png", 8, 8), ""); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("deleted memes must count towards quota, got %v", err)
+	}
+}
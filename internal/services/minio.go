@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"memology-backend/internal/config"
+	"memology-backend/internal/metrics"
+	"memology-backend/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
@@ -20,6 +25,16 @@ type MinIOService interface {
 	UploadBytes(ctx context.Context, objectName string, data []byte) error
 	DeleteMeme(ctx context.Context, objectName string) error
 	GetMemeURL(objectName string) string
+	ObjectNameFromURL(url string) (string, bool)
+	ListObjects(ctx context.Context, prefix string) ([]StoredObject, error)
+	Ping(ctx context.Context) error
+}
+
+// StoredObject - объект в бакете с метаданными, нужными для сборки мусора
+type StoredObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
 }
 
 type minioService struct {
@@ -29,9 +44,15 @@ type minioService struct {
 }
 
 func NewMinIOService(cfg *config.MinIOConfig) (MinIOService, error) {
+	transport, err := minio.DefaultTransport(cfg.UseSSL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio transport: %w", err)
+	}
+
 	client, err := minio.New(cfg.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
-		Secure: cfg.UseSSL,
+		Creds:     credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:    cfg.UseSSL,
+		Transport: tracing.Transport(transport, "minio"),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create minio client: %w", err)
@@ -92,6 +113,7 @@ func (s *minioService) UploadMeme(ctx context.Context, file *multipart.FileHeade
 	_, err = s.client.PutObject(ctx, s.bucket, objectName, src, file.Size, minio.PutObjectOptions{
 		ContentType: contentType,
 	})
+	metrics.ObserveStorageUpload(file.Size, err)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file: %w", err)
 	}
@@ -104,8 +126,9 @@ func (s *minioService) UploadBytes(ctx context.Context, objectName string, data
 		bytes.NewReader(data),
 		int64(len(data)),
 		minio.PutObjectOptions{
-			ContentType: "image/jpeg",
+			ContentType: http.DetectContentType(data),
 		})
+	metrics.ObserveStorageUpload(int64(len(data)), err)
 	if err != nil {
 		return fmt.Errorf("failed to upload bytes: %w", err)
 	}
@@ -124,6 +147,50 @@ func (s *minioService) GetMemeURL(objectName string) string {
 	return fmt.Sprintf("%s/%s/%s", s.publicURL, s.bucket, objectName)
 }
 
+// ObjectNameFromURL возвращает имя объекта для URL, выданного GetMemeURL.
+// Для чужих URL (например, memegen.link) возвращает false.
+func (s *minioService) ObjectNameFromURL(url string) (string, bool) {
+	prefix := fmt.Sprintf("%s/%s/", s.publicURL, s.bucket)
+	if !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	objectName := strings.TrimPrefix(url, prefix)
+	if objectName == "" {
+		return "", false
+	}
+	return objectName, true
+}
+
+// Ping проверяет, что хранилище доступно и бакет существует
+func (s *minioService) Ping(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", s.bucket)
+	}
+	return nil
+}
+
+func (s *minioService) ListObjects(ctx context.Context, prefix string) ([]StoredObject, error) {
+	var objects []StoredObject
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", object.Err)
+		}
+		objects = append(objects, StoredObject{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return objects, nil
+}
+
 func (s *minioService) GetMemeFile(ctx context.Context, objectName string) (io.ReadCloser, error) {
 	object, err := s.client.GetObject(ctx, s.bucket, objectName, minio.GetObjectOptions{})
 	if err != nil {
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest - параметры страницы: Cursor из next_cursor предыдущего ответа
+// или Offset для старого постраничного API. SkipTotal отключает подсчёт total
+type PageRequest struct {
+	Limit     int
+	Offset    int
+	Cursor    string
+	SkipTotal bool
+}
+
+// MemeList - страница мемов. Total равен nil, если подсчёт был отключён,
+// NextCursor пуст, если дальше мемов нет
+type MemeList struct {
+	Memes      []*models.Meme
+	Total      *int64
+	NextCursor string
+}
+
+// toPagination декодирует курсор и запрашивает на один мем больше, чтобы понять, есть ли следующая страница
+func (p PageRequest) toPagination(search string) (repository.Pagination, error) {
+	page := repository.Pagination{Limit: p.Limit + 1, Offset: p.Offset}
+	if p.Cursor == "" {
+		return page, nil
+	}
+
+	cursor, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return page, err
+	}
+	if (search != "") != (cursor.Score != nil) {
+		return page, ErrInvalidCursor
+	}
+
+	page.After = cursor
+	return page, nil
+}
+
+// newMemeList обрезает лишний мем и строит курсор по последнему показанному
+func newMemeList(memes []*models.Meme, limit int, search string) *MemeList {
+	list := &MemeList{Memes: memes}
+	if len(memes) <= limit {
+		return list
+	}
+
+	list.Memes = memes[:limit]
+	last := list.Memes[limit-1]
+	cursor := repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	if search != "" {
+		score := last.SearchRank
+		cursor.Score = &score
+	}
+	list.NextCursor = encodeCursor(cursor)
+	return list
+}
+
+func encodeCursor(cursor repository.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*repository.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor repository.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
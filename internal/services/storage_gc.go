@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/repository"
+)
+
+// memeObjectsPrefix - префикс, под которым лежат все изображения мемов
+const memeObjectsPrefix = "memes/"
+
+// StorageGC находит объекты в бакете, на которые не ссылается ни один мем,
+// и удаляет их (или только сообщает о них в режиме dry-run)
+type StorageGC struct {
+	memeRepo    repository.MemeRepository
+	minioSvc    MinIOService
+	interval    time.Duration
+	gracePeriod time.Duration
+	dryRun      bool
+	wg          sync.WaitGroup
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+type OrphanObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	Deleted      bool      `json:"deleted"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type StorageGCReport struct {
+	DryRun      bool           `json:"dry_run"`
+	GracePeriod string         `json:"grace_period"`
+	Scanned     int            `json:"scanned"`
+	Referenced  int            `json:"referenced"`
+	TooRecent   int            `json:"too_recent"`
+	Orphans     []OrphanObject `json:"orphans"`
+	Deleted     int            `json:"deleted"`
+	Failed      int            `json:"failed"`
+	StartedAt   time.Time      `json:"started_at"`
+	FinishedAt  time.Time      `json:"finished_at"`
+}
+
+func NewStorageGC(cfg *config.StorageGCConfig, memeRepo repository.MemeRepository, minioSvc MinIOService) *StorageGC {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &StorageGC{
+		memeRepo:    memeRepo,
+		minioSvc:    minioSvc,
+		interval:    cfg.Interval,
+		gracePeriod: cfg.GracePeriod,
+		dryRun:      cfg.DryRun,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func (gc *StorageGC) Start() {
+	slog.Info("starting storage GC", "interval", gc.interval, "grace_period", gc.gracePeriod, "dry_run", gc.dryRun)
+
+	gc.wg.Add(1)
+	go gc.loop()
+}
+
+func (gc *StorageGC) Stop() {
+	slog.Info("stopping storage GC")
+	gc.cancel()
+	gc.wg.Wait()
+	slog.Info("storage GC stopped")
+}
+
+func (gc *StorageGC) loop() {
+	defer gc.wg.Done()
+
+	ticker := time.NewTicker(gc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gc.ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := gc.Run(gc.ctx, gc.dryRun)
+			if err != nil {
+				slog.Error("storage GC run failed", "error", err)
+				continue
+			}
+			slog.Info("storage GC run completed", "scanned", report.Scanned, "orphans", len(report.Orphans),
+				"deleted", report.Deleted, "failed", report.Failed, "dry_run", report.DryRun)
+		}
+	}
+}
+
+// Run выполняет один проход сборки мусора. Объекты моложе grace period не трогаются,
+// чтобы не удалить файл, загруженный до создания записи мема.
+func (gc *StorageGC) Run(ctx context.Context, dryRun bool) (*StorageGCReport, error) {
+	report := &StorageGCReport{
+		DryRun:      dryRun,
+		GracePeriod: gc.gracePeriod.String(),
+		Orphans:     []OrphanObject{},
+		StartedAt:   time.Now(),
+	}
+
+	urls, err := gc.memeRepo.ListImageURLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list meme image URLs: %w", err)
+	}
+
+	referenced := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		if objectName, ok := gc.minioSvc.ObjectNameFromURL(url); ok {
+			referenced[objectName] = true
+		}
+	}
+
+	objects, err := gc.minioSvc.ListObjects(ctx, memeObjectsPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage objects: %w", err)
+	}
+
+	threshold := report.StartedAt.Add(-gc.gracePeriod)
+
+	for _, object := range objects {
+		report.Scanned++
+
+		if referenced[object.Key] {
+			report.Referenced++
+			continue
+		}
+
+		if object.LastModified.After(threshold) {
+			report.TooRecent++
+			continue
+		}
+
+		orphan := OrphanObject{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		}
+
+		if !dryRun {
+			if err := gc.minioSvc.DeleteMeme(ctx, object.Key); err != nil {
+				orphan.Error = err.Error()
+				report.Failed++
+			} else {
+				orphan.Deleted = true
+				report.Deleted++
+			}
+		}
+
+		report.Orphans = append(report.Orphans, orphan)
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+)
+
+var (
+	ErrUnknownStyle          = errors.New("unknown style")
+	ErrInvalidStyleOverride  = errors.New("style name is required")
+	ErrStyleOverrideNotFound = errors.New("style override not found")
+)
+
+// Style - стиль генерации в каталоге. Name - идентификатор, который передаётся в
+// CreateMemeRequest.Style; DisplayName - название для показа пользователю
+type Style struct {
+	Name        string `json:"name" example:"anime"`
+	DisplayName string `json:"display_name" example:"Аниме"`
+	Description string `json:"description,omitempty" example:"Рисованный стиль японской анимации"`
+	PreviewURL  string `json:"preview_url,omitempty" example:"https://example.com/styles/anime.jpg"`
+	Available   bool   `json:"available" example:"true"`
+}
+
+// StyleCatalogEntry - стиль для администратора: вместе со скрытыми и с правкой, если она есть
+type StyleCatalogEntry struct {
+	Style
+	Hidden   bool                  `json:"hidden"`
+	Override *models.StyleOverride `json:"override,omitempty"`
+}
+
+// StyleOverrideRequest - правка стиля администратором, пустые поля оставляют значения AI-сервиса
+type StyleOverrideRequest struct {
+	Hidden      bool   `json:"hidden" example:"false"`
+	DisplayName string `json:"display_name,omitempty" validate:"omitempty,max=100" example:"Аниме"`
+	Description string `json:"description,omitempty" validate:"omitempty,max=500" example:"Рисованный стиль японской анимации"`
+	PreviewURL  string `json:"preview_url,omitempty" validate:"omitempty,url,max=500" example:"https://example.com/styles/anime.jpg"`
+}
+
+// StyleCatalog кэширует список стилей AI-сервиса и накладывает на него правки
+// администратора. Стиль, который AI-сервис перестал отдавать, остаётся в каталоге
+// с Available=false: мемы с ним проходят валидацию, но генерация вернёт ошибку AI-сервиса.
+// Каталог обновляется в фоне; запрос обновляет его сам, только если данные старше TTL
+type StyleCatalog struct {
+	aiSvc           AIService
+	overrideRepo    repository.StyleOverrideRepository
+	ttl             time.Duration
+	refreshInterval time.Duration
+
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	order     []string
+	upstream  map[string]StyleObject
+	available map[string]bool
+	overrides map[string]*models.StyleOverride
+	checkedAt time.Time
+	lastErr   error
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewStyleCatalog(cfg *config.StylesConfig, aiSvc AIService, overrideRepo repository.StyleOverrideRepository) *StyleCatalog {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &StyleCatalog{
+		aiSvc:           aiSvc,
+		overrideRepo:    overrideRepo,
+		ttl:             cfg.CacheTTL,
+		refreshInterval: cfg.RefreshInterval,
+		upstream:        make(map[string]StyleObject),
+		available:       make(map[string]bool),
+		overrides:       make(map[string]*models.StyleOverride),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+func (c *StyleCatalog) Start() {
+	slog.Info("starting style catalog", "refresh_interval", c.refreshInterval, "ttl", c.ttl)
+
+	c.wg.Add(1)
+	go c.loop()
+}
+
+func (c *StyleCatalog) Stop() {
+	slog.Info("stopping style catalog")
+	c.cancel()
+	c.wg.Wait()
+	slog.Info("style catalog stopped")
+}
+
+func (c *StyleCatalog) loop() {
+	defer c.wg.Done()
+
+	c.refresh(c.ctx)
+
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.refresh(c.ctx)
+		}
+	}
+}
+
+func (c *StyleCatalog) refresh(ctx context.Context) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.reload(ctx)
+}
+
+func (c *StyleCatalog) fresh() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.ttl
+}
+
+// reload перечитывает стили AI-сервиса и правки. Ошибка AI-сервиса не стирает
+// известные стили, а только помечает их недоступными. Вызывается под refreshMu
+func (c *StyleCatalog) reload(ctx context.Context) {
+	overrides, overridesErr := c.overrideRepo.List(ctx)
+	if overridesErr != nil {
+		slog.WarnContext(ctx, "failed to load style overrides", "error", overridesErr)
+	}
+
+	styles, err := c.aiSvc.GetAvailableStyles(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "failed to refresh style catalog", "error", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if overridesErr == nil {
+		c.overrides = make(map[string]*models.StyleOverride, len(overrides))
+		for _, override := range overrides {
+			c.overrides[override.Name] = override
+		}
+	}
+
+	c.checkedAt = time.Now()
+	c.lastErr = err
+	c.available = make(map[string]bool, len(styles))
+	for _, style := range styles {
+		if _, known := c.upstream[style.Name]; !known {
+			c.order = append(c.order, style.Name)
+		}
+		c.upstream[style.Name] = style
+		c.available[style.Name] = true
+	}
+}
+
+// ensureFresh обновляет каталог, если он ещё не загружался или устарел.
+// Ошибка возвращается, только когда о стилях ничего не известно
+func (c *StyleCatalog) ensureFresh(ctx context.Context) error {
+	if !c.fresh() {
+		// Пока один запрос обновляет каталог, остальные ждут его результата
+		c.refreshMu.Lock()
+		if !c.fresh() {
+			c.reload(ctx)
+		}
+		c.refreshMu.Unlock()
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.order) == 0 && c.lastErr != nil {
+		return c.lastErr
+	}
+	return nil
+}
+
+// Styles возвращает видимые пользователям стили в порядке AI-сервиса
+func (c *StyleCatalog) Styles(ctx context.Context) ([]Style, error) {
+	entries, err := c.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	styles := make([]Style, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Hidden {
+			styles = append(styles, entry.Style)
+		}
+	}
+	return styles, nil
+}
+
+// Entries возвращает весь каталог вместе со скрытыми стилями
+func (c *StyleCatalog) Entries(ctx context.Context) ([]StyleCatalogEntry, error) {
+	if err := c.ensureFresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make([]StyleCatalogEntry, 0, len(c.order))
+	for _, name := range c.order {
+		entries = append(entries, c.entry(name))
+	}
+	return entries, nil
+}
+
+func (c *StyleCatalog) entry(name string) StyleCatalogEntry {
+	upstream := c.upstream[name]
+	entry := StyleCatalogEntry{
+		Style: Style{
+			Name:        name,
+			DisplayName: name,
+			Description: upstream.Description,
+			PreviewURL:  upstream.PreviewURL,
+			Available:   c.available[name],
+		},
+	}
+
+	if override, ok := c.overrides[name]; ok {
+		entry.Override = override
+		entry.Hidden = override.Hidden
+		if override.DisplayName != "" {
+			entry.DisplayName = override.DisplayName
+		}
+		if override.Description != "" {
+			entry.Description = override.Description
+		}
+		if override.PreviewURL != "" {
+			entry.PreviewURL = override.PreviewURL
+		}
+	}
+	return entry
+}
+
+// Validate проверяет стиль из запроса на генерацию: пустой стиль допустим,
+// неизвестный или скрытый администратором - ErrUnknownStyle
+func (c *StyleCatalog) Validate(ctx context.Context, style string) error {
+	if style == "" {
+		return nil
+	}
+	if err := c.ensureFresh(ctx); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, known := c.upstream[style]; !known {
+		return ErrUnknownStyle
+	}
+	if override, ok := c.overrides[style]; ok && override.Hidden {
+		return ErrUnknownStyle
+	}
+	return nil
+}
+
+// SetOverride сохраняет правку стиля. Правку можно задать и для стиля, которого
+// AI-сервис пока не отдаёт: она применится, когда стиль появится
+func (c *StyleCatalog) SetOverride(ctx context.Context, name string, req StyleOverrideRequest) (*models.StyleOverride, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidStyleOverride
+	}
+
+	override := &models.StyleOverride{
+		Name:        name,
+		Hidden:      req.Hidden,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Description: strings.TrimSpace(req.Description),
+		PreviewURL:  strings.TrimSpace(req.PreviewURL),
+		UpdatedAt:   time.Now(),
+	}
+	if err := c.overrideRepo.Upsert(ctx, override); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.overrides[name] = override
+	c.mu.Unlock()
+
+	return override, nil
+}
+
+func (c *StyleCatalog) DeleteOverride(ctx context.Context, name string) error {
+	if err := c.overrideRepo.Delete(ctx, name); err != nil {
+		if errors.Is(err, repository.ErrStyleOverrideNotFound) {
+			return ErrStyleOverrideNotFound
+		}
+		return err
+	}
+
+	c.mu.Lock()
+	delete(c.overrides, name)
+	c.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"memology-backend/internal/models"
+)
+
+const maxTagLength = 50
+
+// stopWords - частые слова, из которых не получаются осмысленные теги
+var stopWords = map[string]bool{
+	"когда": true, "если": true, "потому": true, "чтобы": true, "тоже": true,
+	"только": true, "очень": true, "этот": true, "меня": true, "тебя": true,
+	"есть": true, "было": true, "будет": true, "себя": true, "который": true,
+	"with": true, "that": true, "this": true, "when": true, "from": true,
+	"have": true, "your": true, "what": true, "just": true, "about": true,
+}
+
+// NormalizeTag приводит тег к slug: нижний регистр, без '#', пробелы и '_' заменяются на '-',
+// остаются только буквы (включая кириллицу), цифры и '-'
+func NormalizeTag(raw string) string {
+	raw = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(raw), "#"))
+
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(raw) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastDash = false
+		case r == '-' || r == '_' || unicode.IsSpace(r):
+			if b.Len() > 0 && !lastDash {
+				b.WriteRune('-')
+				lastDash = true
+			}
+		}
+	}
+
+	slug := strings.TrimRight(b.String(), "-")
+	if runes := []rune(slug); len(runes) > maxTagLength {
+		slug = strings.TrimRight(string(runes[:maxTagLength]), "-")
+	}
+	return slug
+}
+
+// buildTags превращает пользовательский ввод в теги без дубликатов (по slug)
+func buildTags(names []string) []models.Tag {
+	seen := make(map[string]bool, len(names))
+	tags := make([]models.Tag, 0, len(names))
+
+	for _, name := range names {
+		slug := NormalizeTag(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		tags = append(tags, models.Tag{
+			Name: strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(name), "#")),
+			Slug: slug,
+		})
+	}
+
+	return tags
+}
+
+// ExtractTags достаёт теги из текста промпта: сначала хэштеги, затем значимые слова
+// (не короче 4 букв и не из списка стоп-слов), не больше limit штук
+func ExtractTags(text string, limit int) []string {
+	var hashtags, keywords []string
+
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, "#") {
+			hashtags = append(hashtags, word)
+			continue
+		}
+
+		word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}))
+		if len([]rune(word)) < 4 || stopWords[word] {
+			continue
+		}
+		keywords = append(keywords, word)
+	}
+
+	result := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for _, word := range append(hashtags, keywords...) {
+		if len(result) >= limit {
+			break
+		}
+		slug := NormalizeTag(word)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		result = append(result, word)
+	}
+
+	return result
+}
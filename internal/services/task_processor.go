@@ -2,22 +2,38 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"memology-backend/internal/config"
+	"memology-backend/internal/logger"
+	"memology-backend/internal/models"
 	"memology-backend/internal/repository"
+	"memology-backend/internal/tracing"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
+// queuedTask - элемент очереди; enqueuedAt нужен, чтобы измерить ожидание свободного воркера
+type queuedTask struct {
+	memeID     uuid.UUID
+	enqueuedAt time.Time
+}
+
 type TaskProcessor struct {
 	memeRepo      repository.MemeRepository
 	aiSvc         AIService
 	minioSvc      MinIOService
-	taskQueue     chan uuid.UUID
+	thumbnailer   *Thumbnailer
+	taskQueue     chan queuedTask
 	workers       int
 	pollInterval  time.Duration
 	wg            sync.WaitGroup
@@ -25,6 +41,8 @@ type TaskProcessor struct {
 	cancel        context.CancelFunc
 	mu            sync.Mutex
 	processingIDs map[uuid.UUID]bool
+	busyWorkers   atomic.Int32
+	running       atomic.Bool
 }
 
 func NewTaskProcessor(
@@ -39,7 +57,8 @@ func NewTaskProcessor(
 		memeRepo:      memeRepo,
 		aiSvc:         aiSvc,
 		minioSvc:      minioSvc,
-		taskQueue:     make(chan uuid.UUID, cfg.TaskProcessor.QueueSize),
+		thumbnailer:   NewThumbnailer(cfg, minioSvc),
+		taskQueue:     make(chan queuedTask, cfg.TaskProcessor.QueueSize),
 		workers:       cfg.TaskProcessor.Workers,
 		pollInterval:  cfg.TaskProcessor.PollInterval,
 		ctx:           ctx,
@@ -49,25 +68,34 @@ func NewTaskProcessor(
 }
 
 func (tp *TaskProcessor) Start() {
-	log.Printf("Starting Task Processor with %d workers, poll interval: %v", tp.workers, tp.pollInterval)
+	tp.StartWorkers()
+
+	tp.wg.Add(1)
+	go tp.stuckTasksScanner()
+}
+
+// StartWorkers запускает только воркеры, без периодического поиска зависших мемов:
+// так memctl обрабатывает выбранные мемы, не трогая остальные
+func (tp *TaskProcessor) StartWorkers() {
+	slog.Info("starting task processor", "workers", tp.workers, "poll_interval", tp.pollInterval)
 
 	for i := 0; i < tp.workers; i++ {
 		tp.wg.Add(1)
 		go tp.worker(i + 1)
 	}
 
-	tp.wg.Add(1)
-	go tp.stuckTasksScanner()
+	tp.running.Store(true)
 
-	log.Println("Task Processor started successfully")
+	slog.Info("task processor started")
 }
 
 func (tp *TaskProcessor) Stop() {
-	log.Println("Stopping Task Processor...")
+	slog.Info("stopping task processor")
+	tp.running.Store(false)
 	tp.cancel()
 	close(tp.taskQueue)
 	tp.wg.Wait()
-	log.Println("Task Processor stopped")
+	slog.Info("task processor stopped")
 }
 
 func (tp *TaskProcessor) stuckTasksScanner() {
@@ -76,14 +104,14 @@ func (tp *TaskProcessor) stuckTasksScanner() {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
-	log.Println("Stuck tasks scanner started (checking every 1 hour)")
+	slog.Info("stuck tasks scanner started", "interval", time.Hour)
 
 	tp.scanAndReschedule()
 
 	for {
 		select {
 		case <-tp.ctx.Done():
-			log.Println("Stuck tasks scanner stopped")
+			slog.Info("stuck tasks scanner stopped")
 			return
 		case <-ticker.C:
 			tp.scanAndReschedule()
@@ -92,15 +120,15 @@ func (tp *TaskProcessor) stuckTasksScanner() {
 }
 
 func (tp *TaskProcessor) scanAndReschedule() {
-	log.Println("=== Scanning for stuck tasks ===")
+	slog.Debug("scanning for stuck tasks")
 
 	stuckMemes, err := tp.memeRepo.FindStuckMemes(tp.ctx, 30*time.Minute)
 	if err != nil {
-		log.Printf("Failed to find stuck memes: %v", err)
+		slog.Error("failed to find stuck memes", "error", err)
 		return
 	}
 
-	log.Printf("Found %d stuck memes to reschedule", len(stuckMemes))
+	slog.Info("found stuck memes", "count", len(stuckMemes))
 
 	for _, meme := range stuckMemes {
 		tp.mu.Lock()
@@ -108,38 +136,64 @@ func (tp *TaskProcessor) scanAndReschedule() {
 		tp.mu.Unlock()
 
 		if !isProcessing {
-			log.Printf("Rescheduling stuck meme %s (status: %s, updated: %s)",
-				meme.ID, meme.Status, meme.UpdatedAt.Format("2006-01-02 15:04:05"))
+			ctx := logger.WithRequestID(tp.ctx, generationRequestID(meme.Generation))
+			slog.InfoContext(ctx, "rescheduling stuck meme",
+				"meme_id", meme.ID, "status", meme.Status, "status_updated_at", meme.StatusUpdatedAt)
 
 			meme.Status = "pending"
-			if err := tp.memeRepo.Update(tp.ctx, meme); err != nil {
-				log.Printf("Failed to reset status for meme %s: %v", meme.ID, err)
+			if err := tp.memeRepo.UpdateGeneration(tp.ctx, meme); err != nil {
+				slog.ErrorContext(ctx, "failed to reset meme status", "meme_id", meme.ID, "error", err)
 				continue
 			}
 
 			if err := tp.AddTask(meme.ID); err != nil {
-				log.Printf("Failed to reschedule meme %s: %v", meme.ID, err)
+				slog.ErrorContext(ctx, "failed to reschedule meme", "meme_id", meme.ID, "error", err)
 			}
 		} else {
-			log.Printf("Meme %s is already in processing, skipping", meme.ID)
+			slog.Debug("meme is already in processing, skipping", "meme_id", meme.ID)
 		}
 	}
-	log.Printf("Scan completed: rescheduled %d memes", len(stuckMemes))
+	slog.Debug("stuck tasks scan completed", "count", len(stuckMemes))
+}
+
+// QueueDepth - задачи, ожидающие свободного воркера
+func (tp *TaskProcessor) QueueDepth() int {
+	return len(tp.taskQueue)
+}
+
+// InFlight - мемы в очереди или в обработке
+func (tp *TaskProcessor) InFlight() int {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return len(tp.processingIDs)
+}
+
+func (tp *TaskProcessor) BusyWorkers() int {
+	return int(tp.busyWorkers.Load())
+}
+
+// Running - воркеры запущены и процессор ещё не останавливается
+func (tp *TaskProcessor) Running() bool {
+	return tp.running.Load()
+}
+
+func (tp *TaskProcessor) Workers() int {
+	return tp.workers
 }
 
 func (tp *TaskProcessor) AddTask(memeID uuid.UUID) error {
 	tp.mu.Lock()
 	if tp.processingIDs[memeID] {
 		tp.mu.Unlock()
-		log.Printf("Task for meme %s is already being processed", memeID)
+		slog.Debug("task is already being processed", "meme_id", memeID)
 		return nil
 	}
 	tp.processingIDs[memeID] = true
 	tp.mu.Unlock()
 
 	select {
-	case tp.taskQueue <- memeID:
-		log.Printf("Task added to queue: meme_id=%s", memeID)
+	case tp.taskQueue <- queuedTask{memeID: memeID, enqueuedAt: time.Now()}:
+		slog.Debug("task added to queue", "meme_id", memeID)
 		return nil
 	case <-tp.ctx.Done():
 		tp.mu.Lock()
@@ -156,48 +210,68 @@ func (tp *TaskProcessor) AddTask(memeID uuid.UUID) error {
 
 func (tp *TaskProcessor) worker(id int) {
 	defer tp.wg.Done()
-	log.Printf("Worker %d started", id)
+	slog.Debug("worker started", "worker", id)
 
 	for {
 		select {
 		case <-tp.ctx.Done():
-			log.Printf("Worker %d stopped", id)
+			slog.Debug("worker stopped", "worker", id)
 			return
-		case memeID, ok := <-tp.taskQueue:
+		case task, ok := <-tp.taskQueue:
 			if !ok {
-				log.Printf("Worker %d: task queue closed", id)
+				slog.Debug("task queue closed", "worker", id)
 				return
 			}
-			tp.processTask(id, memeID)
+			tp.busyWorkers.Add(1)
+			tp.processTask(id, task)
+			tp.busyWorkers.Add(-1)
 		}
 	}
 }
 
-func (tp *TaskProcessor) processTask(workerID int, memeID uuid.UUID) {
+func (tp *TaskProcessor) processTask(workerID int, task queuedTask) {
+	memeID := task.memeID
+	queueWait := time.Since(task.enqueuedAt)
+
 	defer func() {
 		tp.mu.Lock()
 		delete(tp.processingIDs, memeID)
 		tp.mu.Unlock()
 	}()
 
-	log.Printf("Worker %d: processing task for meme %s", workerID, memeID)
+	log := slog.With("worker", workerID, "meme_id", memeID)
 
 	meme, err := tp.memeRepo.GetByID(tp.ctx, memeID)
 	if err != nil {
-		log.Printf("Worker %d: failed to get meme %s: %v", workerID, memeID, err)
+		log.Error("failed to get meme", "error", err)
 		return
 	}
 
+	// Логи и запросы к AI-сервису продолжают запрос, который создал мем
+	ctx := logger.WithRequestID(tp.ctx, generationRequestID(meme.Generation))
+	ctx, span := tracing.StartLinked(ctx, "task_processor process", generationTraceParent(meme.Generation),
+		attribute.String("meme.id", memeID.String()),
+		attribute.String("ai.task_id", meme.TaskID),
+		attribute.String("ai.backend", meme.AIBackend),
+		attribute.Int("task_processor.worker", workerID),
+		attribute.Int64("task_processor.queue_wait_ms", queueWait.Milliseconds()),
+	)
+	defer span.End()
+	log.InfoContext(ctx, "processing task", "task_id", meme.TaskID, "queue_wait", queueWait)
+
 	if meme.Status == "completed" {
-		log.Printf("Worker %d: meme %s already completed", workerID, memeID)
+		log.InfoContext(ctx, "meme already completed")
 		return
 	}
 
 	if meme.TaskID == "" {
-		log.Printf("Worker %d: meme %s has no task_id", workerID, memeID)
+		log.WarnContext(ctx, "meme has no task_id")
 		return
 	}
 
+	// Сохраняется вместе с первым обновлением статуса
+	meme.SetQueueWait(queueWait)
+
 	ticker := time.NewTicker(tp.pollInterval)
 	defer ticker.Stop()
 
@@ -207,88 +281,128 @@ func (tp *TaskProcessor) processTask(workerID int, memeID uuid.UUID) {
 	for {
 		select {
 		case <-tp.ctx.Done():
-			log.Printf("Worker %d: context cancelled, stopping task for meme %s", workerID, memeID)
+			log.InfoContext(ctx, "context cancelled, stopping task")
 			return
 		case <-ticker.C:
+			// Пока circuit breaker бэкенда разомкнут, он не опрашивается и попытки не расходуются
+			if !tp.aiSvc.Available(meme.AIBackend) {
+				log.DebugContext(ctx, "AI backend unavailable, polling paused", "backend", meme.AIBackend)
+				continue
+			}
+
 			attempts++
 			if attempts > maxAttempts {
-				log.Printf("Worker %d: max attempts reached for meme %s - will be retried by scanner", workerID, memeID)
+				log.WarnContext(ctx, "max attempts reached, will be retried by scanner", "attempts", maxAttempts)
 				return
 			}
 
-			log.Printf("Worker %d: checking status for meme %s (attempt %d/%d)", workerID, memeID, attempts, maxAttempts)
-
-			taskStatus, err := tp.aiSvc.GetTaskStatus(tp.ctx, meme.TaskID)
+			taskStatus, err := tp.aiSvc.GetTaskStatus(ctx, meme.AIBackend, meme.TaskID)
 			if err != nil {
-				log.Printf("Worker %d: failed to check task status for meme %s: %v", workerID, memeID, err)
+				if errors.Is(err, ErrUnknownAIBackend) {
+					log.ErrorContext(ctx, "meme task belongs to unknown AI backend", "backend", meme.AIBackend)
+					tp.markAsFailed(ctx, memeID, err.Error())
+					return
+				}
+				log.WarnContext(ctx, "failed to check task status", "attempt", attempts, "error", err)
 				continue
 			}
 
-			log.Printf("Worker %d: meme %s AI status: %s", workerID, memeID, taskStatus.Status)
+			log.DebugContext(ctx, "AI task status", "attempt", attempts, "status", taskStatus.Status)
 
 			meme.Status = taskStatus.Status
-			if err := tp.memeRepo.Update(tp.ctx, meme); err != nil {
-				log.Printf("Worker %d: failed to update meme status: %v", workerID, err)
+			if taskStatus.Model != "" {
+				meme.SetGenerationModel(taskStatus.Model)
+			}
+			if err := tp.memeRepo.UpdateGeneration(ctx, meme); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					log.InfoContext(ctx, "meme was moved to trash, stopping task")
+					return
+				}
+				log.ErrorContext(ctx, "failed to update meme status", "error", err)
 			}
 
 			if taskStatus.Status == "completed" || taskStatus.Status == "SUCCESS" || taskStatus.Status == "success" {
-				log.Printf("Worker %d: task completed for meme %s, fetching result", workerID, memeID)
-				if err := tp.processCompletedTask(memeID); err != nil {
-					log.Printf("Worker %d: failed to process completed task: %v", workerID, err)
-					tp.markAsFailed(memeID, fmt.Sprintf("failed to process result: %v", err))
+				log.InfoContext(ctx, "task completed, fetching result")
+				if err := tp.processCompletedTask(ctx, memeID); errors.Is(err, gorm.ErrRecordNotFound) {
+					log.InfoContext(ctx, "meme was moved to trash, result discarded")
+				} else if err != nil {
+					log.ErrorContext(ctx, "failed to process completed task", "error", err)
+					tp.markAsFailed(ctx, memeID, fmt.Sprintf("failed to process result: %v", err))
 				} else {
-					log.Printf("Worker %d: successfully processed meme %s", workerID, memeID)
+					log.InfoContext(ctx, "meme processed successfully")
 				}
 				return
 			}
 
 			if taskStatus.Status == "failed" || taskStatus.Status == "FAILED" || taskStatus.Status == "error" || taskStatus.Status == "ERROR" {
-				log.Printf("Worker %d: AI returned failed for meme %s", workerID, memeID)
-				tp.markAsFailed(memeID, "AI task failed")
+				log.WarnContext(ctx, "AI task failed", "status", taskStatus.Status)
+				tp.markAsFailed(ctx, memeID, "AI task failed")
 				return
 			}
 		}
 	}
 }
 
-func (tp *TaskProcessor) processCompletedTask(memeID uuid.UUID) error {
-	meme, err := tp.memeRepo.GetByID(tp.ctx, memeID)
+func (tp *TaskProcessor) processCompletedTask(ctx context.Context, memeID uuid.UUID) error {
+	meme, err := tp.memeRepo.GetByID(ctx, memeID)
 	if err != nil {
 		return fmt.Errorf("failed to get meme: %w", err)
 	}
 
-	imageData, err := tp.aiSvc.GetTaskResult(tp.ctx, meme.TaskID)
+	imageData, err := tp.aiSvc.GetTaskResult(ctx, meme.AIBackend, meme.TaskID)
 	if err != nil {
 		return fmt.Errorf("failed to get task result: %w", err)
 	}
 
 	objectName := fmt.Sprintf("memes/%s.jpg", meme.ID.String())
-	if err := tp.minioSvc.UploadBytes(tp.ctx, objectName, imageData); err != nil {
+	if err := tp.minioSvc.UploadBytes(ctx, objectName, imageData); err != nil {
 		return fmt.Errorf("failed to upload image to MinIO: %w", err)
 	}
 
 	meme.ImageURL = tp.minioSvc.GetMemeURL(objectName)
+	meme.ThumbnailURL = tp.thumbnailer.Store(ctx, objectName, imageData)
 	meme.Status = "completed"
+	meme.MarkGenerationCompleted(time.Now())
 
-	if err := tp.memeRepo.Update(tp.ctx, meme); err != nil {
-		tp.minioSvc.DeleteMeme(tp.ctx, objectName)
+	if err := tp.memeRepo.UpdateGeneration(ctx, meme); err != nil {
+		tp.minioSvc.DeleteMeme(ctx, objectName)
+		tp.thumbnailer.Delete(ctx, meme.ThumbnailURL, meme.ImageURL)
 		return fmt.Errorf("failed to update meme: %w", err)
 	}
 
 	return nil
 }
 
-func (tp *TaskProcessor) markAsFailed(memeID uuid.UUID, reason string) {
-	meme, err := tp.memeRepo.GetByID(tp.ctx, memeID)
+func (tp *TaskProcessor) markAsFailed(ctx context.Context, memeID uuid.UUID, reason string) {
+	trace.SpanFromContext(ctx).SetStatus(codes.Error, reason)
+
+	meme, err := tp.memeRepo.GetByID(ctx, memeID)
+	if err == nil {
+		meme.Status = "failed"
+		err = tp.memeRepo.UpdateGeneration(ctx, meme)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.InfoContext(ctx, "meme was moved to trash, not marking as failed", "meme_id", memeID)
+		return
+	}
 	if err != nil {
-		log.Printf("Failed to get meme %s for marking as failed: %v", memeID, err)
+		slog.ErrorContext(ctx, "failed to mark meme as failed", "meme_id", memeID, "error", err)
 		return
 	}
 
-	meme.Status = "failed"
-	if err := tp.memeRepo.Update(tp.ctx, meme); err != nil {
-		log.Printf("Failed to mark meme %s as failed: %v", memeID, err)
+	slog.WarnContext(ctx, "meme marked as failed", "meme_id", memeID, "reason", reason)
+}
+
+func generationRequestID(generation *models.GenerationInfo) string {
+	if generation == nil {
+		return ""
 	}
+	return generation.RequestID
+}
 
-	log.Printf("Meme %s marked as failed: %s", memeID, reason)
+func generationTraceParent(generation *models.GenerationInfo) string {
+	if generation == nil {
+		return ""
+	}
+	return generation.TraceParent
 }
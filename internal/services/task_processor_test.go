@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/mockai"
+	"memology-backend/internal/models"
+	"memology-backend/internal/repository"
+	"memology-backend/internal/repository/memory"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// processorEnv - TaskProcessor и MemeService над репозиториями в памяти и заглушкой AI
+type processorEnv struct {
+	memes     repository.MemeRepository
+	processor *TaskProcessor
+	memeSvc   MemeService
+	user      *models.User
+}
+
+func newProcessorEnv(t *testing.T, aiCfg mockai.Config) *processorEnv {
+	t.Helper()
+
+	aiServer := httptest.NewServer(mockai.New(aiCfg))
+	t.Cleanup(aiServer.Close)
+
+	cfg := &config.Config{
+		AI: config.AIConfig{
+			BaseURL:         aiServer.URL,
+			Timeout:         5 * time.Second,
+			RetryAttempts:   1,
+			RetryBackoff:    10 * time.Millisecond,
+			BreakerFailures: 100,
+			BreakerCooldown: time.Second,
+		},
+		Storage: config.StorageConfig{
+			Backend:        "local",
+			LocalDir:       t.TempDir(),
+			LocalPublicURL: "http://storage.test/files",
+		},
+		TaskProcessor: config.TaskProcessorConfig{Workers: 1, QueueSize: 10, PollInterval: 10 * time.Millisecond},
+		Tags:          config.TagsConfig{MaxPerMeme: 10, AutoLimit: 3},
+	}
+
+	storage, err := NewStorageService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := memory.NewDB()
+	user := &models.User{Username: "author", Email: "author@example.com", PasswordHash: "hash"}
+	if err := memory.NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	memes := memory.NewMemeRepository(db)
+	aiSvc := NewAIService(&cfg.AI)
+	processor := NewTaskProcessor(cfg, memes, aiSvc, storage)
+	// Стиль не задаётся, поэтому каталог стилей не нужен
+	memeSvc := NewMemeServiceWithProcessor(cfg, memes, memory.NewTagRepository(db), storage, aiSvc, nil, processor)
+
+	return &processorEnv{memes: memes, processor: processor, memeSvc: memeSvc, user: user}
+}
+
+func (e *processorEnv) waitFor(t *testing.T, id uuid.UUID, done func(*models.Meme) bool) *models.Meme {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		meme, err := e.memes.GetByID(context.Background(), id)
+		if err == nil && done(meme) {
+			return meme
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("meme %s did not reach expected state: %+v, %v", id, meme, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTaskProcessorKeepsConcurrentEdit(t *testing.T) {
+	ctx := context.Background()
+	env := newProcessorEnv(t, mockai.Config{Seed: 1})
+
+	meme, err := env.memeSvc.CreateMeme(ctx, env.user.ID, CreateMemeRequest{Prompt: "кот программист"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Правка, пока задача ждёт воркера: процессор прочитает мем уже после неё,
+	// а статус будет обновлять много раз
+	title, private := "Понедельник", false
+	edited, err := env.memeSvc.UpdateMeme(ctx, env.user.ID, meme.ID, UpdateMemeRequest{Title: &title, IsPublic: &private})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.processor.StartWorkers()
+	defer env.processor.Stop()
+
+	// Вторая правка во время генерации
+	description := "Когда пришёл на работу"
+	edited, err = env.memeSvc.UpdateMeme(ctx, env.user.ID, meme.ID, UpdateMemeRequest{Description: &description, UpdatedAt: &edited.UpdatedAt})
+	if err != nil {
+		t.Fatalf("edit during generation: %v", err)
+	}
+
+	// Статус completed сохраняется чуть раньше, чем картинка
+	completed := env.waitFor(t, meme.ID, func(m *models.Meme) bool { return m.Status == "completed" && m.ImageURL != "" })
+	if completed.Title != title || completed.Description != description || completed.IsPublic {
+		t.Fatalf("generation reverted the edit: title %q description %q public %v",
+			completed.Title, completed.Description, completed.IsPublic)
+	}
+	if !completed.UpdatedAt.Equal(edited.UpdatedAt) {
+		t.Fatalf("generation changed updated_at from %v to %v", edited.UpdatedAt, completed.UpdatedAt)
+	}
+
+	// ETag из ответа на последнюю правку всё ещё действителен
+	prompt := "кот тимлид"
+	if _, err := env.memeSvc.UpdateMeme(ctx, env.user.ID, meme.ID, UpdateMemeRequest{Prompt: &prompt, UpdatedAt: &edited.UpdatedAt}); err != nil {
+		t.Fatalf("edit with ETag from before completion: %v", err)
+	}
+}
+
+func TestTaskProcessorDoesNotRestoreTrashedMeme(t *testing.T) {
+	ctx := context.Background()
+	statuses := []string{"pending"}
+	for i := 0; i < 20; i++ {
+		statuses = append(statuses, "processing")
+	}
+	env := newProcessorEnv(t, mockai.Config{Seed: 1, Statuses: append(statuses, "completed")})
+
+	meme, err := env.memeSvc.CreateMeme(ctx, env.user.ID, CreateMemeRequest{Prompt: "кот программист"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.processor.StartWorkers()
+	defer env.processor.Stop()
+
+	env.waitFor(t, meme.ID, func(m *models.Meme) bool { return m.Status == "processing" })
+	if err := env.memeSvc.DeleteMeme(ctx, env.user.ID, meme.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for env.processor.InFlight() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("processor did not stop processing trashed meme")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := env.memes.GetByID(ctx, meme.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("trashed meme was restored: %v", err)
+	}
+	trashed, err := env.memes.GetDeletedByID(ctx, meme.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trashed.Status == "completed" || trashed.ImageURL != "" {
+		t.Fatalf("trashed meme was completed: status %q image %q", trashed.Status, trashed.ImageURL)
+	}
+}
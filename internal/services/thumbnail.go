@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"path"
+	"strings"
+
+	"memology-backend/internal/config"
+)
+
+// thumbnailSamples - сколько точек исходника по каждой оси усредняется в один пиксель превью.
+// Полное усреднение большой картинки дорогое, а для превью хватает выборки
+const thumbnailSamples = 4
+
+// Thumbnailer делает превью мемов и кладёт их в хранилище рядом с оригиналом:
+// memes/<id>.png -> memes/<id>_thumb.png. Превью нужно лентам, чтобы не грузить оригиналы
+type Thumbnailer struct {
+	storage      MinIOService
+	maxSize      int
+	maxDimension int
+}
+
+func NewThumbnailer(cfg *config.Config, storage MinIOService) *Thumbnailer {
+	return &Thumbnailer{
+		storage:      storage,
+		maxSize:      cfg.Thumbnail.MaxSize,
+		maxDimension: cfg.Upload.MaxDimension,
+	}
+}
+
+// Store делает превью картинки, сохранённой под objectName, и возвращает его URL.
+// Если картинка не больше превью, возвращается URL оригинала. Превью не обязательно:
+// при ошибке она пишется в лог, а клиенты показывают image_url
+func (t *Thumbnailer) Store(ctx context.Context, objectName string, data []byte) string {
+	if t.maxSize <= 0 {
+		return ""
+	}
+
+	thumb, ext, err := makeThumbnail(data, t.maxSize, t.maxDimension)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to make thumbnail", "object", objectName, "error", err)
+		return ""
+	}
+	if thumb == nil {
+		return t.storage.GetMemeURL(objectName)
+	}
+
+	thumbName := thumbnailObjectName(objectName, ext)
+	if err := t.storage.UploadBytes(ctx, thumbName, thumb); err != nil {
+		slog.WarnContext(ctx, "failed to upload thumbnail", "object", thumbName, "error", err)
+		return ""
+	}
+	return t.storage.GetMemeURL(thumbName)
+}
+
+// Delete удаляет превью, если это отдельный объект, а не сам оригинал
+func (t *Thumbnailer) Delete(ctx context.Context, thumbnailURL, imageURL string) error {
+	return deleteThumbnail(ctx, t.storage, thumbnailURL, imageURL)
+}
+
+func deleteThumbnail(ctx context.Context, storage MinIOService, thumbnailURL, imageURL string) error {
+	if thumbnailURL == "" || thumbnailURL == imageURL {
+		return nil
+	}
+	if objectName, ok := storage.ObjectNameFromURL(thumbnailURL); ok {
+		return storage.DeleteMeme(ctx, objectName)
+	}
+	return nil
+}
+
+func thumbnailObjectName(objectName, ext string) string {
+	return strings.TrimSuffix(objectName, path.Ext(objectName)) + "_thumb." + ext
+}
+
+// makeThumbnail уменьшает картинку так, чтобы большая сторона была не больше maxSize.
+// JPEG остаётся JPEG, остальное кодируется в PNG, чтобы сохранить прозрачность; у GIF
+// берётся первый кадр. nil без ошибки - картинка и так не больше превью
+func makeThumbnail(data []byte, maxSize, maxDimension int) ([]byte, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, "", fmt.Errorf("%w: image is %dx%d, limit %d pixels per side", ErrInvalidFile, cfg.Width, cfg.Height, maxDimension)
+	}
+	if cfg.Width <= maxSize && cfg.Height <= maxSize {
+		return nil, "", nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	width, height := maxSize, cfg.Height*maxSize/cfg.Width
+	if cfg.Height > cfg.Width {
+		width, height = cfg.Width*maxSize/cfg.Height, maxSize
+	}
+	dst := downscale(src, max(width, 1), max(height, 1))
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "jpg", err
+	}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), "png", err
+}
+
+// downscale усредняет по сетке из thumbnailSamples x thumbnailSamples точек области
+// исходника, которая попадает в каждый пиксель результата
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b, a uint32
+			for sy := 0; sy < thumbnailSamples; sy++ {
+				py := bounds.Min.Y + ((y*thumbnailSamples+sy)*bounds.Dy()+bounds.Dy()/2)/(height*thumbnailSamples)
+				for sx := 0; sx < thumbnailSamples; sx++ {
+					px := bounds.Min.X + ((x*thumbnailSamples+sx)*bounds.Dx()+bounds.Dx()/2)/(width*thumbnailSamples)
+					cr, cg, cb, ca := src.At(px, py).RGBA()
+					r, g, b, a = r+cr, g+cg, b+cb, a+ca
+				}
+			}
+			n := uint32(thumbnailSamples * thumbnailSamples)
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"memology-backend/internal/config"
+)
+
+func encodeTestImage(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantExt    string
+		wantWidth  int
+		wantHeight int
+		wantErr    error
+	}{
+		{name: "wide png", data: encodeTestImage(t, "png", 640, 320), wantExt: "png", wantWidth: 100, wantHeight: 50},
+		{name: "tall jpeg", data: encodeTestImage(t, "jpeg", 300, 600), wantExt: "jpg", wantWidth: 50, wantHeight: 100},
+		{name: "already small", data: encodeTestImage(t, "png", 80, 100)},
+		{name: "too large to decode", data: encodeTestImage(t, "png", 1200, 10), wantErr: ErrInvalidFile},
+		{name: "not an image", data: []byte("not an image"), wantErr: ErrInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, ext, err := makeThumbnail(tt.data, 100, 1000)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantExt == "" {
+				if thumb != nil {
+					t.Fatalf("small image must not get a separate thumbnail, got %d bytes", len(thumb))
+				}
+				return
+			}
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ext != tt.wantExt || uploadFormats[format] != tt.wantExt {
+				t.Fatalf("expected %s thumbnail, got ext %s, format %s", tt.wantExt, ext, format)
+			}
+			if cfg.Width != tt.wantWidth || cfg.Height != tt.wantHeight {
+				t.Fatalf("expected %dx%d, got %dx%d", tt.wantWidth, tt.wantHeight, cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
+
+func TestThumbnailerStore(t *testing.T) {
+	ctx := context.Background()
+	storageDir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			Backend:        "local",
+			LocalDir:       storageDir,
+			LocalPublicURL: "http://storage.test/files",
+		},
+		Upload:    config.UploadConfig{MaxDimension: 2048},
+		Thumbnail: config.ThumbnailConfig{MaxSize: 100},
+	}
+	storage, err := NewStorageService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbnailer := NewThumbnailer(cfg, storage)
+
+	url := thumbnailer.Store(ctx, "memes/large.png", encodeTestImage(t, "png", 400, 200))
+	if url != "http://storage.test/files/memes/large_thumb.png" {
+		t.Fatalf("unexpected thumbnail URL %q", url)
+	}
+	if _, err := os.Stat(filepath.Join(storageDir, "memes", "large_thumb.png")); err != nil {
+		t.Fatalf("thumbnail must be stored next to the original: %v", err)
+	}
+
+	// Маленькая картинка сама себе превью
+	if url := thumbnailer.Store(ctx, "memes/small.png", encodeTestImage(t, "png", 50, 50)); !strings.HasSuffix(url, "/memes/small.png") {
+		t.Fatalf("small image must use the original as thumbnail, got %q", url)
+	}
+
+	cfg.Thumbnail.MaxSize = 0
+	if url := NewThumbnailer(cfg, storage).Store(ctx, "memes/large.png", encodeTestImage(t, "png", 400, 200)); url != "" {
+		t.Fatalf("disabled thumbnails must return empty URL, got %q", url)
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"memology-backend/internal/config"
+	"memology-backend/internal/repository"
+)
+
+// trashPurgeBatchSize - сколько мемов удаляется за один проход
+const trashPurgeBatchSize = 100
+
+// TrashPurger окончательно удаляет мемы, пролежавшие в корзине дольше retention,
+// вместе с их файлами в MinIO
+type TrashPurger struct {
+	memeRepo  repository.MemeRepository
+	minioSvc  MinIOService
+	retention time.Duration
+	interval  time.Duration
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+func NewTrashPurger(cfg *config.TrashConfig, memeRepo repository.MemeRepository, minioSvc MinIOService) *TrashPurger {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &TrashPurger{
+		memeRepo:  memeRepo,
+		minioSvc:  minioSvc,
+		retention: cfg.Retention,
+		interval:  cfg.PurgeInterval,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+func (p *TrashPurger) Start() {
+	slog.Info("starting trash purger", "retention", p.retention, "interval", p.interval)
+
+	p.wg.Add(1)
+	go p.loop()
+}
+
+func (p *TrashPurger) Stop() {
+	slog.Info("stopping trash purger")
+	p.cancel()
+	p.wg.Wait()
+	slog.Info("trash purger stopped")
+}
+
+func (p *TrashPurger) loop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.purge()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge()
+		}
+	}
+}
+
+func (p *TrashPurger) purge() {
+	purged, err := p.Purge(p.ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		slog.Error("trash purge failed", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("trash purge completed", "purged", purged)
+	}
+}
+
+// Purge удаляет все мемы, попавшие в корзину раньше before, и возвращает их количество.
+// Если файл удалить не удалось, запись остаётся до следующего прохода.
+func (p *TrashPurger) Purge(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+
+	for {
+		memes, err := p.memeRepo.FindDeletedBefore(ctx, before, trashPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		batchPurged := 0
+		for _, meme := range memes {
+			if objectName, ok := p.minioSvc.ObjectNameFromURL(meme.ImageURL); ok {
+				if err := p.minioSvc.DeleteMeme(ctx, objectName); err != nil {
+					slog.ErrorContext(ctx, "failed to delete image of purged meme", "meme_id", meme.ID, "error", err)
+					continue
+				}
+			}
+			if err := deleteThumbnail(ctx, p.minioSvc, meme.ThumbnailURL, meme.ImageURL); err != nil {
+				slog.ErrorContext(ctx, "failed to delete thumbnail of purged meme", "meme_id", meme.ID, "error", err)
+				continue
+			}
+
+			if err := p.memeRepo.HardDelete(ctx, meme.ID); err != nil {
+				slog.ErrorContext(ctx, "failed to permanently delete meme", "meme_id", meme.ID, "error", err)
+				continue
+			}
+
+			batchPurged++
+		}
+
+		purged += batchPurged
+
+		if len(memes) < trashPurgeBatchSize || batchPurged == 0 {
+			return purged, nil
+		}
+	}
+}
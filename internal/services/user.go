@@ -117,3 +117,40 @@ func (s *userService) DeleteAccount(ctx context.Context, userID uuid.UUID) error
 func (s *userService) GetUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
 	return s.userRepo.List(ctx, limit, offset)
 }
+
+// SetActive включает или блокирует пользователя. Заблокированный не может войти
+// и обновить токены; уже выданные сессии отзывает AuthService.LogoutAll
+func (s *userService) SetActive(ctx context.Context, userID uuid.UUID, active bool) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.IsActive = active
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResetPassword задаёт пароль без проверки текущего - для администратора
+func (s *userService) ResetPassword(ctx context.Context, userID uuid.UUID, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	newPasswordHash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = newPasswordHash
+	return s.userRepo.Update(ctx, user)
+}
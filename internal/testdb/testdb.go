@@ -0,0 +1,219 @@
+// Package testdb поднимает PostgreSQL для тестов. База берётся из TEST_DATABASE_URL
+// (на сервере создаётся и потом удаляется отдельная база) или запускается embedded-postgres
+// из кэша бинарников (EMBEDDED_POSTGRES_CACHE, по умолчанию ~/.embedded-postgres-go).
+// Бинарники скачиваются только с EMBEDDED_POSTGRES_DOWNLOAD=1, иначе без базы Open
+// возвращает ErrNoDatabase и тесты с базой пропускаются. С TEST_DATABASE_REQUIRED=1
+// (в CI) они падают: там пропуск означал бы, что ничего не проверено
+package testdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"memology-backend/internal/database"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrNoDatabase - не задан TEST_DATABASE_URL и в кэше нет бинарников embedded-postgres
+var ErrNoDatabase = errors.New("TEST_DATABASE_URL is not set and embedded-postgres binaries are not cached " +
+	"(set EMBEDDED_POSTGRES_CACHE or EMBEDDED_POSTGRES_DOWNLOAD=1)")
+
+// Required сообщает, что база обязательна (TEST_DATABASE_REQUIRED=1): без неё TestMain
+// должен завершаться ошибкой, а не пропускать тесты
+func Required() bool {
+	return os.Getenv("TEST_DATABASE_REQUIRED") == "1"
+}
+
+// Open возвращает пустую базу со схемой приложения и функцию, которая её удаляет
+func Open() (*gorm.DB, func(), error) {
+	db, stop, err := start()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := database.Migrate(db); err != nil {
+		stop()
+		return nil, nil, fmt.Errorf("failed to migrate test database: %w", err)
+	}
+	return db, stop, nil
+}
+
+// OpenEmpty - как Open, но без миграций: для тестов самих миграций
+func OpenEmpty() (*gorm.DB, func(), error) {
+	return start()
+}
+
+func start() (*gorm.DB, func(), error) {
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		return createDatabase(dsn)
+	}
+	return startEmbedded()
+}
+
+// createDatabase создаёт на сервере из dsn отдельную базу со случайным именем,
+// чтобы тесты не трогали данные и не зависели от предыдущих запусков
+func createDatabase(dsn string) (*gorm.DB, func(), error) {
+	admin, err := open(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := "memology_test_" + hex.EncodeToString(suffix)
+
+	if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		closeDB(admin)
+		return nil, nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	testDSN, err := withDatabase(dsn, name)
+	if err != nil {
+		closeDB(admin)
+		return nil, nil, err
+	}
+	db, err := open(testDSN)
+	if err != nil {
+		admin.Exec("DROP DATABASE IF EXISTS " + name)
+		closeDB(admin)
+		return nil, nil, err
+	}
+
+	return db, func() {
+		closeDB(db)
+		admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)")
+		closeDB(admin)
+	}, nil
+}
+
+// withDatabase подставляет имя базы в DSN формата URL или key=value
+func withDatabase(dsn, name string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("invalid TEST_DATABASE_URL: %w", err)
+		}
+		u.Path = "/" + name
+		return u.String(), nil
+	}
+
+	var fields []string
+	for _, field := range strings.Fields(dsn) {
+		if !strings.HasPrefix(field, "dbname=") {
+			fields = append(fields, field)
+		}
+	}
+	return strings.Join(append(fields, "dbname="+name), " "), nil
+}
+
+func startEmbedded() (*gorm.DB, func(), error) {
+	if os.Getenv("EMBEDDED_POSTGRES_DOWNLOAD") != "1" && !cachedBinaries() {
+		return nil, nil, ErrNoDatabase
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runtimeDir, err := os.MkdirTemp("", "memology-pg-*")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgConfig := embeddedpostgres.DefaultConfig().
+		Port(uint32(port)).
+		Database("memology_test").
+		RuntimePath(runtimeDir).
+		DataPath(filepath.Join(runtimeDir, "data")).
+		StartTimeout(time.Minute).
+		Logger(io.Discard)
+	if cache := os.Getenv("EMBEDDED_POSTGRES_CACHE"); cache != "" {
+		pgConfig = pgConfig.CachePath(cache)
+	}
+
+	pg := embeddedpostgres.NewDatabase(pgConfig)
+	if err := pg.Start(); err != nil {
+		os.RemoveAll(runtimeDir)
+		return nil, nil, fmt.Errorf("failed to start embedded postgres: %w", err)
+	}
+
+	stop := func() {
+		pg.Stop()
+		os.RemoveAll(runtimeDir)
+	}
+
+	dsn := fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=memology_test sslmode=disable", port)
+	db, err := open(dsn)
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return db, func() {
+		closeDB(db)
+		stop()
+	}, nil
+}
+
+// cachedBinaries проверяет, что архив с бинарниками уже лежит в кэше embedded-postgres
+func cachedBinaries() bool {
+	cache := os.Getenv("EMBEDDED_POSTGRES_CACHE")
+	if cache == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		cache = filepath.Join(home, ".embedded-postgres-go")
+	}
+	matches, _ := filepath.Glob(filepath.Join(cache, "embedded-postgres-binaries-*.txz"))
+	return len(matches) > 0
+}
+
+func open(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping: %w", err)
+	}
+	return db, nil
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -47,6 +47,7 @@ func (j *JWTManager) GenerateTokens(userID uuid.UUID, username string, isActive
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Subject:   userID.String(),
+			ID:        uuid.NewString(),
 		},
 	}
 
@@ -65,6 +66,9 @@ func (j *JWTManager) GenerateTokens(userID uuid.UUID, username string, isActive
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Subject:   userID.String(),
+			// Без уникального id токены, выданные в одну секунду, совпадают, и
+			// отозванный при ротации refresh-токен оставался бы действительным
+			ID: uuid.NewString(),
 		},
 	}
 